@@ -0,0 +1,104 @@
+package logfire
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// maxInlineAttachmentSize is the most bytes of an attachment that are recorded in an
+// attribute when there is no BlobStore.
+const maxInlineAttachmentSize = 64 * 1024
+
+// BlobStore stores attachments that are too large to record on spans, such as request
+// dumps or CSV files.
+type BlobStore interface {
+	// Put stores the content of r, and returns the URL it can be downloaded from.
+	Put(ctx context.Context, name string, r io.Reader, contentType string) (url string, err error)
+}
+
+// Attach records a debug artifact, such as a request dump or a CSV file, on the span in
+// ctx.  The artifact is streamed to the BlobStore set with WithBlobStore, and its URL
+// recorded in the logfire.attachment.<name>.url attribute.  Without a BlobStore, the
+// first 64 KiB are recorded in the logfire.attachment.<name> attribute, base64 encoded
+// if they aren't valid UTF-8.
+//
+//	logfire.Attach(ctx, "response", resp.Body, resp.Header.Get("Content-Type"))
+//
+// Nothing is read from r if the span isn't recording.
+func Attach(ctx context.Context, name string, r io.Reader, contentType string) error {
+	span := oteltrace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+
+	key := "logfire.attachment." + name
+	attrs := []attribute.KeyValue{attribute.String(key+".content_type", contentType)}
+	counter := &countingReader{Reader: r}
+
+	if globalBlobStore != nil {
+		url, err := globalBlobStore.Put(ctx, name, counter, contentType)
+		if err != nil {
+			return fmt.Errorf("failed to store attachment %q: %w", name, err)
+		}
+		attrs = append(attrs,
+			attribute.String(key+".url", url),
+			attribute.Int64(key+".size", counter.n),
+		)
+		span.SetAttributes(attrs...)
+		return nil
+	}
+
+	content, err := io.ReadAll(io.LimitReader(counter, maxInlineAttachmentSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read attachment %q: %w", name, err)
+	}
+	truncated := len(content) > maxInlineAttachmentSize
+	if truncated {
+		content = content[:maxInlineAttachmentSize]
+	}
+	if truncated && !utf8.Valid(content) {
+		// The content may only be invalid because a character was cut in half.
+		if i := lastRuneStart(content); utf8.Valid(content[:i]) {
+			content = content[:i]
+		}
+	}
+	value := string(content)
+	if !utf8.Valid(content) {
+		value = base64.StdEncoding.EncodeToString(content)
+		attrs = append(attrs, attribute.String(key+".encoding", "base64"))
+	}
+	attrs = append(attrs,
+		attribute.String(key, value),
+		attribute.Bool(key+".truncated", truncated),
+	)
+	span.SetAttributes(attrs...)
+	return nil
+}
+
+// lastRuneStart returns the index of the start of the last character in b.
+func lastRuneStart(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			return i
+		}
+	}
+	return len(b)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
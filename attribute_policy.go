@@ -0,0 +1,57 @@
+package logfire
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attributePolicy decides which attribute keys are exported.  Patterns are either an
+// exact key, or a prefix followed by "*", e.g. "http.request.header.*".
+type attributePolicy struct {
+	allow []string
+	deny  []string
+}
+
+// keep reports whether the attribute with key is exported.  Denied keys are never
+// exported, and with an allowlist only allowed keys, and the logfire.* attributes that
+// Logfire needs, are exported.
+func (p *attributePolicy) keep(key attribute.Key) bool {
+	if p == nil {
+		return true
+	}
+	if matchesAny(p.deny, string(key)) {
+		return false
+	}
+	if len(p.allow) == 0 || strings.HasPrefix(string(key), "logfire.") {
+		return true
+	}
+	return matchesAny(p.allow, string(key))
+}
+
+// filter returns the attributes that are exported.
+func (p *attributePolicy) filter(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if p == nil {
+		return attrs
+	}
+	kept := make([]attribute.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		if p.keep(kv.Key) {
+			kept = append(kept, kv)
+		}
+	}
+	return kept
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+	return false
+}
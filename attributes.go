@@ -0,0 +1,108 @@
+package logfire
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AttrValue is the set of value types that Attr accepts.
+type AttrValue interface {
+	string | bool | int | int64 | float64 | time.Time | time.Duration | []string
+}
+
+// Attr creates an attribute with the given key and value.  The type of the value is
+// checked at compile time, and converted to the matching OpenTelemetry type:
+//
+//   - time.Time is stored as an RFC 3339 string.
+//   - time.Duration is stored as a float64 number of milliseconds.
+//
+// Use StringerAttr for values that implement fmt.Stringer.
+func Attr[T AttrValue](key string, value T) attribute.KeyValue {
+	k := attribute.Key(key)
+	switch v := any(value).(type) {
+	case string:
+		return k.String(v)
+	case bool:
+		return k.Bool(v)
+	case int:
+		return k.Int(v)
+	case int64:
+		return k.Int64(v)
+	case float64:
+		return k.Float64(v)
+	case time.Time:
+		return k.String(v.Format(time.RFC3339Nano))
+	case time.Duration:
+		return k.Float64(float64(v) / float64(time.Millisecond))
+	case []string:
+		return k.StringSlice(v)
+	}
+	// Unreachable, the type constraint only allows the types above.
+	panic(fmt.Sprintf("logfire: unsupported attribute type %T", value))
+}
+
+// StringerAttr creates a string attribute from the String method of value.  A nil
+// value is stored as "<nil>", and if String panics, a placeholder describing the panic
+// is stored instead, so a broken Stringer can't crash the caller.
+func StringerAttr(key string, value fmt.Stringer) attribute.KeyValue {
+	if value == nil {
+		return attribute.String(key, "<nil>")
+	}
+	return attribute.String(key, safeString("String", value.String))
+}
+
+// Any creates an attribute from a value of any type.  Strings, booleans and numbers are
+// stored as themselves, time.Time and time.Duration as with Attr, errors and
+// fmt.Stringers as their text, and anything else as JSON, or formatted with %+v if it
+// can't be encoded.  Panics in Error, String or MarshalJSON methods are recovered, and
+// a placeholder describing the panic is stored instead.
+//
+// Use Flatten instead to record each field of a struct or map as its own attribute.
+func Any(key string, value any) attribute.KeyValue {
+	k := attribute.Key(key)
+	switch v := value.(type) {
+	case nil:
+		return k.String("<nil>")
+	case string:
+		return k.String(v)
+	case bool:
+		return k.Bool(v)
+	case int:
+		return k.Int(v)
+	case int64:
+		return k.Int64(v)
+	case float64:
+		return k.Float64(v)
+	case time.Time:
+		return Attr(key, v)
+	case time.Duration:
+		return Attr(key, v)
+	case []string:
+		return k.StringSlice(v)
+	case error:
+		return k.String(safeString("Error", v.Error))
+	case fmt.Stringer:
+		return StringerAttr(key, v)
+	}
+	return k.String(safeString("MarshalJSON", func() string {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprintf("%+v", value)
+		}
+		return string(b)
+	}))
+}
+
+// safeString returns the result of f, the method of a user provided value, or a
+// placeholder if it panics.
+func safeString(method string, f func() string) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("<panic in %s: %v>", method, r)
+		}
+	}()
+	return f()
+}
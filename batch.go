@@ -0,0 +1,152 @@
+package logfire
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// maxBatchEvents is the most logs sent on one span by LogBatch.Send, the default limit
+// of events per span.  Larger batches are split over several spans.
+const maxBatchEvents = 128
+
+// batchRecord is a log kept by a LogBatch.
+type batchRecord struct {
+	time  time.Time
+	level Level
+	msg   string
+	attrs []attribute.KeyValue
+}
+
+// LogBatch accumulates logs and sends them all at once, as events of a single log span,
+// for per-item logs in tight loops where a span per log is too heavy:
+//
+//	batch := logger.Batch()
+//	for _, item := range items {
+//		batch.Info("processed item", logfire.WithAttributes(attribute.String("item.id", item.ID)))
+//	}
+//	batch.Send("processed items")
+//
+// Logs below the minimum level are dropped when they are added.
+type LogBatch struct {
+	ctx context.Context
+
+	mu      sync.Mutex
+	records []batchRecord
+}
+
+// Batch returns a LogBatch that sends its logs in the global span context.
+func Batch() *LogBatch {
+	return globalLogger.Batch()
+}
+
+// Batch returns a LogBatch that sends its logs in the current span context.
+func (s *SpanLogger) Batch() *LogBatch {
+	return &LogBatch{ctx: s.spanCtx}
+}
+
+// Log adds a log with the given level to the batch.  WithAttributes and WithTimestamp
+// apply to the log, other options are ignored.
+func (b *LogBatch) Log(level Level, msg string, opts ...SpanOption) {
+	if level < globalMinLevel && !debugForced(b.ctx) {
+		return
+	}
+	config := newSpanConfig(opts)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.records = append(b.records, batchRecord{
+		time:  config.Timestamp,
+		level: level,
+		msg:   msg,
+		attrs: config.Attributes,
+	})
+}
+
+// Trace adds a log with severity Trace to the batch.
+func (b *LogBatch) Trace(msg string, opts ...SpanOption) {
+	b.Log(LevelTrace, msg, opts...)
+}
+
+// Debug adds a log with severity Debug to the batch.
+func (b *LogBatch) Debug(msg string, opts ...SpanOption) {
+	b.Log(LevelDebug, msg, opts...)
+}
+
+// Info adds a log with severity Info to the batch.
+func (b *LogBatch) Info(msg string, opts ...SpanOption) {
+	b.Log(LevelInfo, msg, opts...)
+}
+
+// Warn adds a log with severity Warn to the batch.
+func (b *LogBatch) Warn(msg string, opts ...SpanOption) {
+	b.Log(LevelWarn, msg, opts...)
+}
+
+// Error adds a log with severity Error to the batch.
+func (b *LogBatch) Error(msg string, opts ...SpanOption) {
+	b.Log(LevelError, msg, opts...)
+}
+
+// Fatal adds a log with severity Fatal to the batch.
+func (b *LogBatch) Fatal(msg string, opts ...SpanOption) {
+	b.Log(LevelFatal, msg, opts...)
+}
+
+// Len returns the number of logs in the batch.
+func (b *LogBatch) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.records)
+}
+
+// Send sends the logs in the batch as events of a log span named msg, with the level
+// of the most severe log, and empties the batch.  The span lasts from the first log to
+// the last.  Nothing is sent if the batch is empty.
+func (b *LogBatch) Send(msg string) {
+	b.mu.Lock()
+	records := b.records
+	b.records = nil
+	b.mu.Unlock()
+
+	for len(records) > 0 {
+		n := min(len(records), maxBatchEvents)
+		b.send(msg, records[:n])
+		records = records[n:]
+	}
+}
+
+// send sends records as one log span.
+func (b *LogBatch) send(msg string, records []batchRecord) {
+	start, end := records[0].time, records[0].time
+	level := records[0].level
+	for _, r := range records[1:] {
+		if r.time.Before(start) {
+			start = r.time
+		}
+		if r.time.After(end) {
+			end = r.time
+		}
+		level = max(level, r.level)
+	}
+
+	tracer, componentAttrs := tracerFor(b.ctx)
+	_, span := tracer.Start(b.ctx, msg,
+		oteltrace.WithTimestamp(start),
+		oteltrace.WithAttributes(componentAttrs...),
+		oteltrace.WithAttributes(
+			attribute.String("logfire.span_type", "log"),
+			attribute.String("logfire.msg_template", "log message template"),
+			attribute.String("logfire.msg", msg),
+			attribute.Int("logfire.level_num", int(level)),
+			attribute.Int("logfire.batch.count", len(records)),
+		),
+	)
+	for _, r := range records {
+		attrs := append([]attribute.KeyValue{attribute.Int("logfire.level_num", int(r.level))}, r.attrs...)
+		span.AddEvent(r.msg, oteltrace.WithTimestamp(r.time), oteltrace.WithAttributes(attrs...))
+	}
+	span.End(oteltrace.WithTimestamp(end))
+}
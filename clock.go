@@ -0,0 +1,38 @@
+package logfire
+
+import "time"
+
+// Clock tells the time for spans, logs and batching.  Replace it with WithClock to get
+// deterministic timestamps and durations in tests and simulations.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTicker returns a Ticker that ticks every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker.
+type Ticker interface {
+	// Chan returns the channel that ticks are delivered on.
+	Chan() <-chan time.Time
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// systemClock is the Clock of the time package.
+type systemClock struct{}
+
+// Now returns time.Now().
+func (systemClock) Now() time.Time { return time.Now() }
+
+// NewTicker returns a time.Ticker.
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+// Chan returns the channel of the time.Ticker.
+func (t systemTicker) Chan() <-chan time.Time { return t.C }
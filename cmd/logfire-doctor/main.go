@@ -0,0 +1,144 @@
+// Command logfire-doctor checks that telemetry can be sent to Logfire.  It validates
+// the token, resolves the endpoint, sends a test span, log and metric, and prints the
+// URL of the test trace.  Use it when onboarding a service, or triaging why telemetry
+// doesn't arrive.
+//
+//	LOGFIRE_TOKEN=... go run github.com/jerechua/logfire-go/cmd/logfire-doctor -project-url https://logfire.pydantic.dev/org/project
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func main() {
+	token := flag.String("token", os.Getenv("LOGFIRE_TOKEN"), "Logfire write token, defaults to $LOGFIRE_TOKEN")
+	endpoint := flag.String("endpoint", logfire.DefaultEndpoint, "Logfire endpoint")
+	projectURL := flag.String("project-url", "", "URL of the Logfire project, to link to the test trace")
+	timeout := flag.Duration("timeout", 30*time.Second, "how long to wait for telemetry to be exported")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	ok := check("token is set", func() error {
+		if strings.TrimSpace(*token) == "" {
+			return errors.New("set $LOGFIRE_TOKEN or -token to a Logfire write token")
+		}
+		return nil
+	})
+	ok = check("endpoint resolves", func() error {
+		return resolve(ctx, *endpoint)
+	}) && ok
+	if !ok {
+		os.Exit(1)
+	}
+
+	// Export errors are reported to the OpenTelemetry error handler.
+	handler := &errorHandler{}
+	otel.SetErrorHandler(handler)
+
+	var traceID oteltrace.TraceID
+	var link string
+	ok = check("telemetry is exported", func() error {
+		closer, err := logfire.Initialize(ctx,
+			logfire.WithServiceName("logfire-doctor"),
+			logfire.WithAPIToken(*token),
+			logfire.WithEndpoint(*endpoint),
+			logfire.WithProjectURL(*projectURL),
+		)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		logger := logfire.NewSpanLogger(ctx, "logfire-doctor test span")
+		traceID = oteltrace.SpanContextFromContext(logger.Context()).TraceID()
+		link = logfire.TraceURL(logger.Context())
+		logger.Info("logfire-doctor test log")
+		counter, err := logfire.Meter().Int64Counter("logfire_doctor.checks")
+		if err != nil {
+			return err
+		}
+		counter.Add(ctx, 1)
+		logger.Close()
+
+		return errors.Join(logfire.Flush(ctx), handler.err())
+	})
+	if !ok {
+		os.Exit(1)
+	}
+
+	fmt.Printf("\ntrace ID: %s\n", traceID)
+	if link != "" {
+		fmt.Printf("trace URL: %s\n", link)
+	}
+}
+
+// check runs a check, printing whether it passed.
+func check(name string, fn func() error) bool {
+	if err := fn(); err != nil {
+		fmt.Printf("FAIL %s: %v\n", name, err)
+		return false
+	}
+	fmt.Printf("ok   %s\n", name)
+	return true
+}
+
+// resolve checks that the host of endpoint resolves, and accepts connections.
+func resolve(ctx context.Context, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", endpoint)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	addrs, err := net.DefaultResolver.LookupHost(ctx, u.Hostname())
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return fmt.Errorf("resolved to %s, but failed to connect: %w", strings.Join(addrs, ", "), err)
+	}
+	return conn.Close()
+}
+
+// errorHandler records the errors reported to OpenTelemetry.
+type errorHandler struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (h *errorHandler) Handle(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errs = append(h.errs, err)
+}
+
+func (h *errorHandler) err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return errors.Join(h.errs...)
+}
@@ -0,0 +1,178 @@
+// Command logfire-relay sends spans on to Logfire for services configured with
+// logfire.WithRelay, so the services never hold the Logfire token.  Run it where the
+// token and the internet are available, and point the services at it:
+//
+//	LOGFIRE_TOKEN=... LOGFIRE_RELAY_SECRET=... go run github.com/jerechua/logfire-go/cmd/logfire-relay -listen :7070
+//
+// Anything that can connect to the relay can send spans with its token, so it listens
+// on the loopback interface unless -listen says otherwise, and only accepts connections
+// that send the secret set with -secret or $LOGFIRE_RELAY_SECRET.  Listen on other
+// interfaces only with a secret, or on a network only trusted services can reach.
+//
+// The first frame of a connection, a big endian uint32 length followed by the secret, is
+// answered with the big endian uint16 status code 200, or 401 before closing the
+// connection if the secret is wrong.  Each later frame, holding a protobuf OTLP
+// ExportTraceServiceRequest, is posted to Logfire, and answered with the status code of
+// Logfire's response, or 502 if Logfire couldn't be reached.
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:7070", "address to listen for services on")
+	token := flag.String("token", os.Getenv("LOGFIRE_TOKEN"), "Logfire write token, defaults to $LOGFIRE_TOKEN")
+	secret := flag.String("secret", os.Getenv("LOGFIRE_RELAY_SECRET"), "secret services must send, defaults to $LOGFIRE_RELAY_SECRET")
+	endpoint := flag.String("endpoint", logfire.DefaultEndpoint, "Logfire endpoint")
+	genericOTLP, _ := strconv.ParseBool(os.Getenv("LOGFIRE_GENERIC_OTLP"))
+	flag.BoolVar(&genericOTLP, "generic-otlp", genericOTLP, "send standard OTLP to -endpoint without a token, defaults to $LOGFIRE_GENERIC_OTLP")
+	timeout := flag.Duration("timeout", 30*time.Second, "how long posting spans to Logfire may take")
+	flag.Parse()
+	if *token == "" && !genericOTLP {
+		log.Fatal("Set $LOGFIRE_TOKEN or -token to a Logfire write token")
+	}
+
+	r := &relay{
+		url:    logfire.TracesURL(*endpoint, genericOTLP),
+		secret: *secret,
+		client: &http.Client{Timeout: *timeout},
+	}
+	if !genericOTLP {
+		r.token = *token
+	}
+	l, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	if *secret == "" && !isLoopback(l.Addr()) {
+		log.Printf("Warning: listening on %s without a secret, anything that can connect can send spans", l.Addr())
+	}
+	log.Printf("Relaying spans from %s to %s", l.Addr(), r.url)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			log.Fatalf("Failed to accept connection: %v", err)
+		}
+		go r.serve(conn)
+	}
+}
+
+// maxSecretSize is the longest secret accepted, so connections can't make the relay
+// allocate a whole frame before they're authenticated.
+const maxSecretSize = 1024
+
+// isLoopback reports whether addr is on the loopback interface.
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// relay posts the spans received from services to Logfire.
+type relay struct {
+	url string
+	// token is the Logfire write token, or empty in generic OTLP mode.
+	token string
+	// secret is the secret services must send, or empty to accept every connection.
+	secret string
+	client *http.Client
+}
+
+// serve relays the frames sent on conn until it is closed.
+func (r *relay) serve(conn net.Conn) {
+	defer conn.Close()
+	if !r.authenticate(conn) {
+		return
+	}
+	for {
+		payload, err := readFrame(conn, logfire.MaxRelayFrameSize)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("Closing connection from %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		var status [2]byte
+		binary.BigEndian.PutUint16(status[:], uint16(r.post(payload)))
+		if _, err := conn.Write(status[:]); err != nil {
+			log.Printf("Closing connection from %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// authenticate reads the secret sent as the first frame of conn, and answers whether it
+// is the relay's secret.
+func (r *relay) authenticate(conn net.Conn) bool {
+	secret, err := readFrame(conn, maxSecretSize)
+	if err != nil {
+		log.Printf("Closing connection from %s: %v", conn.RemoteAddr(), err)
+		return false
+	}
+	status := http.StatusOK
+	if subtle.ConstantTimeCompare(secret, []byte(r.secret)) != 1 {
+		log.Printf("Closing connection from %s: wrong secret", conn.RemoteAddr())
+		status = http.StatusUnauthorized
+	}
+	var answer [2]byte
+	binary.BigEndian.PutUint16(answer[:], uint16(status))
+	if _, err := conn.Write(answer[:]); err != nil {
+		log.Printf("Closing connection from %s: %v", conn.RemoteAddr(), err)
+		return false
+	}
+	return status == http.StatusOK
+}
+
+// post posts the spans to Logfire, and returns the status code of the response.
+func (r *relay) post(payload []byte) int {
+	req, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Failed to create request: %v", err)
+		return http.StatusBadGateway
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		log.Printf("Failed to send spans to Logfire: %v", err)
+		return http.StatusBadGateway
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Printf("Logfire responded with status %s", resp.Status)
+	}
+	return resp.StatusCode
+}
+
+// readFrame reads the payload of a frame of at most maxSize bytes.
+func readFrame(r io.Reader, maxSize uint32) ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(length[:])
+	if n > maxSize {
+		return nil, errors.New("frame is too large")
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// logfireServer returns a fake Logfire that records the bodies and Authorization
+// headers of the requests it receives.
+func logfireServer(t *testing.T) (*httptest.Server, chan *http.Request) {
+	t.Helper()
+	requests := make(chan *http.Request, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		requests <- r
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+// connect serves a connection with the relay, returning the service's end.
+func connect(r *relay) net.Conn {
+	service, conn := net.Pipe()
+	go r.serve(conn)
+	return service
+}
+
+func writeFrame(t *testing.T, conn net.Conn, payload string) uint16 {
+	t.Helper()
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	if _, err := conn.Write(append(frame, payload...)); err != nil {
+		t.Fatalf("failed to write frame: %v", err)
+	}
+	var status [2]byte
+	if _, err := io.ReadFull(conn, status[:]); err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	return binary.BigEndian.Uint16(status[:])
+}
+
+func TestRelayPostsSpans(t *testing.T) {
+	srv, requests := logfireServer(t)
+	r := &relay{url: srv.URL + "/v1/traces", token: "pylf_token", secret: "s3cret", client: srv.Client()}
+	conn := connect(r)
+	defer conn.Close()
+
+	if status := writeFrame(t, conn, "s3cret"); status != http.StatusOK {
+		t.Fatalf("secret answered with %d, want 200", status)
+	}
+	if status := writeFrame(t, conn, "spans"); status != http.StatusOK {
+		t.Errorf("spans answered with %d, want Logfire's 200", status)
+	}
+	req := <-requests
+	if req.URL.Path != "/v1/traces" || req.Header.Get("Authorization") != "Bearer pylf_token" {
+		t.Errorf("posted to %s with Authorization %q", req.URL.Path, req.Header.Get("Authorization"))
+	}
+}
+
+func TestRelayRejectsWrongSecret(t *testing.T) {
+	srv, requests := logfireServer(t)
+	r := &relay{url: srv.URL, token: "pylf_token", secret: "s3cret", client: srv.Client()}
+	conn := connect(r)
+	defer conn.Close()
+
+	if status := writeFrame(t, conn, "guess"); status != http.StatusUnauthorized {
+		t.Fatalf("wrong secret answered with %d, want 401", status)
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("reading after a wrong secret = %v, want the connection closed", err)
+	}
+	select {
+	case <-requests:
+		t.Errorf("the relay posted spans for a connection with the wrong secret")
+	default:
+	}
+}
+
+func TestRelayGenericOTLPSendsNoToken(t *testing.T) {
+	srv, requests := logfireServer(t)
+	r := &relay{url: srv.URL, client: srv.Client()}
+	conn := connect(r)
+	defer conn.Close()
+
+	writeFrame(t, conn, "")
+	writeFrame(t, conn, "spans")
+	if req := <-requests; req.Header.Get("Authorization") != "" {
+		t.Errorf("posted with Authorization %q, want none", req.Header.Get("Authorization"))
+	}
+}
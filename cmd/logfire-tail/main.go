@@ -0,0 +1,124 @@
+// Command logfire-tail follows the telemetry of a local service, like kubectl logs.  It
+// connects to the debug socket the service serves with logfire.WithDebugSocket, and
+// prints every span and log as it is exported.
+//
+//	go run github.com/jerechua/logfire-go/cmd/logfire-tail /tmp/my-service.sock
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+)
+
+// internalAttributes are the attributes that are shown as part of the line rather
+// than as key value pairs.
+var internalAttributes = map[string]bool{
+	"logfire.span_type":    true,
+	"logfire.msg_template": true,
+	"logfire.msg":          true,
+	"logfire.level_num":    true,
+}
+
+func main() {
+	showAttrs := flag.Bool("attrs", true, "print the attributes of spans and logs")
+	minLevel := flag.String("level", "trace", "least severe level of logs to print")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <socket>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	min, ok := parseLevel(*minLevel)
+	if !ok {
+		log.Fatalf("Unknown level %q", *minLevel)
+	}
+
+	conn, err := net.Dial("unix", flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to connect to debug socket: %v", err)
+	}
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var span logfire.SpanRecord
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			log.Printf("Invalid span: %v", err)
+			continue
+		}
+		if level, isLog := levelOf(span); isLog && level < min {
+			continue
+		}
+		fmt.Println(format(span, *showAttrs))
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Failed to read debug socket: %v", err)
+	}
+}
+
+// parseLevel parses the name of a level, e.g. "info".
+func parseLevel(name string) (logfire.Level, bool) {
+	for _, level := range []logfire.Level{
+		logfire.LevelTrace, logfire.LevelDebug, logfire.LevelInfo,
+		logfire.LevelWarn, logfire.LevelError, logfire.LevelFatal,
+	} {
+		if strings.EqualFold(level.String(), name) {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// levelOf returns the level of a log, and whether the span is a log.
+func levelOf(span logfire.SpanRecord) (logfire.Level, bool) {
+	if span.Attributes["logfire.span_type"] != "log" {
+		return 0, false
+	}
+	n, _ := span.Attributes["logfire.level_num"].(float64)
+	return logfire.Level(n), true
+}
+
+// format formats a span as a single line, e.g.
+//
+//	12:00:00.000 INFO  hello world user=bob  trace=4bf92f35
+//	12:00:00.000 SPAN  checkout (12.3ms)  trace=4bf92f35
+func format(span logfire.SpanRecord, showAttrs bool) string {
+	var b strings.Builder
+	b.WriteString(span.StartTime.Local().Format("15:04:05.000"))
+	if level, isLog := levelOf(span); isLog {
+		fmt.Fprintf(&b, " %-5s %s", level.String(), span.Name)
+	} else {
+		fmt.Fprintf(&b, " SPAN  %s (%v)", span.Name, span.EndTime.Sub(span.StartTime).Round(100*time.Microsecond))
+	}
+	if span.Error {
+		fmt.Fprintf(&b, " ERROR %s", span.StatusMessage)
+	}
+	if showAttrs {
+		keys := make([]string, 0, len(span.Attributes))
+		for key := range span.Attributes {
+			if !internalAttributes[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, " %s=%v", key, span.Attributes[key])
+		}
+	}
+	fmt.Fprintf(&b, "  trace=%.8s", span.TraceID)
+	return b.String()
+}
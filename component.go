@@ -0,0 +1,63 @@
+package logfire
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// component is a module of the application, with its own instrumentation scope.
+type component struct {
+	tracer oteltrace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+type componentKey struct{}
+
+// Component returns a logger for a module of the application, e.g. "billing".  Its
+// logs and spans, and any spans nested in them, are created by a tracer with the
+// component as its instrumentation scope name, and have the code.namespace attribute
+// set to the component, so telemetry can be filtered by component:
+//
+//	var billing = logfire.Component("billing")
+//
+//	func Charge(ctx context.Context) {
+//		logger := billing.Start(ctx, "charge")
+//		defer logger.Close()
+//		...
+//	}
+//
+// The instrumentation scope can be given a version, or a different name, with
+// WithScope.
+//
+// Component can be called before Initialize, e.g. in a package level variable.  Like
+// the global logger, the returned logger has no span of its own and must not be closed.
+func Component(name string, opts ...ScopeOption) *SpanLogger {
+	c := &component{
+		tracer: newTracer(name, opts),
+		attrs:  []attribute.KeyValue{semconv.CodeNamespaceKey.String(name)},
+	}
+	return &SpanLogger{
+		spanCtx: context.WithValue(context.Background(), componentKey{}, c),
+	}
+}
+
+// Start creates a new child SpanLogger of ctx, belonging to the component of the
+// logger.
+func (s *SpanLogger) Start(ctx context.Context, spanName string, opts ...SpanOption) *SpanLogger {
+	if c, ok := s.spanCtx.Value(componentKey{}).(*component); ok {
+		ctx = context.WithValue(ctx, componentKey{}, c)
+	}
+	return NewSpanLogger(ctx, spanName, opts...)
+}
+
+// tracerFor returns the tracer of the component in ctx, or the logfire tracer, along
+// with the attributes of the component.
+func tracerFor(ctx context.Context) (oteltrace.Tracer, []attribute.KeyValue) {
+	if c, ok := ctx.Value(componentKey{}).(*component); ok {
+		return c.tracer, c.attrs
+	}
+	return globalTracer, nil
+}
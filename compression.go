@@ -0,0 +1,203 @@
+package logfire
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// spanRun is a run of consecutive sibling spans with the same name.
+type spanRun struct {
+	name string
+	// spans are kept until the run is long enough to be compressed.
+	spans []sdktrace.ReadOnlySpan
+	first sdktrace.ReadOnlySpan
+	count int
+	// min, max and total are the durations of the spans.
+	min, max, total time.Duration
+	end             time.Time
+}
+
+func (r *spanRun) add(s sdktrace.ReadOnlySpan, keep int) {
+	d := s.EndTime().Sub(s.StartTime())
+	if r.count == 0 {
+		r.first = s
+		r.min, r.max = d, d
+	}
+	r.count++
+	r.min = min(r.min, d)
+	r.max = max(r.max, d)
+	r.total += d
+	r.end = s.EndTime()
+	if len(r.spans) < keep {
+		r.spans = append(r.spans, s)
+	} else {
+		r.spans = nil
+	}
+}
+
+const (
+	// maxRunAge is how long a run is held after its last span ended, so the children of
+	// a long-running span are still exported while it runs.
+	maxRunAge = time.Minute
+	// maxEndedSpans is how many ended spans are remembered, so children that end after
+	// their parent, e.g. in goroutines, aren't held for a parent that has already ended.
+	maxEndedSpans = 10000
+)
+
+// compressor collapses runs of identical consecutive child spans, e.g. thousands of row
+// inserts, into one aggregate span.  Only leaf spans are compressed, because the
+// children of the other spans of a run, which have already been exported, would point
+// at spans that are never exported.
+type compressor struct {
+	minRun int
+	clock  Clock
+
+	mu sync.Mutex
+	// runs are the current run of each parent span.
+	runs map[oteltrace.SpanID]*spanRun
+	// parents are the open spans that have had a child end.
+	parents map[oteltrace.SpanID]struct{}
+	// ended are the most recently ended spans, in a ring of maxEndedSpans, the oldest at
+	// next.
+	ended      map[oteltrace.SpanID]struct{}
+	endedOrder []oteltrace.SpanID
+	next       int
+	// swept is when the runs were last checked for ones older than maxRunAge.
+	swept time.Time
+}
+
+func newCompressor(minRun int, clock Clock) *compressor {
+	return &compressor{
+		minRun:  minRun,
+		clock:   clock,
+		runs:    make(map[oteltrace.SpanID]*spanRun),
+		parents: make(map[oteltrace.SpanID]struct{}),
+		ended:   make(map[oteltrace.SpanID]struct{}),
+		swept:   clock.Now(),
+	}
+}
+
+// add adds an ended span, and returns the spans that are ready to export.  A span
+// ends the run of its children, and a span with a different name, a failed span, or a
+// span with children, ends the run of its siblings.  A span whose parent has already
+// ended isn't compressed, and runs older than maxRunAge are ended.
+func (c *compressor) add(s sdktrace.ReadOnlySpan) []sdktrace.ReadOnlySpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := s.SpanContext().SpanID()
+	ready := c.sweep()
+	if run, ok := c.runs[id]; ok {
+		delete(c.runs, id)
+		ready = append(ready, c.flush(run)...)
+	}
+	_, hasChildren := c.parents[id]
+	delete(c.parents, id)
+	c.markEnded(id)
+
+	parent := s.Parent()
+	if !parent.IsValid() || parent.IsRemote() {
+		return append(ready, s)
+	}
+	if _, ok := c.ended[parent.SpanID()]; ok {
+		return append(ready, s)
+	}
+	c.parents[parent.SpanID()] = struct{}{}
+	run, ok := c.runs[parent.SpanID()]
+	if ok && run.name == s.Name() && !isError(s) && !hasChildren {
+		run.add(s, c.minRun)
+		return ready
+	}
+	if ok {
+		delete(c.runs, parent.SpanID())
+		ready = append(ready, c.flush(run)...)
+	}
+	if isError(s) || hasChildren {
+		return append(ready, s)
+	}
+	run = &spanRun{name: s.Name()}
+	run.add(s, c.minRun)
+	c.runs[parent.SpanID()] = run
+	return ready
+}
+
+// markEnded remembers that a span ended, forgetting the oldest ended span if there are
+// maxEndedSpans.
+func (c *compressor) markEnded(id oteltrace.SpanID) {
+	if len(c.endedOrder) < maxEndedSpans {
+		c.endedOrder = append(c.endedOrder, id)
+	} else {
+		delete(c.ended, c.endedOrder[c.next])
+		c.endedOrder[c.next] = id
+		c.next = (c.next + 1) % maxEndedSpans
+	}
+	c.ended[id] = struct{}{}
+}
+
+// sweep ends the runs whose last span ended more than maxRunAge ago, checking at most
+// once every maxRunAge, and returns the spans that are ready to export.
+func (c *compressor) sweep() []sdktrace.ReadOnlySpan {
+	now := c.clock.Now()
+	if now.Sub(c.swept) < maxRunAge {
+		return nil
+	}
+	c.swept = now
+	var ready []sdktrace.ReadOnlySpan
+	for id, run := range c.runs {
+		if now.Sub(run.end) >= maxRunAge {
+			delete(c.runs, id)
+			ready = append(ready, c.flush(run)...)
+		}
+	}
+	return ready
+}
+
+// flushAll ends every run, and returns the spans that are ready to export.
+func (c *compressor) flushAll() []sdktrace.ReadOnlySpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var ready []sdktrace.ReadOnlySpan
+	for id, run := range c.runs {
+		delete(c.runs, id)
+		ready = append(ready, c.flush(run)...)
+	}
+	return ready
+}
+
+// flush returns the spans of a run that ended, compressed if the run is long enough.
+func (c *compressor) flush(run *spanRun) []sdktrace.ReadOnlySpan {
+	if run.count < c.minRun {
+		return run.spans
+	}
+	first := run.first.Attributes()
+	attrs := append(first[:len(first):len(first)],
+		attribute.Int("logfire.compressed.count", run.count),
+		Attr("logfire.compressed.min_duration_ms", run.min),
+		Attr("logfire.compressed.max_duration_ms", run.max),
+		Attr("logfire.compressed.mean_duration_ms", run.total/time.Duration(run.count)),
+	)
+	return []sdktrace.ReadOnlySpan{&compressedSpan{
+		ReadOnlySpan: run.first,
+		end:          run.end,
+		attrs:        attrs,
+	}}
+}
+
+// compressedSpan is the first span of a compressed run, ending when the run ended.
+type compressedSpan struct {
+	sdktrace.ReadOnlySpan
+	end   time.Time
+	attrs []attribute.KeyValue
+}
+
+// EndTime returns the time the last span of the run ended.
+func (s *compressedSpan) EndTime() time.Time { return s.end }
+
+// Attributes returns the attributes of the first span of the run, with the stats of
+// the run.
+func (s *compressedSpan) Attributes() []attribute.KeyValue { return s.attrs }
@@ -0,0 +1,194 @@
+package logfire
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// addAll adds spans to the compressor in order, returning every span ready to export.
+func addAll(c *compressor, spans ...sdktrace.ReadOnlySpan) []sdktrace.ReadOnlySpan {
+	var ready []sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		ready = append(ready, c.add(s)...)
+	}
+	return ready
+}
+
+func compressedCount(s sdktrace.ReadOnlySpan) int64 {
+	for _, kv := range s.Attributes() {
+		if kv.Key == "logfire.compressed.count" {
+			return kv.Value.AsInt64()
+		}
+	}
+	return 0
+}
+
+func TestCompressorCompressesRuns(t *testing.T) {
+	c := newCompressor(3, fixedClock{now: time.Unix(0, 0)})
+	ready := addAll(c,
+		testSpan("insert", 2, 1),
+		testSpan("insert", 3, 1),
+		testSpan("insert", 4, 1),
+		testSpan("insert", 5, 1),
+		testSpan("root", 1, 0),
+	)
+	if len(ready) != 2 {
+		t.Fatalf("exported %d spans, want the aggregate span and the root", len(ready))
+	}
+	aggregate := ready[0]
+	if aggregate.Name() != "insert" || compressedCount(aggregate) != 4 {
+		t.Errorf("aggregate span = %s with count %d, want insert with count 4", aggregate.Name(), compressedCount(aggregate))
+	}
+	if aggregate.SpanContext().SpanID() != testSpan("", 2, 0).SpanContext().SpanID() {
+		t.Errorf("aggregate span isn't the first span of the run")
+	}
+	if want := testSpan("", 5, 0).EndTime(); !aggregate.EndTime().Equal(want) {
+		t.Errorf("aggregate span ends at %v, want the end of the last span, %v", aggregate.EndTime(), want)
+	}
+	if ready[1].Name() != "root" {
+		t.Errorf("second span = %s, want root", ready[1].Name())
+	}
+}
+
+func TestCompressorKeepsShortRuns(t *testing.T) {
+	c := newCompressor(3, fixedClock{now: time.Unix(0, 0)})
+	ready := addAll(c,
+		testSpan("insert", 2, 1),
+		testSpan("insert", 3, 1),
+		testSpan("select", 4, 1),
+		testSpan("root", 1, 0),
+	)
+	var names []string
+	for _, s := range ready {
+		if compressedCount(s) != 0 {
+			t.Errorf("span %s was compressed", s.Name())
+		}
+		names = append(names, s.Name())
+	}
+	if want := []string{"insert", "insert", "select", "root"}; !slices.Equal(names, want) {
+		t.Errorf("exported %v, want %v", names, want)
+	}
+}
+
+func TestCompressorSkipsFailedSpans(t *testing.T) {
+	failed := tracetest.SpanStubFromReadOnlySpan(testSpan("insert", 4, 1))
+	failed.Status.Code = codes.Error
+
+	c := newCompressor(2, fixedClock{now: time.Unix(0, 0)})
+	ready := addAll(c,
+		testSpan("insert", 2, 1),
+		testSpan("insert", 3, 1),
+		failed.Snapshot(),
+		testSpan("root", 1, 0),
+	)
+	if len(ready) != 3 {
+		t.Fatalf("exported %d spans, want the aggregate span, the failed span and the root", len(ready))
+	}
+	if compressedCount(ready[0]) != 2 {
+		t.Errorf("first span has count %d, want 2", compressedCount(ready[0]))
+	}
+	if compressedCount(ready[1]) != 0 || ready[1].Status().Code != codes.Error {
+		t.Errorf("failed span was compressed")
+	}
+}
+
+func TestCompressorSkipsSpansWithChildren(t *testing.T) {
+	c := newCompressor(2, fixedClock{now: time.Unix(0, 0)})
+	ready := addAll(c,
+		// Each row span has a child, which is exported before the row ends.
+		testSpan("query", 10, 2),
+		testSpan("row", 2, 1),
+		testSpan("query", 11, 3),
+		testSpan("row", 3, 1),
+		testSpan("query", 12, 4),
+		testSpan("row", 4, 1),
+		testSpan("root", 1, 0),
+	)
+	exported := make(map[byte]bool)
+	for _, s := range ready {
+		if compressedCount(s) != 0 {
+			t.Errorf("span %s was compressed", s.Name())
+		}
+		exported[s.SpanContext().SpanID()[0]] = true
+	}
+	for _, s := range ready {
+		if parent := s.Parent(); parent.IsValid() && !exported[parent.SpanID()[0]] {
+			t.Errorf("span %s points at parent %s, which wasn't exported", s.Name(), parent.SpanID())
+		}
+	}
+	if len(ready) != 7 {
+		t.Errorf("exported %d spans, want 7", len(ready))
+	}
+}
+
+func TestCompressorFlushAll(t *testing.T) {
+	c := newCompressor(2, fixedClock{now: time.Unix(0, 0)})
+	if ready := addAll(c, testSpan("insert", 2, 1), testSpan("insert", 3, 1)); len(ready) != 0 {
+		t.Fatalf("exported %d spans before the run ended, want 0", len(ready))
+	}
+	ready := c.flushAll()
+	if len(ready) != 1 || compressedCount(ready[0]) != 2 {
+		t.Errorf("flushAll() = %d spans, want one aggregate span with count 2", len(ready))
+	}
+	if ready := c.flushAll(); len(ready) != 0 {
+		t.Errorf("second flushAll() = %d spans, want 0", len(ready))
+	}
+}
+
+func TestCompressorExportsChildrenOfEndedParents(t *testing.T) {
+	c := newCompressor(2, fixedClock{now: time.Unix(0, 0)})
+	if ready := c.add(testSpan("root", 1, 0)); len(ready) != 1 {
+		t.Fatalf("exported %d spans for the root, want 1", len(ready))
+	}
+	if ready := addAll(c, testSpan("insert", 2, 1), testSpan("insert", 3, 1)); len(ready) != 2 {
+		t.Errorf("exported %d children of an ended parent, want both straight away", len(ready))
+	}
+	if len(c.runs) != 0 || len(c.parents) != 0 {
+		t.Errorf("compressor holds %d runs and %d parents, want none", len(c.runs), len(c.parents))
+	}
+}
+
+func TestCompressorEndsOldRuns(t *testing.T) {
+	c := newCompressor(2, fixedClock{now: time.Unix(0, 0)})
+	if ready := addAll(c, testSpan("insert", 2, 1), testSpan("insert", 3, 1)); len(ready) != 0 {
+		t.Fatalf("exported %d spans before the run ended, want 0", len(ready))
+	}
+	c.clock = fixedClock{now: time.Unix(0, 0).Add(maxRunAge + time.Second)}
+	ready := c.add(testSpan("query", 4, 9))
+	if len(ready) != 1 || compressedCount(ready[0]) != 2 {
+		t.Errorf("exported %d spans after maxRunAge, want one aggregate span with count 2", len(ready))
+	}
+	if _, ok := c.runs[testSpan("", 9, 0).SpanContext().SpanID()]; !ok {
+		t.Errorf("the new run was ended with the old one")
+	}
+}
+
+func TestCompressedSpanAttributes(t *testing.T) {
+	stub := tracetest.SpanStubFromReadOnlySpan(testSpan("insert", 2, 1))
+	stub.Attributes = []attribute.KeyValue{attribute.String("db.table", "orders")}
+	c := newCompressor(2, fixedClock{now: time.Unix(0, 0)})
+	ready := addAll(c, stub.Snapshot(), testSpan("insert", 3, 1), testSpan("root", 1, 0))
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range ready[0].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["db.table"].AsString() != "orders" {
+		t.Errorf("aggregate span lost the attributes of the first span: %v", ready[0].Attributes())
+	}
+	for _, key := range []attribute.Key{
+		"logfire.compressed.min_duration_ms",
+		"logfire.compressed.max_duration_ms",
+		"logfire.compressed.mean_duration_ms",
+	} {
+		if _, ok := attrs[key]; !ok {
+			t.Errorf("aggregate span has no %s attribute", key)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package logfire
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	redactedValue = "[REDACTED]"
+	// maxConfigAttributes is the maximum number of config fields LogConfig records.
+	maxConfigAttributes = 512
+)
+
+// sensitiveKey matches attribute keys whose values should never be recorded.
+var sensitiveKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[_-]?key|auth|credential|private[_-]?key|dsn)`)
+
+// LogConfig logs cfg, typically a configuration struct, at startup.  Each field is
+// recorded as an attribute under "config.", using the same dotted keys as Flatten,
+// and fields with names that look sensitive, such as passwords and tokens, are
+// redacted.  This makes it possible to find out what config a service was running
+// with when an incident happened.
+func LogConfig(ctx context.Context, cfg any) {
+	attrs := []attribute.KeyValue{
+		attribute.String("config.type", fmt.Sprintf("%T", cfg)),
+	}
+	for _, kv := range Flatten("config", cfg, WithMaxAttributes(maxConfigAttributes)) {
+		if sensitiveKey.MatchString(string(kv.Key)) {
+			kv = attribute.String(string(kv.Key), redactedValue)
+		}
+		attrs = append(attrs, kv)
+	}
+	FromContext(ctx).Info("config", WithAttributes(attrs...))
+}
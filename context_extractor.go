@@ -0,0 +1,38 @@
+package logfire
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ContextExtractor returns attributes to set on a span or log from the context it is
+// started in, e.g. auth claims, the locale or request metadata stored by a framework.
+type ContextExtractor func(ctx context.Context) []attribute.KeyValue
+
+// contextExtractorProcessor is a SpanProcessor that sets the attributes returned by
+// the extractors on every span when it starts.
+type contextExtractorProcessor struct {
+	extractors []ContextExtractor
+}
+
+var _ sdktrace.SpanProcessor = (*contextExtractorProcessor)(nil)
+
+// OnStart sets the attributes extracted from the context the span is started in.
+func (p *contextExtractorProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	for _, extract := range p.extractors {
+		if attrs := extract(parent); len(attrs) > 0 {
+			s.SetAttributes(attrs...)
+		}
+	}
+}
+
+// OnEnd does nothing.
+func (p *contextExtractorProcessor) OnEnd(s sdktrace.ReadOnlySpan) {}
+
+// Shutdown does nothing.
+func (p *contextExtractorProcessor) Shutdown(ctx context.Context) error { return nil }
+
+// ForceFlush does nothing.
+func (p *contextExtractorProcessor) ForceFlush(ctx context.Context) error { return nil }
@@ -0,0 +1,52 @@
+package logfire
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// crashFlushTimeout is how long HandleCrash waits for the crash to be exported.
+const crashFlushTimeout = 5 * time.Second
+
+// HandleCrash reports an unrecovered panic to Logfire before the process crashes.  It
+// must be deferred at the top of main, and of any goroutine whose panics should be
+// reported:
+//
+//	func main() {
+//		closer, _ := logfire.Initialize(ctx)
+//		defer closer()
+//		defer logfire.HandleCrash()
+//		...
+//	}
+//
+// The panic is logged with severity Fatal, along with its stack.  Spans are flushed, and
+// the webhook set with WithFatalNotifier is notified, synchronously before the panic
+// continues.  This matters most in goroutines, where a panic crashes the process
+// without running the deferred closer in main.
+func HandleCrash() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if globalLogger != nil {
+		Fatal(fmt.Sprintf("panic: %v", r), WithAttributes(
+			attribute.String("exception.type", fmt.Sprintf("%T", r)),
+			attribute.String("exception.message", fmt.Sprint(r)),
+			attribute.String("exception.stacktrace", string(debug.Stack())),
+		))
+		ctx, cancel := context.WithTimeout(context.Background(), crashFlushTimeout)
+		if err := Flush(ctx); err != nil {
+			log.Printf("Error flushing crash report: %v", err)
+		}
+		if globalFatalNotifier != nil {
+			globalFatalNotifier.wait(ctx)
+		}
+		cancel()
+	}
+	panic(r)
+}
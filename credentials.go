@@ -0,0 +1,152 @@
+package logfire
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// credentials are the credentials written by the Logfire CLI.
+type credentials struct {
+	// Token is the write token.
+	Token string
+	// BaseURL is the URL of the Logfire API the token belongs to, if known.
+	BaseURL string
+	// ProjectURL is the URL of the project in the Logfire UI, if known.
+	ProjectURL string
+}
+
+// credentialsPath returns the path of the credentials file, $LOGFIRE_CREDENTIALS or
+// ~/.logfire/default.toml.
+func credentialsPath() string {
+	if path := os.Getenv("LOGFIRE_CREDENTIALS"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".logfire", "default.toml")
+}
+
+// loadCredentials reads the credentials file, returning false if there is none or it
+// has no unexpired token.
+func loadCredentials() (credentials, bool) {
+	path := credentialsPath()
+	if path == "" {
+		return credentials{}, false
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return credentials{}, false
+	}
+	if err != nil {
+		log.Printf("Error reading Logfire credentials: %v", err)
+		return credentials{}, false
+	}
+	defer f.Close()
+
+	creds, err := parseCredentials(bufio.NewScanner(f), time.Now())
+	if err != nil {
+		log.Printf("Error reading Logfire credentials from %s: %v", path, err)
+		return credentials{}, false
+	}
+	return creds, creds.Token != ""
+}
+
+// parseCredentials parses the subset of TOML written by the Logfire CLI: top level keys,
+// and a table of tokens per API URL, e.g.
+//
+//	[tokens."https://logfire-us.pydantic.dev"]
+//	token = "pylf_v1_us_..."
+//	expiration = "2025-01-01T00:00:00Z"
+//
+// The first unexpired token is returned.
+func parseCredentials(scanner *bufio.Scanner, now time.Time) (credentials, error) {
+	var top, current credentials
+	var found []credentials
+	var expired bool
+	inTokens := false
+	flush := func() {
+		if inTokens && current.Token != "" && !expired {
+			found = append(found, current)
+		}
+	}
+
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			flush()
+			table := strings.TrimSpace(strings.Trim(line, "[]"))
+			url, ok := strings.CutPrefix(table, "tokens.")
+			inTokens = ok
+			current = credentials{BaseURL: unquoteTOML(url)}
+			expired = false
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return credentials{}, fmt.Errorf("line %d: expected key = value", lineno)
+		}
+		key, value = unquoteTOML(strings.TrimSpace(key)), unquoteTOML(strings.TrimSpace(value))
+		target := &top
+		if inTokens {
+			target = &current
+		}
+		switch key {
+		case "token":
+			target.Token = value
+		case "logfire_api_url", "base_url":
+			target.BaseURL = value
+		case "project_url":
+			target.ProjectURL = value
+		case "expiration":
+			if t, err := time.Parse(time.RFC3339, value); err == nil && inTokens {
+				expired = !t.After(now)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return credentials{}, err
+	}
+	flush()
+
+	if top.Token != "" {
+		return top, nil
+	}
+	if len(found) > 0 {
+		return found[0], nil
+	}
+	return credentials{}, nil
+}
+
+// unquoteTOML removes the quotes and any trailing comment from a TOML string.
+func unquoteTOML(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		if end := strings.Index(s[1:], `"`); end >= 0 {
+			if unquoted, err := strconv.Unquote(s[:end+2]); err == nil {
+				return unquoted
+			}
+			return s[1 : end+1]
+		}
+	}
+	if strings.HasPrefix(s, "'") {
+		if end := strings.Index(s[1:], "'"); end >= 0 {
+			return s[1 : end+1]
+		}
+	}
+	if value, _, ok := strings.Cut(s, "#"); ok {
+		return strings.TrimSpace(value)
+	}
+	return s
+}
@@ -0,0 +1,157 @@
+package logfire
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// debugClientBuffer is how many lines are queued for a debug socket client before new
+// lines are dropped, so a slow client can't hold back exports.
+const debugClientBuffer = 1024
+
+// SpanRecord is a span as it is written to the debug socket, one JSON object per line.
+type SpanRecord struct {
+	TraceID       string         `json:"trace_id"`
+	SpanID        string         `json:"span_id"`
+	ParentSpanID  string         `json:"parent_span_id,omitempty"`
+	Name          string         `json:"name"`
+	StartTime     time.Time      `json:"start_time"`
+	EndTime       time.Time      `json:"end_time"`
+	Error         bool           `json:"error,omitempty"`
+	StatusMessage string         `json:"status_message,omitempty"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+}
+
+// newSpanRecord converts an exported span to a SpanRecord.
+func newSpanRecord(s sdktrace.ReadOnlySpan) SpanRecord {
+	r := SpanRecord{
+		TraceID:       s.SpanContext().TraceID().String(),
+		SpanID:        s.SpanContext().SpanID().String(),
+		Name:          s.Name(),
+		StartTime:     s.StartTime(),
+		EndTime:       s.EndTime(),
+		Error:         s.Status().Code == codes.Error,
+		StatusMessage: s.Status().Description,
+	}
+	if s.Parent().IsValid() {
+		r.ParentSpanID = s.Parent().SpanID().String()
+	}
+	if attrs := s.Attributes(); len(attrs) > 0 {
+		r.Attributes = make(map[string]any, len(attrs))
+		for _, kv := range attrs {
+			r.Attributes[string(kv.Key)] = kv.Value.AsInterface()
+		}
+	}
+	return r
+}
+
+// debugSocket is a SpanExporter that writes spans, as JSON lines, to every client
+// connected to a unix socket.
+type debugSocket struct {
+	path     string
+	listener net.Listener
+
+	mu      sync.Mutex
+	clients map[net.Conn]chan []byte
+}
+
+var _ sdktrace.SpanExporter = (*debugSocket)(nil)
+
+// newDebugSocket listens on the unix socket at path, replacing a socket left behind by
+// a previous process.
+func newDebugSocket(path string) (*debugSocket, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	s := &debugSocket{
+		path:     path,
+		listener: listener,
+		clients:  make(map[net.Conn]chan []byte),
+	}
+	go s.accept()
+	return s, nil
+}
+
+func (s *debugSocket) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Printf("Error accepting debug socket client: %v", err)
+			return
+		}
+		lines := make(chan []byte, debugClientBuffer)
+		s.mu.Lock()
+		s.clients[conn] = lines
+		s.mu.Unlock()
+		go s.serve(conn, lines)
+	}
+}
+
+// serve writes lines to a client until it disconnects, or the socket is shut down.
+func (s *debugSocket) serve(conn net.Conn, lines chan []byte) {
+	defer conn.Close()
+	for line := range lines {
+		if _, err := conn.Write(line); err != nil {
+			s.mu.Lock()
+			if _, ok := s.clients[conn]; ok {
+				delete(s.clients, conn)
+				close(lines)
+			}
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+// ExportSpans writes the spans to every client, dropping them for clients that are
+// too far behind.
+func (s *debugSocket) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.clients) == 0 {
+		return nil
+	}
+
+	for _, span := range spans {
+		line, err := json.Marshal(newSpanRecord(span))
+		if err != nil {
+			continue
+		}
+		line = append(line, '\n')
+		for _, lines := range s.clients {
+			select {
+			case lines <- line:
+			default:
+			}
+		}
+	}
+	return nil
+}
+
+// Shutdown stops listening, and disconnects every client.
+func (s *debugSocket) Shutdown(ctx context.Context) error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	for conn, lines := range s.clients {
+		delete(s.clients, conn)
+		close(lines)
+	}
+	s.mu.Unlock()
+	return err
+}
@@ -11,7 +11,7 @@ import (
 func main() {
 	closer, err := logfire.Initialize(
 		context.Background(),
-		logfire.WithServiceName("test-my-service"), // This is optional.
+		logfire.WithServiceName("test-my-service"),
 	)
 	if err != nil {
 		log.Fatalf("Failed to initialize logfire: %v", err)
@@ -0,0 +1,57 @@
+package logfire
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+)
+
+// logfireHeaders returns the headers Logfire requires on every export.
+func logfireHeaders(config *config) map[string]string {
+	return map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", config.APIToken),
+	}
+}
+
+// TracesURL returns the URL spans are exported to for an endpoint set with WithEndpoint:
+// the endpoint with the standard /v1/traces path in generic OTLP mode, or with /traces
+// for Logfire, whose endpoints already include the version.
+func TracesURL(endpoint string, genericOTLP bool) string {
+	if genericOTLP {
+		return endpoint + "/v1/traces"
+	}
+	return endpoint + "/traces"
+}
+
+// traceExporterOptions returns the options of the trace exporter.
+//
+// In generic OTLP mode, the standard OTEL_EXPORTER_OTLP_* environment variables apply,
+// and an endpoint set with WithEndpoint gets the standard /v1/traces path.
+func traceExporterOptions(config *config) []otlptracehttp.Option {
+	if config.GenericOTLP {
+		if config.Endpoint == DefaultEndpoint {
+			return nil
+		}
+		return []otlptracehttp.Option{otlptracehttp.WithEndpointURL(TracesURL(config.Endpoint, true))}
+	}
+	return []otlptracehttp.Option{
+		otlptracehttp.WithEndpointURL(TracesURL(config.Endpoint, false)),
+		otlptracehttp.WithHeaders(logfireHeaders(config)),
+	}
+}
+
+// metricExporterOptions returns the options of the metric exporter, following the same
+// rules as traceExporterOptions.
+func metricExporterOptions(config *config) []otlpmetrichttp.Option {
+	if config.GenericOTLP {
+		if config.Endpoint == DefaultEndpoint {
+			return nil
+		}
+		return []otlpmetrichttp.Option{otlpmetrichttp.WithEndpointURL(config.Endpoint + "/v1/metrics")}
+	}
+	return []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpointURL(config.Endpoint + "/metrics"),
+		otlpmetrichttp.WithHeaders(logfireHeaders(config)),
+	}
+}
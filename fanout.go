@@ -0,0 +1,176 @@
+package logfire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// drainReportInterval is how often the progress of draining the queues is reported
+// during shutdown.
+const drainReportInterval = time.Second
+
+// fanoutProcessor sends spans to several processors.  Each processor has its own
+// queue, so a slow or failing exporter can't hold back the others, and a panic in one
+// processor doesn't stop spans reaching the rest.
+type fanoutProcessor struct {
+	processors []*batchProcessor
+	// required drops or warns about spans missing required attributes, if set.
+	required *requiredAttributes
+	// policy removes attributes that must not be exported, if set.
+	policy *attributePolicy
+	// compressor collapses runs of identical child spans, if set.
+	compressor *compressor
+}
+
+var _ sdktrace.SpanProcessor = (*fanoutProcessor)(nil)
+
+// newFanoutProcessor creates a fanoutProcessor with a batchProcessor for each exporter.
+func newFanoutProcessor(policy OverflowPolicy, exporters ...sdktrace.SpanExporter) *fanoutProcessor {
+	processors := make([]*batchProcessor, len(exporters))
+	for i, exporter := range exporters {
+		processors[i] = newBatchProcessor(exporter, policy)
+	}
+	return &fanoutProcessor{processors: processors}
+}
+
+// OnStart does nothing, spans are only queued once they end.
+func (f *fanoutProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {}
+
+// OnEnd queues the span on every processor, unless it is filtered out or held by the
+// compressor.
+func (f *fanoutProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	observeSLOs(s)
+	if !s.SpanContext().IsSampled() || !f.required.check(s) {
+		return
+	}
+	if f.compressor == nil {
+		f.export(s)
+		return
+	}
+	for _, ready := range f.compressor.add(s) {
+		f.export(ready)
+	}
+}
+
+// export sanitizes the span, and queues it on every processor.
+func (f *fanoutProcessor) export(s sdktrace.ReadOnlySpan) {
+	s = sanitizeSpan(s, f.policy)
+	for _, p := range f.processors {
+		isolate(func() { p.OnEnd(s) })
+	}
+}
+
+// flushCompressor exports the spans held by the compressor.
+func (f *fanoutProcessor) flushCompressor() {
+	if f.compressor == nil {
+		return
+	}
+	for _, ready := range f.compressor.flushAll() {
+		f.export(ready)
+	}
+}
+
+// Shutdown shuts down every processor, returning all of their errors.  Progress is
+// reported while draining takes longer than drainReportInterval, and spans still
+// queued when ctx is done are counted as dropped.
+func (f *fanoutProcessor) Shutdown(ctx context.Context) error {
+	f.flushCompressor()
+	start := time.Now()
+	droppedBefore := f.dropped()
+	stopReporting := f.reportDrain(start)
+	var errs []error
+	for _, p := range f.processors {
+		errs = append(errs, p.Shutdown(ctx))
+	}
+	stopReporting()
+	if ctx.Err() != nil {
+		log.Printf("logfire: shutdown timed out after %s, %d queued spans dropped", time.Since(start).Round(time.Millisecond), f.dropped()-droppedBefore)
+	}
+	return errors.Join(errs...)
+}
+
+// reportDrain logs how many spans remain queued every drainReportInterval, until the
+// returned function is called.
+func (f *fanoutProcessor) reportDrain(start time.Time) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(drainReportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				log.Printf("logfire: draining, %d spans queued after %s", f.queued(), time.Since(start).Round(time.Millisecond))
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// queued returns the number of spans waiting to be exported by any processor.
+func (f *fanoutProcessor) queued() int {
+	var n int
+	for _, p := range f.processors {
+		n += p.queued()
+	}
+	return n
+}
+
+// dropped returns the number of spans dropped by every processor.
+func (f *fanoutProcessor) dropped() uint64 {
+	var n uint64
+	for _, s := range f.stats() {
+		n += s.Dropped
+	}
+	return n
+}
+
+// ForceFlush flushes every processor, returning all of their errors.
+func (f *fanoutProcessor) ForceFlush(ctx context.Context) error {
+	f.flushCompressor()
+	var errs []error
+	for _, p := range f.processors {
+		errs = append(errs, p.ForceFlush(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// utilization returns the highest queue utilization of any processor.
+func (f *fanoutProcessor) utilization() float64 {
+	var highest float64
+	for _, p := range f.processors {
+		highest = max(highest, p.utilization())
+	}
+	return highest
+}
+
+// stats returns the health of every processor.
+func (f *fanoutProcessor) stats() []exportStats {
+	stats := make([]exportStats, len(f.processors))
+	for i, p := range f.processors {
+		stats[i] = p.stats()
+	}
+	return stats
+}
+
+// isolate runs fn, reporting rather than propagating any panic.
+func isolate(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			otel.Handle(fmt.Errorf("logfire: span processor panicked: %v", r))
+		}
+	}()
+	fn()
+}
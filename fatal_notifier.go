@@ -0,0 +1,104 @@
+package logfire
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// notifyTimeout is how long posting a notification can take.
+	notifyTimeout = 5 * time.Second
+	// notifyMinInterval is the least time between notifications, so a crash loop or a
+	// burst of Fatal logs doesn't flood the channel.
+	notifyMinInterval = time.Minute
+)
+
+// fatalNotifier posts a message to a webhook for every Fatal log.
+type fatalNotifier struct {
+	url    string
+	client *http.Client
+
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+	pending    sync.WaitGroup
+}
+
+func newFatalNotifier(url string) *fatalNotifier {
+	return &fatalNotifier{
+		url:    url,
+		client: &http.Client{Timeout: notifyTimeout},
+	}
+}
+
+// notify posts a message about a Fatal log in the background.
+func (n *fatalNotifier) notify(msg string, sc oteltrace.SpanContext) {
+	now := globalClock.Now()
+	n.mu.Lock()
+	if !n.last.IsZero() && now.Sub(n.last) < notifyMinInterval {
+		n.suppressed++
+		n.mu.Unlock()
+		return
+	}
+	n.last = now
+	suppressed := n.suppressed
+	n.suppressed = 0
+	n.mu.Unlock()
+
+	text := "Fatal: " + msg
+	if globalServiceName != "" {
+		text = fmt.Sprintf("Fatal in %s: %s", globalServiceName, msg)
+	}
+	if globalProjectURL != "" && sc.IsValid() {
+		text += "\n" + traceURL(globalProjectURL, sc.TraceID())
+	}
+	if suppressed > 0 {
+		text += fmt.Sprintf("\n(%d more since the last notification)", suppressed)
+	}
+
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		if err := n.post(text); err != nil {
+			log.Printf("Error posting fatal notification: %v", err)
+		}
+	}()
+}
+
+// post posts a Slack compatible message to the webhook.
+func (n *fatalNotifier) post(text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// wait waits for notifications being posted, or until ctx is done.
+func (n *fatalNotifier) wait(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,31 @@
+package logfire
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// featureFlagEventName is the name of the OpenTelemetry semantic convention event for
+// feature flag evaluations.
+const featureFlagEventName = "feature_flag"
+
+// LogFeatureFlag records the evaluation of a feature flag as an event on the span in
+// ctx, following the OpenTelemetry feature flag semantic conventions, so experiments
+// can be correlated with traces.  Use the attribute "feature_flag.provider_name" to
+// record which provider evaluated the flag.
+func LogFeatureFlag(ctx context.Context, key, variant string, attrs ...attribute.KeyValue) {
+	span := oteltrace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	eventAttrs := make([]attribute.KeyValue, 0, len(attrs)+2)
+	eventAttrs = append(eventAttrs,
+		attribute.String("feature_flag.key", key),
+		attribute.String("feature_flag.variant", variant),
+	)
+	eventAttrs = append(eventAttrs, attrs...)
+	span.AddEvent(featureFlagEventName, oteltrace.WithAttributes(eventAttrs...))
+}
@@ -0,0 +1,186 @@
+package logfire
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultFlattenMaxDepth      = 5
+	defaultFlattenMaxAttributes = 128
+)
+
+// flattenConfig is the config used by Flatten.
+type flattenConfig struct {
+	// MaxDepth is how many levels of nesting are flattened.
+	MaxDepth int
+	// MaxAttributes is the maximum number of attributes returned.
+	MaxAttributes int
+}
+
+// FlattenOption is a function type that modifies how Flatten works.
+type FlattenOption func(*flattenConfig)
+
+// WithMaxDepth sets how many levels of nesting are flattened.  Values nested deeper
+// are formatted into a single string attribute.  Defaults to 5.
+func WithMaxDepth(depth int) FlattenOption {
+	return func(c *flattenConfig) {
+		c.MaxDepth = depth
+	}
+}
+
+// WithMaxAttributes sets the maximum number of attributes returned.  Anything after
+// the limit is dropped.  Defaults to 128.
+func WithMaxAttributes(n int) FlattenOption {
+	return func(c *flattenConfig) {
+		c.MaxAttributes = n
+	}
+}
+
+// Flatten recursively flattens nested maps, structs and slices into attributes with
+// dotted keys, so each field can be queried on its own:
+//
+//	logfire.Flatten("http.request", map[string]any{"headers": map[string]any{"host": "x"}})
+//	// http.request.headers.host = "x"
+//
+// Struct fields are named by their json tag if they have one, and unexported fields
+// are skipped.  Map keys are sorted so that the output is stable.
+func Flatten(prefix string, value any, opts ...FlattenOption) []attribute.KeyValue {
+	config := &flattenConfig{
+		MaxDepth:      defaultFlattenMaxDepth,
+		MaxAttributes: defaultFlattenMaxAttributes,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	f := &flattener{config: config}
+	f.flatten(prefix, reflect.ValueOf(value), 0)
+	return f.attrs
+}
+
+type flattener struct {
+	config *flattenConfig
+	attrs  []attribute.KeyValue
+}
+
+func (f *flattener) full() bool {
+	return len(f.attrs) >= f.config.MaxAttributes
+}
+
+func (f *flattener) add(kv attribute.KeyValue) {
+	if !f.full() {
+		f.attrs = append(f.attrs, kv)
+	}
+}
+
+func (f *flattener) flatten(key string, v reflect.Value, depth int) {
+	if f.full() {
+		return
+	}
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return
+	}
+
+	if kv, ok := leafAttribute(key, v); ok {
+		f.add(kv)
+		return
+	}
+	if depth >= f.config.MaxDepth {
+		f.add(attribute.String(key, fmt.Sprintf("%+v", v.Interface())))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = fmt.Sprint(k.Interface())
+		}
+		order := make([]int, len(keys))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool { return names[order[i]] < names[order[j]] })
+		for _, i := range order {
+			f.flatten(joinKey(key, names[i]), v.MapIndex(keys[i]), depth+1)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Name
+			if tag, ok := field.Tag.Lookup("json"); ok {
+				tagName, _, _ := strings.Cut(tag, ",")
+				if tagName == "-" {
+					continue
+				}
+				if tagName != "" {
+					name = tagName
+				}
+			}
+			f.flatten(joinKey(key, name), v.Field(i), depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			f.flatten(joinKey(key, strconv.Itoa(i)), v.Index(i), depth+1)
+		}
+	default:
+		f.add(attribute.String(key, fmt.Sprintf("%v", v.Interface())))
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// leafAttribute converts values that are not flattened any further into an attribute.
+func leafAttribute(key string, v reflect.Value) (attribute.KeyValue, bool) {
+	if v.CanInterface() {
+		switch value := v.Interface().(type) {
+		case time.Time:
+			return Attr(key, value), true
+		case time.Duration:
+			return Attr(key, value), true
+		case []string:
+			return Attr(key, value), true
+		case fmt.Stringer:
+			if v.Kind() != reflect.Struct && v.Kind() != reflect.Map && v.Kind() != reflect.Slice {
+				return StringerAttr(key, value), true
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return attribute.String(key, v.String()), true
+	case reflect.Bool:
+		return attribute.Bool(key, v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return attribute.Int64(key, v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return attribute.Int64(key, int64(v.Uint())), true
+	case reflect.Float32, reflect.Float64:
+		return attribute.Float64(key, v.Float()), true
+	}
+	return attribute.KeyValue{}, false
+}
@@ -0,0 +1,31 @@
+package logfire
+
+import (
+	"context"
+	"net/http"
+)
+
+type forceDebugKey struct{}
+
+// ForceDebug returns a context in which every span is sampled, as with ForceSample,
+// and logs of every level are sent, regardless of WithMinLevel.  Use it to debug
+// specific requests in production.
+func ForceDebug(ctx context.Context) context.Context {
+	return context.WithValue(ForceSample(ctx), forceDebugKey{}, true)
+}
+
+// debugForced reports whether ctx is from ForceDebug.
+func debugForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(forceDebugKey{}).(bool)
+	return forced
+}
+
+// ForceTraceContext returns the context of r, with ForceDebug applied if r matches the
+// rule set with WithForceTraceRule.  HTTP middlewares use it before starting the span
+// of a request.
+func ForceTraceContext(r *http.Request) context.Context {
+	if globalForceTraceRule == nil || !globalForceTraceRule(r) {
+		return r.Context()
+	}
+	return ForceDebug(r.Context())
+}
@@ -0,0 +1,158 @@
+package gin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxBodyFieldBytes is the largest request body that fields are extracted from.
+// Fields of larger bodies aren't recorded, rather than reading the whole body twice.
+const maxBodyFieldBytes = 64 * 1024
+
+// WithBodyFields records fields of JSON request bodies as attributes of the request's
+// span, giving business context without capturing whole payloads.  fields maps the
+// names of attributes to JSONPath-style paths of fields, made of object keys and array
+// indexes:
+//
+//	router.Use(logfiregin.Middleware(logfiregin.WithBodyFields(map[string]string{
+//		"order.id":         "$.order.id",
+//		"order.first_item": "$.order.items[0].sku",
+//	})))
+//
+// Fields missing from the body are skipped, and objects and arrays are recorded as
+// JSON.  Bodies over 64KiB, or not of a JSON content type, are ignored.
+func WithBodyFields(fields map[string]string) Option {
+	return func(c *config) {
+		for name, path := range fields {
+			c.BodyFields = append(c.BodyFields, bodyField{name: name, path: path})
+		}
+	}
+}
+
+// bodyField is a field of request bodies recorded as an attribute.
+type bodyField struct {
+	name string
+	path string
+	// segments are the parsed path, each an object key or an array index.
+	segments []any
+}
+
+// parsePath parses a path such as $.order.items[0].sku into object keys and array
+// indexes.
+func parsePath(path string) ([]any, error) {
+	rest := strings.TrimPrefix(path, "$")
+	var segments []any
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in %q", path)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed index in %q", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index %q in %q", rest[1:end], path)
+			}
+			segments = append(segments, index)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("expected . or [ in %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// bodyAttributes returns the fields of the body of r as attributes, leaving the body to
+// be read again by the handler.
+func bodyAttributes(r *http.Request, fields []bodyField) []attribute.KeyValue {
+	if len(fields) == 0 || r.Body == nil || !strings.Contains(r.Header.Get("Content-Type"), "json") {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyFieldBytes+1))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+	if err != nil || len(body) > maxBodyFieldBytes {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	var attrs []attribute.KeyValue
+	for _, field := range fields {
+		if v, ok := lookup(value, field.segments); ok {
+			attrs = append(attrs, jsonAttribute(field.name, v))
+		}
+	}
+	return attrs
+}
+
+// lookup returns the field of a decoded JSON value at the path.
+func lookup(value any, segments []any) (any, bool) {
+	for _, segment := range segments {
+		switch segment := segment.(type) {
+		case string:
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if value, ok = object[segment]; !ok {
+				return nil, false
+			}
+		case int:
+			array, ok := value.([]any)
+			if !ok || segment >= len(array) {
+				return nil, false
+			}
+			value = array[segment]
+		}
+	}
+	return value, true
+}
+
+// jsonAttribute converts a decoded JSON value to an attribute.
+func jsonAttribute(name string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(name, v)
+	case bool:
+		return attribute.Bool(name, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return attribute.Int64(name, i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return attribute.Float64(name, f)
+		}
+		return attribute.String(name, v.String())
+	default:
+		b, _ := json.Marshal(v)
+		return attribute.String(name, string(b))
+	}
+}
+
+// readCloser reads the rest of a request body after the part that was already read.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,53 @@
+package gin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// WithErrorBodies records up to maxBytes of the body of 5xx responses as the
+// http.response.body attribute of the request's span.  Capturing every body is too
+// expensive, but the bodies of errors are often the quickest way to what went wrong.
+func WithErrorBodies(maxBytes int) Option {
+	return func(c *config) {
+		c.ErrorBodyBytes = maxBytes
+	}
+}
+
+// errorBodyWriter records the start of the body of 5xx responses on the request's span.
+type errorBodyWriter struct {
+	gin.ResponseWriter
+	c        *gin.Context
+	maxBytes int
+	body     strings.Builder
+}
+
+func (w *errorBodyWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.record(b[:n])
+	return n, err
+}
+
+func (w *errorBodyWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.record([]byte(s[:n]))
+	return n, err
+}
+
+// record adds b to the recorded body, if the response is an error and the body isn't
+// already at maxBytes.
+func (w *errorBodyWriter) record(b []byte) {
+	if w.Status() < http.StatusInternalServerError || w.body.Len() >= w.maxBytes {
+		return
+	}
+	b = b[:min(len(b), w.maxBytes-w.body.Len())]
+	w.body.Write(b)
+	// Drop invalid UTF-8, such as a rune cut in half.
+	body := strings.ToValidUTF8(w.body.String(), "")
+	span := oteltrace.SpanFromContext(w.c.Request.Context())
+	span.SetAttributes(attribute.String("http.response.body", body))
+}
@@ -1,14 +1,115 @@
+// Package gin records the requests served by gin in Logfire spans.  Its name is the
+// same as gin's, so import it as logfiregin, the name used in these docs:
+//
+//	import logfiregin "github.com/jerechua/logfire-go/gin"
+//
+//	router.Use(logfiregin.Middleware())
 package gin
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/jerechua/logfire-go"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
-func Middleware() gin.HandlerFunc {
+// config is the config used by Middleware.
+type config struct {
+	// StatusMapper maps the status code of responses to the status of their spans, if set.
+	StatusMapper func(code int) codes.Code
+	// BodyFields are the fields of JSON request bodies recorded as attributes.
+	BodyFields []bodyField
+	// ErrorBodyBytes is how much of the body of 5xx responses is recorded, or 0 for none.
+	ErrorBodyBytes int
+	// OperationIDs maps the method and route of requests to their OpenAPI operationId.
+	OperationIDs map[string]string
+}
+
+// Option is a function type that modifies the Middleware config.
+type Option func(*config)
+
+// WithStatusMapper sets how the status codes of responses map to the status of their
+// spans, e.g. to treat a 404 from a search endpoint as OK, or a 499 as an error.  By
+// default 5xx responses are errors.  Codes mapped to codes.Unset or codes.Ok are never
+// errors.
+//
+//	router.Use(logfiregin.Middleware(logfiregin.WithStatusMapper(func(code int) codes.Code {
+//		if code >= 500 || code == 499 {
+//			return codes.Error
+//		}
+//		return codes.Unset
+//	})))
+func WithStatusMapper(mapper func(code int) codes.Code) Option {
+	return func(c *config) {
+		c.StatusMapper = mapper
+	}
+}
+
+func Middleware(opts ...Option) gin.HandlerFunc {
+	config := &config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	fields := config.BodyFields[:0]
+	for _, field := range config.BodyFields {
+		segments, err := parsePath(field.path)
+		if err != nil {
+			logfire.Warn("invalid body field path: " + err.Error())
+			continue
+		}
+		field.segments = segments
+		fields = append(fields, field)
+	}
+	config.BodyFields = fields
+
 	return func(c *gin.Context) {
-		otelgin.Middleware(logfire.ServiceName())(c)
+		// Apply the force trace rule before the span of the request is started.
+		c.Request = c.Request.WithContext(logfire.ForceTraceContext(c.Request))
+		if config.StatusMapper != nil {
+			c.Writer = &statusWriter{ResponseWriter: c.Writer, c: c, mapper: config.StatusMapper}
+		}
+		if config.ErrorBodyBytes > 0 {
+			c.Writer = &errorBodyWriter{ResponseWriter: c.Writer, c: c, maxBytes: config.ErrorBodyBytes}
+		}
+		otelOpts := []otelgin.Option{
+			otelgin.WithSpanNameFormatter(func(r *http.Request) string {
+				return logfire.HTTPSpanName(r.Method, c.FullPath())
+			}),
+		}
+		attrs := bodyAttributes(c.Request, config.BodyFields)
+		if id, ok := config.OperationIDs[c.Request.Method+" "+c.FullPath()]; ok {
+			attrs = append(attrs, attribute.String("openapi.operation_id", id))
+		}
+		if len(attrs) > 0 {
+			otelOpts = append(otelOpts, otelgin.WithTracerProvider(newRequestTracerProvider(attrs)))
+		}
+		otelgin.Middleware(logfire.ServiceName(), otelOpts...)(c)
 		c.Next()
 	}
 }
+
+// statusWriter sets the status of the request's span when the handler sets the status
+// code of the response, before otelgin sets its default status.
+type statusWriter struct {
+	gin.ResponseWriter
+	c      *gin.Context
+	mapper func(code int) codes.Code
+}
+
+// WriteHeader sets the status of the span from code.  The span status can only be
+// raised, from Unset to Error to Ok, so 5xx codes that aren't errors are recorded as Ok
+// to keep otelgin from marking them as errors.
+func (w *statusWriter) WriteHeader(code int) {
+	span := oteltrace.SpanFromContext(w.c.Request.Context())
+	switch status := w.mapper(code); {
+	case status == codes.Error:
+		span.SetStatus(codes.Error, http.StatusText(code))
+	case status == codes.Ok || code >= 500:
+		span.SetStatus(codes.Ok, "")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,126 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jerechua/logfire-go/logfiretest"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// serve sends a request to a router using Middleware with opts, and returns the span of
+// the request.
+func serve(t *testing.T, rec *logfiretest.Recorder, req *http.Request, opts ...Option) logfiretest.Span {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(opts...))
+	router.GET("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.POST("/orders", func(c *gin.Context) { c.String(http.StatusCreated, "created") })
+	router.GET("/broken", func(c *gin.Context) { c.String(http.StatusInternalServerError, "database is down") })
+	router.GET("/missing", func(c *gin.Context) { c.String(499, "client closed request") })
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want the request span", len(spans))
+	}
+	return spans[0]
+}
+
+func TestMiddlewareNamesSpansAfterRoutes(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	span := serve(t, rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if span.Name != "GET /users/:id" || span.Kind != "server" {
+		t.Errorf("span is a %s span named %q, want a server span named after the route", span.Kind, span.Name)
+	}
+	if span.Status != "" {
+		t.Errorf("span status = %q, want unset", span.Status)
+	}
+}
+
+func TestMiddlewareMarksServerErrors(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	span := serve(t, rec, httptest.NewRequest(http.MethodGet, "/broken", nil))
+	if !strings.HasPrefix(span.Status, "Error") {
+		t.Errorf("span status = %q, want an error", span.Status)
+	}
+}
+
+func TestWithStatusMapper(t *testing.T) {
+	mapper := WithStatusMapper(func(code int) codes.Code {
+		if code == 499 {
+			return codes.Error
+		}
+		return codes.Unset
+	})
+
+	rec := logfiretest.NewRecorder(t)
+	if span := serve(t, rec, httptest.NewRequest(http.MethodGet, "/missing", nil), mapper); !strings.HasPrefix(span.Status, "Error") {
+		t.Errorf("span status of a 499 = %q, want an error", span.Status)
+	}
+	rec.Reset()
+	if span := serve(t, rec, httptest.NewRequest(http.MethodGet, "/broken", nil), mapper); strings.HasPrefix(span.Status, "Error") {
+		t.Errorf("span status of a 500 mapped to Unset = %q, want no error", span.Status)
+	}
+}
+
+func TestWithBodyFields(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"order": {"id": "o-1", "items": [{"sku": "A7"}]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	span := serve(t, rec, req, WithBodyFields(map[string]string{
+		"order.id":         "$.order.id",
+		"order.first_item": "$.order.items[0].sku",
+		"order.coupon":     "$.order.coupon",
+	}))
+	if span.Attributes["order.id"] != "o-1" || span.Attributes["order.first_item"] != "A7" {
+		t.Errorf("span attributes = %v, want the order fields", span.Attributes)
+	}
+	if _, ok := span.Attributes["order.coupon"]; ok {
+		t.Errorf("span has an attribute for a field missing from the body")
+	}
+}
+
+func TestWithErrorBodies(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	span := serve(t, rec, httptest.NewRequest(http.MethodGet, "/broken", nil), WithErrorBodies(8))
+	if got := span.Attributes["http.response.body"]; got != "database" {
+		t.Errorf("http.response.body = %q, want the first 8 bytes of the body", got)
+	}
+
+	rec.Reset()
+	span = serve(t, rec, httptest.NewRequest(http.MethodGet, "/users/42", nil), WithErrorBodies(8))
+	if _, ok := span.Attributes["http.response.body"]; ok {
+		t.Errorf("recorded the body of a successful response")
+	}
+}
+
+func TestWithOperationIDs(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	span := serve(t, rec, httptest.NewRequest(http.MethodGet, "/users/42", nil),
+		WithOperationIDs(map[string]string{"GET /users/:id": "getUser"}))
+	if got := span.Attributes["openapi.operation_id"]; got != "getUser" {
+		t.Errorf("openapi.operation_id = %v, want getUser", got)
+	}
+}
+
+func TestLogRoutes(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/users/:id", func(c *gin.Context) {})
+	router.POST("/orders", func(c *gin.Context) {})
+	LogRoutes(router)
+
+	spans := rec.Spans()
+	if len(spans) != 1 || spans[0].Name != "gin routes" {
+		t.Fatalf("recorded %+v, want the gin routes log", spans)
+	}
+	if got := spans[0].Attributes["http.routes.count"]; got != int64(2) {
+		t.Errorf("http.routes.count = %v, want 2", got)
+	}
+}
@@ -0,0 +1,64 @@
+package gin
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// openAPIMethods are the operations of an OpenAPI path item.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIParameter matches the parameters of OpenAPI paths, such as {id}.
+var openAPIParameter = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// WithOperationIDs records the OpenAPI operationId of each request as the
+// openapi.operation_id attribute of its span, for analytics by API operation.  ids maps
+// the method and gin route of operations, e.g. "GET /users/:id", to their operationId.
+// OperationIDsFromOpenAPI reads them from a spec.
+func WithOperationIDs(ids map[string]string) Option {
+	return func(c *config) {
+		c.OperationIDs = ids
+	}
+}
+
+// OperationIDsFromOpenAPI returns the operationIds of the operations of an OpenAPI
+// spec, in JSON or YAML, for WithOperationIDs.  Path parameters such as {id} are
+// converted to gin parameters such as :id.
+//
+//	ids, err := logfiregin.OperationIDsFromOpenAPI(spec)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	router.Use(logfiregin.Middleware(logfiregin.WithOperationIDs(ids)))
+func OperationIDsFromOpenAPI(spec []byte) (map[string]string, error) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]string)
+	for path, item := range doc.Paths {
+		route := openAPIParameter.ReplaceAllString(path, ":$1")
+		for _, method := range openAPIMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var operation struct {
+				OperationID string `json:"operationId"`
+			}
+			if err := json.Unmarshal(raw, &operation); err != nil {
+				return nil, err
+			}
+			if operation.OperationID != "" {
+				ids[strings.ToUpper(method)+" "+route] = operation.OperationID
+			}
+		}
+	}
+	return ids, nil
+}
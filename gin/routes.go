@@ -0,0 +1,30 @@
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// LogRoutes logs the routes registered on router, so Logfire has a catalog of the API
+// of each deployment.  Call it once the routes are registered, before serving:
+//
+//	logfiregin.LogRoutes(router)
+//	router.Run()
+//
+// The log has the method and path of each route in http.routes, e.g. "GET /users/:id",
+// and the name of its handler, at the same index, in http.route_handlers.
+func LogRoutes(router *gin.Engine) {
+	routes := router.Routes()
+	names := make([]string, len(routes))
+	handlers := make([]string, len(routes))
+	for i, route := range routes {
+		names[i] = route.Method + " " + route.Path
+		handlers[i] = route.Handler
+	}
+	logfire.Info("gin routes", logfire.WithAttributes(
+		attribute.Int("http.routes.count", len(routes)),
+		attribute.StringSlice("http.routes", names),
+		attribute.StringSlice("http.route_handlers", handlers),
+	))
+}
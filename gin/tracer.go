@@ -0,0 +1,33 @@
+package gin
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// requestTracerProvider starts the span of a request with attributes known before
+// otelgin starts it, such as fields of the request body.
+type requestTracerProvider struct {
+	oteltrace.TracerProvider
+	attrs []attribute.KeyValue
+}
+
+func newRequestTracerProvider(attrs []attribute.KeyValue) requestTracerProvider {
+	return requestTracerProvider{TracerProvider: otel.GetTracerProvider(), attrs: attrs}
+}
+
+func (p requestTracerProvider) Tracer(name string, opts ...oteltrace.TracerOption) oteltrace.Tracer {
+	return requestTracer{Tracer: p.TracerProvider.Tracer(name, opts...), attrs: p.attrs}
+}
+
+type requestTracer struct {
+	oteltrace.Tracer
+	attrs []attribute.KeyValue
+}
+
+func (t requestTracer) Start(ctx context.Context, name string, opts ...oteltrace.SpanStartOption) (context.Context, oteltrace.Span) {
+	return t.Tracer.Start(ctx, name, append(opts, oteltrace.WithAttributes(t.attrs...))...)
+}
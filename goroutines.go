@@ -0,0 +1,61 @@
+package logfire
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"syscall"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxGoroutineDumpSize is the maximum size of a goroutine dump, larger dumps are
+// truncated.
+const maxGoroutineDumpSize = 1 << 20
+
+// DumpGoroutines logs the stack of every goroutine, so that a stuck process can be
+// investigated without exec-ing into its container.  The dump is in the format of an
+// unrecovered panic, and is stored in the goroutine.dump attribute of an Info log.
+func DumpGoroutines(ctx context.Context) {
+	FromContext(ctx).Info("goroutine dump", WithAttributes(goroutineDumpAttributes()...))
+}
+
+// goroutineDumpAttributes captures the goroutine.count and goroutine.dump attributes.
+func goroutineDumpAttributes() []attribute.KeyValue {
+	var buf bytes.Buffer
+	// Errors are impossible when writing to a bytes.Buffer.
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, 2)
+	dump := buf.String()
+	if len(dump) > maxGoroutineDumpSize {
+		dump = dump[:maxGoroutineDumpSize] + "\n...truncated"
+	}
+	return []attribute.KeyValue{
+		attribute.Int("goroutine.count", runtime.NumGoroutine()),
+		attribute.String("goroutine.dump", dump),
+	}
+}
+
+// dumpGoroutinesOnSignal calls DumpGoroutines whenever the process receives SIGQUIT,
+// until the returned function is called.
+func dumpGoroutinesOnSignal() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGQUIT)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+				DumpGoroutines(context.Background())
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,48 @@
+package logfire
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// health is the response of HealthHandler.
+type health struct {
+	Status    string        `json:"status"`
+	Exporters []exportStats `json:"exporters"`
+}
+
+// HealthHandler returns an http.Handler that reports the health of the telemetry
+// pipeline as JSON, for readiness probes and dashboards:
+//
+//	{
+//	  "status": "ok",
+//	  "exporters": [{
+//	    "exporter": "*otlptrace.Exporter",
+//	    "connected": true,
+//	    "last_export": "2024-09-20T10:00:00Z",
+//	    "queue_utilization": 0.02,
+//	    "dropped": 0
+//	  }]
+//	}
+//
+// The status is "ok", with a 200 response, while the Logfire exporter is connected,
+// i.e. its last export succeeded.  Otherwise the status is "unavailable", with a 503
+// response.  Additional exporters are reported, but don't affect the status.
+func HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := health{Status: "unavailable"}
+		if globalExportQueue != nil {
+			h.Exporters = globalExportQueue.stats()
+			// The Logfire exporter is always first.
+			if len(h.Exporters) > 0 && h.Exporters[0].Connected {
+				h.Status = "ok"
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if h.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(h)
+	})
+}
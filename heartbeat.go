@@ -0,0 +1,47 @@
+package logfire
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// startHeartbeat records the service.heartbeat and service.uptime metrics every
+// interval, until the returned function is called.
+func startHeartbeat(meter metric.Meter, interval time.Duration) func() {
+	heartbeats, err := meter.Int64Counter("service.heartbeat",
+		metric.WithDescription("Incremented periodically while the service is alive."),
+		metric.WithUnit("{heartbeat}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+		return func() {}
+	}
+	uptime, err := meter.Float64Gauge("service.uptime",
+		metric.WithDescription("Time since the service started."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		otel.Handle(err)
+		return func() {}
+	}
+
+	started := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			heartbeats.Add(ctx, 1)
+			uptime.Record(ctx, time.Since(started).Seconds())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
@@ -0,0 +1,51 @@
+package logfire
+
+import (
+	"net/http"
+	"strings"
+)
+
+// httpMethods are the methods that HTTPSpanName keeps, any other method is named
+// "HTTP" so that span names stay bounded.
+var httpMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// HTTPSpanName returns the name of the span of a server request, e.g. "GET /users/:id",
+// from the route template of the router that matched it.  Never pass the raw path, which
+// has unbounded cardinality.  Requests that matched no route are named after their
+// method alone.  The gin middleware and logfirehttp.Handler name their spans with it,
+// and custom routers should too:
+//
+//	logfire.Tracer().Start(ctx, logfire.HTTPSpanName(r.Method, route))
+func HTTPSpanName(method, route string) string {
+	if !httpMethods[method] {
+		method = "HTTP"
+	}
+	if route == "" {
+		return method
+	}
+	return method + " " + route
+}
+
+// HTTPRoute returns the route template of the http.ServeMux pattern that matched r, e.g.
+// "/users/{id}" for the pattern "GET example.com/users/{id}", or "" if r wasn't routed by
+// a ServeMux.  logfirehttp.Handler uses it to name the spans of ServeMux routes.
+func HTTPRoute(r *http.Request) string {
+	pattern := r.Pattern
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimLeft(path, " \t")
+	}
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
@@ -0,0 +1,47 @@
+package logfire
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// jsonLogWriter writes logs as JSON lines, e.g. for platforms that collect the stdout
+// of containers.
+type jsonLogWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newJSONLogWriter(w io.Writer) *jsonLogWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &jsonLogWriter{enc: enc}
+}
+
+// write writes a single log, with the trace and span IDs of its span so it can be
+// correlated with Logfire.
+func (w *jsonLogWriter) write(t time.Time, severity Level, msg string, sc oteltrace.SpanContext, attrs []attribute.KeyValue) {
+	line := make(map[string]any, len(attrs)+5)
+	for _, kv := range attrs {
+		line[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	line["time"] = t.Format(time.RFC3339Nano)
+	line["level"] = severity.String()
+	line["msg"] = msg
+	if sc.IsValid() {
+		line["trace_id"] = sc.TraceID().String()
+		line["span_id"] = sc.SpanID().String()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.enc.Encode(line); err != nil {
+		log.Printf("Error writing JSON log: %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+package logfire
+
+import (
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// watchForLeak starts a watchdog that warns, with the stack that created the span, if
+// the span is still open after the leak detection timeout.  The returned timer must be
+// stopped when the span is closed.
+func watchForLeak(spanName string, timeout time.Duration) *time.Timer {
+	stack := debug.Stack()
+	return time.AfterFunc(timeout, func() {
+		log.Printf("logfire: span %q was not closed after %v, did you forget to call Close()? Created at:\n%s", spanName, timeout, stack)
+	})
+}
@@ -0,0 +1,86 @@
+package logfire
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// processStart approximates when the process started, as the time this package was
+// initialized.
+var processStart = time.Now()
+
+// recordStart emits the service.start span, covering the time from process start
+// until Initialize finished, with a summary of the config and the build.
+func recordStart(ctx context.Context, config *config) {
+	attrs := []attribute.KeyValue{
+		attribute.String("config.service_name", config.ServiceName),
+		attribute.String("config.endpoint", config.Endpoint),
+		attribute.Int("config.route_sampling_rules", len(config.RouteSampling)),
+		attribute.Float64("service.boot_duration_ms", float64(time.Since(processStart))/float64(time.Millisecond)),
+	}
+	attrs = append(attrs, buildAttributes()...)
+	if config.DependencyInventory {
+		attrs = append(attrs, dependencyAttributes()...)
+	}
+
+	_, span := globalTracer.Start(ctx, "service.start",
+		oteltrace.WithTimestamp(processStart),
+		oteltrace.WithAttributes(attrs...),
+	)
+	span.End()
+}
+
+// recordStop emits the service.stop span, which ends when the remaining telemetry is
+// about to be flushed.
+func recordStop(ctx context.Context) {
+	_, span := globalTracer.Start(ctx, "service.stop", oteltrace.WithAttributes(
+		attribute.Float64("service.uptime_s", time.Since(processStart).Seconds()),
+	))
+	span.End()
+}
+
+// buildAttributes describes how the binary was built.
+func buildAttributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("build.go_version", runtime.Version()),
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return attrs
+	}
+	attrs = append(attrs,
+		attribute.String("build.main_path", info.Main.Path),
+		attribute.String("build.main_version", info.Main.Version),
+	)
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.time", "vcs.modified":
+			attrs = append(attrs, attribute.String("build."+setting.Key, setting.Value))
+		}
+	}
+	return attrs
+}
+
+// dependencyAttributes lists the modules the binary was built with.
+func dependencyAttributes() []attribute.KeyValue {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	deps := make([]string, len(info.Deps))
+	for i, dep := range info.Deps {
+		deps[i] = dep.Path + "@" + dep.Version
+		if dep.Replace != nil {
+			deps[i] += " => " + dep.Replace.Path + "@" + dep.Replace.Version
+		}
+	}
+	return []attribute.KeyValue{
+		attribute.Int("build.dependencies.count", len(deps)),
+		attribute.StringSlice("build.dependencies", deps),
+	}
+}
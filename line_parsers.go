@@ -0,0 +1,287 @@
+package logfire
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ParsedLine is a line written to a LineWriter, parsed into a structured log.
+type ParsedLine struct {
+	// Level is the level of the log, or 0 to use the level of the LineWriter.
+	Level Level
+	// Message is the message of the log, or "" to use the whole line.
+	Message string
+	// Time is when the log happened, or the zero time if it isn't known.
+	Time time.Time
+	// Attributes are the fields of the log.
+	Attributes []attribute.KeyValue
+}
+
+// LineParser parses a line written to a LineWriter.  It returns false if the line
+// isn't in the format it parses, so the next parser is tried.
+type LineParser func(line string) (ParsedLine, bool)
+
+// levelNames maps level names, in lower case, to levels.
+var levelNames = map[string]Level{
+	"trace":    LevelTrace,
+	"debug":    LevelDebug,
+	"info":     LevelInfo,
+	"warn":     LevelWarn,
+	"warning":  LevelWarn,
+	"error":    LevelError,
+	"fatal":    LevelFatal,
+	"critical": LevelFatal,
+	"panic":    LevelFatal,
+}
+
+// LevelNames returns a copy of the level names, in lower case, that line parsers and
+// ParseLevel recognize, e.g. "warning" and "critical".
+func LevelNames() map[string]Level {
+	names := make(map[string]Level, len(levelNames))
+	for name, level := range levelNames {
+		names[name] = level
+	}
+	return names
+}
+
+// ParseLevel returns the level named name, compared case insensitively.
+func ParseLevel(name string) (Level, bool) {
+	level, ok := levelNames[strings.ToLower(name)]
+	return level, ok
+}
+
+var (
+	levelKeys   = []string{"level", "lvl", "severity"}
+	messageKeys = []string{"msg", "message"}
+	timeKeys    = []string{"time", "ts", "timestamp"}
+)
+
+// ParseJSONLine parses lines that are JSON objects, such as the output of zap, zerolog
+// or slog.JSONHandler.  The level, message and time are read from the usual fields,
+// e.g. "level", "msg" and "time", and every other field becomes an attribute.
+func ParseJSONLine(line string) (ParsedLine, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return ParsedLine{}, false
+	}
+	var fields map[string]any
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return ParsedLine{}, false
+	}
+	return structuredLine(fields), true
+}
+
+// ParseLogfmtLine parses logfmt lines, i.e. key=value pairs, such as the output of
+// logrus or slog.TextHandler.  Only lines with a level or message field are parsed,
+// so plain text that happens to contain "=" is left alone.
+func ParseLogfmtLine(line string) (ParsedLine, bool) {
+	fields, err := ParseLogfmt(line)
+	if err != nil || (findField(fields, levelKeys) == "" && findField(fields, messageKeys) == "") {
+		return ParsedLine{}, false
+	}
+	return structuredLine(fields), true
+}
+
+// klogLine matches the header of klog lines, e.g.
+// `I0102 15:04:05.123456   12345 main.go:42] message`.
+var klogLine = regexp.MustCompile(`^([IWEF])(\d{4} \d{2}:\d{2}:\d{2}\.\d{6})\s+(\d+) ([^:\]]+):(\d+)\] ?(.*)$`)
+
+// klogLevels maps the first letter of klog lines to levels.
+var klogLevels = map[string]Level{
+	"I": LevelInfo,
+	"W": LevelWarn,
+	"E": LevelError,
+	"F": LevelFatal,
+}
+
+// ParseKlogLine parses klog lines, as written by Kubernetes components and client-go.
+// The file and line of the log are recorded as attributes, and so are the key value
+// pairs of structured klog lines.  klog doesn't record the year, so the current year
+// is assumed.
+func ParseKlogLine(line string) (ParsedLine, bool) {
+	m := klogLine.FindStringSubmatch(line)
+	if m == nil {
+		return ParsedLine{}, false
+	}
+	parsed := ParsedLine{
+		Level:   klogLevels[m[1]],
+		Message: m[6],
+	}
+	if t, err := time.ParseInLocation("0102 15:04:05.000000", m[2], time.Local); err == nil {
+		parsed.Time = t.AddDate(globalClock.Now().Year(), 0, 0)
+	}
+	parsed.Attributes = append(parsed.Attributes, attribute.String("code.filepath", m[4]))
+	if n, err := strconv.Atoi(m[5]); err == nil {
+		parsed.Attributes = append(parsed.Attributes, attribute.Int("code.lineno", n))
+	}
+	if n, err := strconv.Atoi(m[3]); err == nil {
+		parsed.Attributes = append(parsed.Attributes, attribute.Int("thread.id", n))
+	}
+
+	// Structured lines have a quoted message followed by key value pairs.
+	if quoted, err := strconv.QuotedPrefix(parsed.Message); err == nil {
+		if fields, err := ParseLogfmt(parsed.Message[len(quoted):]); err == nil {
+			parsed.Message, _ = strconv.Unquote(quoted)
+			parsed.Attributes = append(parsed.Attributes, FieldAttributes(fields)...)
+		}
+	}
+	return parsed, true
+}
+
+// structuredLine maps the fields of a JSON or logfmt line to a ParsedLine.
+func structuredLine(fields map[string]any) ParsedLine {
+	var parsed ParsedLine
+	if key := findField(fields, levelKeys); key != "" {
+		if level, ok := ParseLevel(fmt.Sprint(fields[key])); ok {
+			parsed.Level = level
+			delete(fields, key)
+		}
+	}
+	if key := findField(fields, messageKeys); key != "" {
+		parsed.Message = fmt.Sprint(fields[key])
+		delete(fields, key)
+	}
+	if key := findField(fields, timeKeys); key != "" {
+		if t, err := ParseTime(fields[key], time.RFC3339Nano); err == nil {
+			parsed.Time = t
+			delete(fields, key)
+		}
+	}
+	parsed.Attributes = FieldAttributes(fields)
+	return parsed
+}
+
+// findField returns the first of keys that is in fields, or "" if there is none.
+func findField(fields map[string]any, keys []string) string {
+	for _, key := range keys {
+		if _, ok := fields[key]; ok {
+			return key
+		}
+	}
+	return ""
+}
+
+// ParseTime parses the time of a log record, either a number of Unix seconds, or a
+// string in the given layout.
+func ParseTime(v any, layout string) (time.Time, error) {
+	s := fmt.Sprint(v)
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		whole := int64(seconds)
+		return time.Unix(whole, int64((seconds-float64(whole))*float64(time.Second))), nil
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// FieldAttributes converts the fields of a log record, as returned by ParseLogfmt or
+// decoded from JSON, to attributes, flattening nested values.  JSON numbers become
+// int64s if they are whole, and float64s otherwise.  Attributes are sorted by key, so
+// the output is stable.
+func FieldAttributes(fields map[string]any) []attribute.KeyValue {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var attrs []attribute.KeyValue
+	for _, key := range keys {
+		value := fields[key]
+		if n, ok := value.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				value = i
+			} else if f, err := n.Float64(); err == nil {
+				value = f
+			} else {
+				value = n.String()
+			}
+		}
+		attrs = append(attrs, Flatten(key, value)...)
+	}
+	return attrs
+}
+
+// ParseLogfmt parses a single logfmt line, e.g. `level=info msg="hello world" ok`, into
+// its fields.  Keys without a value are set to "true".
+func ParseLogfmt(line string) (map[string]any, error) {
+	fields := make(map[string]any)
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		key := line[start:i]
+		if key == "" {
+			return nil, errors.New("logfmt: missing key")
+		}
+		if i >= len(line) || line[i] != '=' {
+			fields[key] = "true"
+			continue
+		}
+		i++ // Skip the '='.
+
+		if i < len(line) && line[i] == '"' {
+			value, n, err := parseQuoted(line[i:])
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = value
+			i += n
+			continue
+		}
+
+		start = i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		fields[key] = line[start:i]
+	}
+	return fields, nil
+}
+
+// parseQuoted parses the quoted string at the start of s, returning the unquoted value
+// and the number of bytes consumed.
+func parseQuoted(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, errors.New("logfmt: unterminated escape")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, errors.New("logfmt: unterminated quoted value")
+}
@@ -0,0 +1,219 @@
+package logfire
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		line string
+		want map[string]any
+	}{
+		{``, map[string]any{}},
+		{`a=1 b=two`, map[string]any{"a": "1", "b": "two"}},
+		{`msg="hello world" ok`, map[string]any{"msg": "hello world", "ok": "true"}},
+		{`msg="say \"hi\"\n" x=`, map[string]any{"msg": "say \"hi\"\n", "x": ""}},
+		{"  a=1\t\tb=2  ", map[string]any{"a": "1", "b": "2"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLogfmt(tt.line)
+		if err != nil {
+			t.Errorf("ParseLogfmt(%q) failed: %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLogfmt(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{`=1`, `msg="unterminated`, `msg="bad\`} {
+		if _, err := ParseLogfmt(line); err == nil {
+			t.Errorf("ParseLogfmt(%q) succeeded, want an error", line)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		want Level
+		ok   bool
+	}{
+		{"info", LevelInfo, true},
+		{"WARNING", LevelWarn, true},
+		{"Critical", LevelFatal, true},
+		{"verbose", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseLevel(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	names := LevelNames()
+	names["info"] = LevelFatal
+	if level, _ := ParseLevel("info"); level != LevelInfo {
+		t.Errorf("changing the map returned by LevelNames changed ParseLevel")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value any
+		want  time.Time
+	}{
+		{"2024-09-20T10:00:00.5Z", time.Date(2024, 9, 20, 10, 0, 0, 500000000, time.UTC)},
+		{json.Number("1726826400"), time.Unix(1726826400, 0)},
+		{1726826400.25, time.Unix(1726826400, 250000000)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.value, time.RFC3339Nano)
+		if err != nil {
+			t.Errorf("ParseTime(%v) failed: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+	if _, err := ParseTime("yesterday", time.RFC3339Nano); err == nil {
+		t.Errorf("ParseTime(%q) succeeded, want an error", "yesterday")
+	}
+}
+
+func TestFieldAttributes(t *testing.T) {
+	fields := map[string]any{
+		"zeta":  "last",
+		"alpha": json.Number("42"),
+		"mid":   json.Number("1.5"),
+		"http":  map[string]any{"method": "GET"},
+	}
+	want := []attribute.KeyValue{
+		attribute.Int64("alpha", 42),
+		attribute.String("http.method", "GET"),
+		attribute.Float64("mid", 1.5),
+		attribute.String("zeta", "last"),
+	}
+	// Map iteration is random, so check the order is stable over several runs.
+	for i := 0; i < 10; i++ {
+		if got := FieldAttributes(fields); !reflect.DeepEqual(got, want) {
+			t.Fatalf("FieldAttributes() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLineParsers(t *testing.T) {
+	local := time.Date(globalClock.Now().Year(), 1, 2, 15, 4, 5, 123456000, time.Local)
+	tests := []struct {
+		name   string
+		parser LineParser
+		line   string
+		want   ParsedLine
+		ok     bool
+	}{
+		{
+			name:   "json",
+			parser: ParseJSONLine,
+			line:   `{"level":"error","msg":"failed","time":"2024-09-20T10:00:00Z","attempt":3}`,
+			want: ParsedLine{
+				Level:      LevelError,
+				Message:    "failed",
+				Time:       time.Date(2024, 9, 20, 10, 0, 0, 0, time.UTC),
+				Attributes: []attribute.KeyValue{attribute.Int64("attempt", 3)},
+			},
+			ok: true,
+		},
+		{
+			name:   "json with an unknown level",
+			parser: ParseJSONLine,
+			line:   `{"level":"verbose","message":"hi"}`,
+			want: ParsedLine{
+				Message:    "hi",
+				Attributes: []attribute.KeyValue{attribute.String("level", "verbose")},
+			},
+			ok: true,
+		},
+		{
+			name:   "invalid json",
+			parser: ParseJSONLine,
+			line:   `{"level":`,
+		},
+		{
+			name:   "logfmt",
+			parser: ParseLogfmtLine,
+			line:   `level=warn msg="disk full" ts=1726826400 path=/var`,
+			want: ParsedLine{
+				Level:      LevelWarn,
+				Message:    "disk full",
+				Time:       time.Unix(1726826400, 0),
+				Attributes: []attribute.KeyValue{attribute.String("path", "/var")},
+			},
+			ok: true,
+		},
+		{
+			name:   "plain text with an equals sign",
+			parser: ParseLogfmtLine,
+			line:   `x=1 is not a log`,
+		},
+		{
+			name:   "klog",
+			parser: ParseKlogLine,
+			line:   `E0102 15:04:05.123456   12345 main.go:42] something broke`,
+			want: ParsedLine{
+				Level:   LevelError,
+				Message: "something broke",
+				Time:    local,
+				Attributes: []attribute.KeyValue{
+					attribute.String("code.filepath", "main.go"),
+					attribute.Int("code.lineno", 42),
+					attribute.Int("thread.id", 12345),
+				},
+			},
+			ok: true,
+		},
+		{
+			name:   "structured klog",
+			parser: ParseKlogLine,
+			line:   `I0102 15:04:05.123456       1 pod.go:7] "Pod synced" pod="default/web" ready=true`,
+			want: ParsedLine{
+				Level:   LevelInfo,
+				Message: "Pod synced",
+				Time:    local,
+				Attributes: []attribute.KeyValue{
+					attribute.String("code.filepath", "pod.go"),
+					attribute.Int("code.lineno", 7),
+					attribute.Int("thread.id", 1),
+					attribute.String("pod", "default/web"),
+					attribute.String("ready", "true"),
+				},
+			},
+			ok: true,
+		},
+		{
+			name:   "not klog",
+			parser: ParseKlogLine,
+			line:   `hello world`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.parser(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parsing %q returned %v, want %v", tt.line, ok, tt.ok)
+			}
+			if !got.Time.Equal(tt.want.Time) {
+				t.Errorf("parsing %q: time = %v, want %v", tt.line, got.Time, tt.want.Time)
+			}
+			got.Time, tt.want.Time = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsing %q = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package logfire
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// logRecord is a log that was held back instead of being sent.
+type logRecord struct {
+	ctx       context.Context
+	msg       string
+	severity  Level
+	opts      []SpanOption
+	timestamp time.Time
+}
+
+// send sends the log, at the time it was originally logged.
+func (r logRecord) send() {
+	opts := append([]SpanOption{WithTimestamp(r.timestamp)}, r.opts...)
+	opts = append(opts, WithAttributes(attribute.Bool("logfire.buffered", true)))
+	emitLog(r.ctx, r.msg, r.severity, opts)
+}
+
+// logBuffer is a ring buffer that keeps the most recent logs.
+type logBuffer struct {
+	mu      sync.Mutex
+	records []logRecord
+	// next is the index the next record is written to.
+	next int
+	full bool
+}
+
+func newLogBuffer(size int) *logBuffer {
+	return &logBuffer{records: make([]logRecord, size)}
+}
+
+// add adds a log, replacing the oldest log if the buffer is full.
+func (b *logBuffer) add(r logRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.records[b.next] = r
+	b.next = (b.next + 1) % len(b.records)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// drain removes and returns every log, oldest first.
+func (b *logBuffer) drain() []logRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []logRecord
+	if b.full {
+		records = append(records, b.records[b.next:]...)
+	}
+	records = append(records, b.records[:b.next]...)
+	clear(b.records)
+	b.next = 0
+	b.full = false
+	return records
+}
+
+type debugBufferKey struct{}
+
+// WithDebugBuffer keeps the last size logs below the minimum level, see WithMinLevel,
+// that are logged within the span, instead of dropping them.  If the span is closed
+// with an error they are sent, at the time they were logged and with the
+// logfire.buffered attribute, otherwise they are discarded.  This gives full debug
+// context for failed requests, while keeping successful requests cheap.
+func WithDebugBuffer(size int) SpanOption {
+	return func(c *spanConfig) {
+		c.DebugBufferSize = size
+	}
+}
+
+// bufferLog keeps a log that is below the minimum level, if ctx has a debug buffer.
+func bufferLog(ctx context.Context, msg string, severity Level, opts []SpanOption) {
+	buf, ok := ctx.Value(debugBufferKey{}).(*logBuffer)
+	if !ok {
+		return
+	}
+	buf.add(logRecord{
+		ctx:       ctx,
+		msg:       msg,
+		severity:  severity,
+		opts:      opts,
+		timestamp: globalClock.Now(),
+	})
+}
+
+// trailingLogsKey is the attribute of a Fatal log that holds the logs before it.
+const trailingLogsKey = attribute.Key("logfire.trailing_logs")
+
+// recordTrailingLog keeps the log in the trailing log buffer, if it is enabled.
+func recordTrailingLog(msg string, severity Level, timestamp time.Time) {
+	if globalTrailingLogs == nil {
+		return
+	}
+	globalTrailingLogs.add(logRecord{msg: msg, severity: severity, timestamp: timestamp})
+}
+
+// trailingLogsAttribute returns the logs in the trailing log buffer, oldest first, each
+// formatted as "<timestamp> <level> <message>".
+func trailingLogsAttribute() attribute.KeyValue {
+	records := globalTrailingLogs.drain()
+	lines := make([]string, len(records))
+	for i, r := range records {
+		lines[i] = r.timestamp.Format(time.RFC3339Nano) + " " + r.severity.String() + " " + r.msg
+	}
+	return trailingLogsKey.StringSlice(lines)
+}
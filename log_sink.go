@@ -0,0 +1,25 @@
+package logfire
+
+import (
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// LogSink receives Error and Fatal logs as well as Logfire, e.g. to mirror them to the
+// system log of the platform.
+type LogSink interface {
+	// WriteLog writes a log, with the span context of its span.
+	WriteLog(t time.Time, level Level, msg string, sc oteltrace.SpanContext, attrs []attribute.KeyValue) error
+}
+
+// writeLogSinks writes an Error or Fatal log to every sink.
+func writeLogSinks(t time.Time, level Level, msg string, sc oteltrace.SpanContext, attrs []attribute.KeyValue) {
+	for _, sink := range globalLogSinks {
+		if err := sink.WriteLog(t, level, msg, sc, attrs); err != nil {
+			log.Printf("Error writing log to sink: %v", err)
+		}
+	}
+}
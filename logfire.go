@@ -7,47 +7,160 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 
 	otellog "go.opentelemetry.io/otel/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 const (
-	serviceVersion         = "0.0.1"
-	defaultLogfireEndpoint = "https://logfire-api.pydantic.dev/v1"
-	logfireTracerName      = "logfire"
+	serviceVersion    = "0.0.1"
+	logfireTracerName = "logfire"
+)
+
+const (
+	// DefaultEndpoint is the Logfire endpoint used unless one is set with WithEndpoint.
+	DefaultEndpoint = "https://logfire-api.pydantic.dev/v1"
+	// DefaultUIURL is the URL of the Logfire UI that WithProject links to.
+	DefaultUIURL = "https://logfire.pydantic.dev"
 )
 
 var (
-	globalTracer      oteltrace.Tracer
-	globalServiceName string
-	globalLogger      *SpanLogger
+	globalTracer         oteltrace.Tracer
+	globalTracerProvider *sdktrace.TracerProvider
+	globalMeterProvider  *sdkmetric.MeterProvider
+	globalMeter          metric.Meter
+	globalServiceName    string
+	globalProjectURL     string
+	globalLogger         *SpanLogger
+
+	globalClock           Clock = systemClock{}
+	globalTraceAttributes *traceAttributesProcessor
+	globalExportQueue     *fanoutProcessor
+	globalUsage           *usageExporter
+	globalLeakTimeout     time.Duration
+	globalMinLevel        Level
+	globalForceTraceRule  func(*http.Request) bool
+	globalTrailingLogs    *logBuffer
+	globalBlobStore       BlobStore
+	globalJSONLogs        *jsonLogWriter
+	globalDroppedErrors   *jsonLogWriter
+	globalLogSinks        []LogSink
+	globalFatalNotifier   *fatalNotifier
 )
 
 // config is the config that is required to initialize the logfire logger.
 type config struct {
-	// ServiceName refers to the service this logger is for.
+	// ServiceName refers to the service this logger is for.  It is required.
 	ServiceName string
 	// APIToken is the Write API token for logfire.
 	APIToken string
 	// The endpoint to logfire.
 	Endpoint string
+	// OverflowPolicy decides what happens to spans when the export queue is full.
+	OverflowPolicy OverflowPolicy
+	// RouteSampling maps span names or HTTP routes to the ratio of traces to sample.
+	RouteSampling map[string]float64
+	// ScheduleSampling samples traces at a different rate during a daily window, if set.
+	ScheduleSampling *scheduleSampling
+	// WarmupSampling samples the first traces of every span name, if set.
+	WarmupSampling *warmupSampling
+	// LeakTimeout is how long a SpanLogger can stay open before it is reported as leaked.
+	LeakTimeout time.Duration
+	// HeartbeatInterval is how often the service reports that it is alive.
+	HeartbeatInterval time.Duration
+	// GenericOTLP exports standard OTLP, without any Logfire specific defaults.
+	GenericOTLP bool
+	// AdditionalExporters receive every span, as well as Logfire.
+	AdditionalExporters []sdktrace.SpanExporter
+	// MetricReaders read every metric, as well as the Logfire exporter.
+	MetricReaders []sdkmetric.Reader
+	// RuntimeMetrics reports expvar values and runtime/metrics counters as metrics.
+	RuntimeMetrics bool
+	// DependencyInventory records the module dependencies of the binary at startup.
+	DependencyInventory bool
+	// DumpOnSIGQUIT logs a goroutine dump whenever the process receives SIGQUIT.
+	DumpOnSIGQUIT bool
+	// Watchdog warns about goroutine leaks and scheduler latency, if set.
+	Watchdog *Watchdog
+	// MemoryWatcher warns about memory usage and GC pauses, if set.
+	MemoryWatcher *MemoryWatcher
+	// Propagators are the names of the formats that trace context is propagated in.
+	Propagators []string
+	// ProjectURL is the URL of the Logfire project, used to link to traces.
+	ProjectURL string
+	// Clock tells the time for spans, logs and batching.
+	Clock Clock
+	// IDGenerator generates trace and span IDs, if set.
+	IDGenerator sdktrace.IDGenerator
+	// SpanExporter replaces the Logfire exporter, if set.
+	SpanExporter sdktrace.SpanExporter
+	// MinLevel is the least severe level of logs that are sent.
+	MinLevel Level
+	// ForceTraceRule matches requests that are traced and logged in full, if set.
+	ForceTraceRule func(*http.Request) bool
+	// TrailingLogs is how many recent logs are attached to Fatal logs.
+	TrailingLogs int
+	// RequiredAttributes are the attribute keys every span must have.
+	RequiredAttributes []string
+	// MissingAttributesHook handles spans missing required attributes, if set.
+	MissingAttributesHook MissingAttributesHook
+	// AttributeAllowlist are the attribute key patterns that are exported, if set.
+	AttributeAllowlist []string
+	// AttributeDenylist are the attribute key patterns that are never exported.
+	AttributeDenylist []string
+	// DailyByteBudget is the bytes that can be exported to Logfire per day before only
+	// errors are exported, or 0 for no budget.
+	DailyByteBudget uint64
+	// CompressionMinRun is the shortest run of identical child spans that is
+	// compressed, or 0 to not compress spans.
+	CompressionMinRun int
+	// BlobStore stores attachments that are too large to record on spans, if set.
+	BlobStore BlobStore
+	// JSONLogs receives every log as a JSON line, as well as Logfire, if set.
+	JSONLogs io.Writer
+	// DroppedErrorLogs receives Error and Fatal logs that aren't sent to Logfire, as
+	// JSON lines, if set.
+	DroppedErrorLogs io.Writer
+	// LogSinks receive Error and Fatal logs, as well as Logfire.
+	LogSinks []LogSink
+	// ShutdownTimeout is how long the closer waits for queued spans to be exported, or 0
+	// to wait until the context of Initialize is done.
+	ShutdownTimeout time.Duration
+	// DebugSocket is the path of the unix socket exported spans are served on, if set.
+	DebugSocket string
+	// FatalNotifierURL is the webhook that Fatal logs and panics are posted to, if set.
+	FatalNotifierURL string
+	// ContextExtractors return attributes to set on every span and log from its context.
+	ContextExtractors []ContextExtractor
+	// TenantRouter returns the tenant of each span, to route it to the project of its
+	// token in TenantTokens.
+	TenantRouter TenantRouter
+	// TenantTokens are the write tokens of the project of each tenant.
+	TenantTokens map[string]string
 }
 
 // Option is a function type that modifies Config.
 type Option func(*config)
 
-// WithServiceName sets the service name in the Config.
+// WithServiceName sets the service name in the Config.  A service name is required.
 func WithServiceName(name string) Option {
 	return func(c *config) {
 		c.ServiceName = name
@@ -68,17 +181,446 @@ func WithAPIToken(token string) Option {
 	}
 }
 
+// WithOverflowPolicy sets what happens to spans when the export queue is full.
+// Defaults to DropNewest.
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(c *config) {
+		c.OverflowPolicy = policy
+	}
+}
+
+// WithRouteSampling samples traces by the span name or HTTP route of their root span,
+// e.g. {"/healthz": 0.001, "/checkout": 1}.  Rates are between 0 and 1.  Routes that
+// are not listed are sampled by WithWarmupSampling or WithScheduleSampling if they are
+// set, and are always sampled otherwise.
+func WithRouteSampling(rates map[string]float64) Option {
+	return func(c *config) {
+		c.RouteSampling = rates
+	}
+}
+
+// WithScheduleSampling samples traces at rate during a daily window of local time, e.g.
+// "02:00-03:00", and at defaultRate outside it.  This captures full fidelity traces
+// during low traffic windows, e.g. for profiling, while sampling the rest of the day.
+// Rates are between 0 and 1, and WithRouteSampling takes precedence for the routes it
+// lists.
+//
+//	logfire.Initialize(ctx, logfire.WithScheduleSampling("02:00-03:00", 1, 0.05))
+func WithScheduleSampling(window string, rate, defaultRate float64) Option {
+	return func(c *config) {
+		c.ScheduleSampling = &scheduleSampling{
+			Window:      window,
+			Rate:        rate,
+			DefaultRate: defaultRate,
+		}
+	}
+}
+
+// WithWarmupSampling samples the first n traces of each root span name, and traces at
+// rate after that, so new endpoints and deployments always have representative traces
+// without paying for every trace.  Counts start again when the process restarts.
+// With WithScheduleSampling, traces after the first n are sampled by the schedule
+// instead of rate.
+//
+//	logfire.Initialize(ctx, logfire.WithWarmupSampling(100, 0.05))
+func WithWarmupSampling(n int, rate float64) Option {
+	return func(c *config) {
+		c.WarmupSampling = &warmupSampling{
+			N:    n,
+			Rate: rate,
+		}
+	}
+}
+
+// WithLeakDetection warns, with the stack that created it, about any SpanLogger that
+// is still open after the timeout.  This is a debugging aid to find missing calls to
+// Close, and captures a stack trace for every span created.
+func WithLeakDetection(timeout time.Duration) Option {
+	return func(c *config) {
+		c.LeakTimeout = timeout
+	}
+}
+
+// WithHeartbeat records the service.heartbeat and service.uptime metrics every
+// interval, so that alerts can fire when a service stops reporting.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(c *config) {
+		c.HeartbeatInterval = interval
+	}
+}
+
+// WithGenericOTLP exports standard OTLP without any Logfire specific defaults, e.g. to
+// send telemetry to a local OpenTelemetry Collector.  No API token is required, the
+// standard OTEL_EXPORTER_OTLP_* environment variables configure the exporters, and an
+// endpoint set with WithEndpoint gets the standard /v1/traces and /v1/metrics paths.
+//
+// This can also be turned on by setting the LOGFIRE_GENERIC_OTLP environment variable
+// to true, so the same code can export to a collector in development and to Logfire
+// in production.
+func WithGenericOTLP() Option {
+	return func(c *config) {
+		c.GenericOTLP = true
+	}
+}
+
+// WithAdditionalExporter sends every span to exporter as well as Logfire, e.g. to a
+// self-hosted Jaeger or Tempo while migrating.  Each exporter has its own queue, so a
+// failing exporter doesn't affect the others.
+func WithAdditionalExporter(exporter sdktrace.SpanExporter) Option {
+	return func(c *config) {
+		c.AdditionalExporters = append(c.AdditionalExporters, exporter)
+	}
+}
+
+// WithMetricReader registers an additional metric reader, such as a Prometheus
+// exporter, so metrics are available to it as well as being sent to Logfire.
+func WithMetricReader(reader sdkmetric.Reader) Option {
+	return func(c *config) {
+		c.MetricReaders = append(c.MetricReaders, reader)
+	}
+}
+
+// WithRuntimeMetrics reports the Go runtime/metrics counters, e.g. go.sched.goroutines,
+// and every numeric value published with expvar as metrics.  This captures telemetry
+// from libraries that only publish expvars.  Values are snapshotted each time metrics
+// are exported.
+func WithRuntimeMetrics() Option {
+	return func(c *config) {
+		c.RuntimeMetrics = true
+	}
+}
+
+// WithDependencyInventory records the modules the binary was built with, from its build
+// info, on the service.start span, so security and platform teams can find which
+// deployments run vulnerable versions.  Each module is recorded as "path@version" in
+// build.dependencies, followed by " => path@version" if it is replaced.
+func WithDependencyInventory() Option {
+	return func(c *config) {
+		c.DependencyInventory = true
+	}
+}
+
+// WithGoroutineDumpOnSIGQUIT logs a goroutine dump, as with DumpGoroutines, whenever
+// the process receives SIGQUIT.  The process keeps running afterwards, instead of
+// printing the dump to stderr and exiting.
+func WithGoroutineDumpOnSIGQUIT() Option {
+	return func(c *config) {
+		c.DumpOnSIGQUIT = true
+	}
+}
+
+// WithWatchdog samples the number of goroutines and the scheduler latency, and logs a
+// Warn with a goroutine dump and the currently open spans when a threshold is exceeded.
+// This helps to find deadlocks, goroutine leaks and starved processes.
+func WithWatchdog(watchdog Watchdog) Option {
+	return func(c *config) {
+		c.Watchdog = &watchdog
+	}
+}
+
+// WithMemoryWatcher samples memory usage and GC pauses, and logs a Warn or Error with
+// heap stats when a threshold is exceeded, to help catch leaks before the OOM killer
+// does.
+func WithMemoryWatcher(watcher MemoryWatcher) Option {
+	return func(c *config) {
+		c.MemoryWatcher = &watcher
+	}
+}
+
+// WithPropagators sets the formats that trace context is propagated in, so traces
+// interoperate with service meshes and legacy services that only speak B3 or Jaeger
+// headers.  Supported names are "tracecontext", "baggage", "b3", "b3multi", "jaeger",
+// "xray" and "ottrace".  Incoming requests are accepted in any of the formats, and
+// outgoing requests carry all of them.
+//
+// The "datadog" and "gcp" names only accept the trace context of incoming requests,
+// from Datadog headers and the Google Cloud X-Cloud-Trace-Context header, so traces
+// started in those ecosystems continue in Logfire instead of starting new roots.
+//
+// Defaults to the OTEL_PROPAGATORS environment variable, or "tracecontext" and
+// "baggage" if it isn't set.
+func WithPropagators(names ...string) Option {
+	return func(c *config) {
+		c.Propagators = names
+	}
+}
+
+// WithProjectURL sets the URL of the Logfire project, e.g.
+// https://logfire.pydantic.dev/my-org/my-project, so that links to traces can be
+// created.
+func WithProjectURL(projectURL string) Option {
+	return func(c *config) {
+		c.ProjectURL = strings.TrimSuffix(projectURL, "/")
+	}
+}
+
+// WithProject sets the organization and project in Logfire, so that links to traces can
+// be created.  Use WithProjectURL for projects that aren't hosted at DefaultUIURL.
+func WithProject(org, project string) Option {
+	return WithProjectURL(DefaultUIURL + "/" + url.PathEscape(org) + "/" + url.PathEscape(project))
+}
+
+// WithClock replaces the clock used to timestamp spans and logs, and to time export
+// batches, e.g. with a fake clock so tests produce deterministic timestamps and
+// durations.  Spans created by other integrations through Tracer are not affected.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.Clock = clock
+	}
+}
+
+// WithIDGenerator replaces the random generator of trace and span IDs, e.g. so tests
+// produce stable IDs, or to encode shard or tenant bits into IDs.  Generated IDs must
+// still be unique, or traces will be merged.
+func WithIDGenerator(generator sdktrace.IDGenerator) Option {
+	return func(c *config) {
+		c.IDGenerator = generator
+	}
+}
+
+// WithSpanExporter replaces the Logfire exporter, e.g. with an in-memory exporter in
+// tests.  No API token is required, and metrics are only sent to readers registered
+// with WithMetricReader.
+func WithSpanExporter(exporter sdktrace.SpanExporter) Option {
+	return func(c *config) {
+		c.SpanExporter = exporter
+	}
+}
+
+// WithMinLevel only sends logs that are at least as severe as level.  Defaults to
+// LevelTrace, which sends every log.
+func WithMinLevel(level Level) Option {
+	return func(c *config) {
+		c.MinLevel = level
+	}
+}
+
+// WithForceTraceRule forces HTTP requests that match rule, e.g. by a debug header or
+// user ID, to be sampled and to send logs of every level, for targeted debugging in
+// production.  The rule is applied by HTTP middlewares, see ForceTraceContext.
+//
+//	logfire.WithForceTraceRule(func(r *http.Request) bool {
+//		return r.Header.Get("X-Debug-Trace") == debugToken
+//	})
+func WithForceTraceRule(rule func(r *http.Request) bool) Option {
+	return func(c *config) {
+		c.ForceTraceRule = rule
+	}
+}
+
+// WithTrailingLogs keeps the last n logs in memory, including logs below the minimum
+// level, and attaches them to the next Fatal log, including the log of a panic reported
+// by HandleCrash, in the logfire.trailing_logs attribute.  This makes the events leading
+// up to a crash available, even when they weren't sent.
+func WithTrailingLogs(n int) Option {
+	return func(c *config) {
+		c.TrailingLogs = n
+	}
+}
+
+// WithRequiredAttributes requires every span and log to have the given attributes,
+// e.g. "tenant_id", to enforce telemetry standards across services.  Attributes set
+// with SetTraceAttributes count.  By default, the first span with each name that is
+// missing attributes is warned about, and every span is exported anyway, see
+// WithMissingAttributesHook.
+func WithRequiredAttributes(keys ...string) Option {
+	return func(c *config) {
+		c.RequiredAttributes = append(c.RequiredAttributes, keys...)
+	}
+}
+
+// WithMissingAttributesHook replaces the warning about spans missing the attributes
+// required by WithRequiredAttributes.  Spans are dropped if the hook returns false.
+func WithMissingAttributesHook(hook MissingAttributesHook) Option {
+	return func(c *config) {
+		c.MissingAttributesHook = hook
+	}
+}
+
+// WithAttributeAllowlist only exports attributes whose keys match one of the patterns,
+// so platform owners can guarantee which keys leave the process, regardless of what
+// application code sets.  A pattern is either an exact key, or a prefix followed by
+// "*", e.g. "http.*".  The logfire.* attributes are always exported.
+func WithAttributeAllowlist(patterns ...string) Option {
+	return func(c *config) {
+		c.AttributeAllowlist = append(c.AttributeAllowlist, patterns...)
+	}
+}
+
+// WithAttributeDenylist never exports attributes whose keys match one of the patterns,
+// e.g. high-cardinality or sensitive keys such as "user.email" or
+// "http.request.header.*".  The denylist takes precedence over the allowlist.
+func WithAttributeDenylist(patterns ...string) Option {
+	return func(c *config) {
+		c.AttributeDenylist = append(c.AttributeDenylist, patterns...)
+	}
+}
+
+// WithDailyByteBudget limits the telemetry exported to Logfire to approximately bytes
+// per day, in UTC.  Once the budget is exceeded, only failed spans and logs of at least
+// error severity are exported to Logfire until the next day, protecting against
+// surprise bills.  Additional exporters and the debug socket still get every span.  See
+// GetStats for the bytes exported.
+func WithDailyByteBudget(bytes uint64) Option {
+	return func(c *config) {
+		c.DailyByteBudget = bytes
+	}
+}
+
+// WithSpanCompression collapses runs of at least minRun consecutive sibling spans with
+// the same name, e.g. 10,000 row inserts, into one aggregate span, keeping traces
+// readable and cheap.  The aggregate span is the first span of the run, ending when the
+// last span ended, with the logfire.compressed.count attribute and the min, max and
+// mean durations of the run.  Failed spans, and spans with children, are never
+// compressed.
+//
+// Child spans are held until their parent ends, or a sibling with a different name
+// ends, so they are exported later than usual.
+func WithSpanCompression(minRun int) Option {
+	return func(c *config) {
+		c.CompressionMinRun = minRun
+	}
+}
+
+// WithBlobStore streams attachments recorded with Attach to store, rather than
+// truncating them into attributes.
+func WithBlobStore(store BlobStore) Option {
+	return func(c *config) {
+		c.BlobStore = store
+	}
+}
+
+// WithJSONLogs writes every log sent to Logfire to w as a JSON line, with its time,
+// level, message, attributes, and the trace_id and span_id to correlate it with
+// Logfire.  Use it with os.Stdout on platforms that collect the stdout of containers,
+// such as GKE or ECS.
+//
+//	logfire.Initialize(ctx, logfire.WithJSONLogs(os.Stdout))
+func WithJSONLogs(w io.Writer) Option {
+	return func(c *config) {
+		c.JSONLogs = w
+	}
+}
+
+// WithDroppedErrorLogs writes Error and Fatal logs that aren't sent to Logfire, because
+// their trace isn't sampled or their level is filtered out, to w as JSON lines, in the
+// same format as WithJSONLogs.  No error is then completely invisible, even under
+// aggressive sampling.
+//
+//	logfire.Initialize(ctx, logfire.WithRouteSampling(rates), logfire.WithDroppedErrorLogs(os.Stderr))
+func WithDroppedErrorLogs(w io.Writer) Option {
+	return func(c *config) {
+		c.DroppedErrorLogs = w
+	}
+}
+
+// WithLogSink mirrors Error and Fatal logs to sink, as well as sending them to Logfire,
+// e.g. to the Windows Event Log with logfiresystemlog.  It can be used more than once.
+func WithLogSink(sink LogSink) Option {
+	return func(c *config) {
+		c.LogSinks = append(c.LogSinks, sink)
+	}
+}
+
+// WithShutdownTimeout caps how long the closer returned by Initialize waits for queued
+// spans to be exported.  Spans still queued after d are dropped, and counted in the
+// DroppedSpans of GetStats.  While draining, the number of spans remaining is logged
+// every second.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.ShutdownTimeout = d
+	}
+}
+
+// WithDebugSocket serves every exported span, as JSON lines, to clients of the unix
+// socket at path, so telemetry can be followed locally with logfire-tail:
+//
+//	go run github.com/jerechua/logfire-go/cmd/logfire-tail /tmp/my-service.sock
+func WithDebugSocket(path string) Option {
+	return func(c *config) {
+		c.DebugSocket = path
+	}
+}
+
+// WithFatalNotifier posts a short message to webhookURL, e.g. a Slack incoming webhook,
+// for every Fatal log and every panic reported by HandleCrash.  The message links to
+// the trace if a project is configured with WithProject or WithProjectURL.  At most one
+// message is posted a minute.
+func WithFatalNotifier(webhookURL string) Option {
+	return func(c *config) {
+		c.FatalNotifierURL = webhookURL
+	}
+}
+
+// WithContextExtractor runs extractor whenever a span or log starts, including spans of
+// other integrations, setting the attributes it returns from the context, so auth
+// claims, the locale or other request metadata are recorded without plumbing them
+// through every call:
+//
+//	logfire.WithContextExtractor(func(ctx context.Context) []attribute.KeyValue {
+//		if user, ok := auth.UserFromContext(ctx); ok {
+//			return []attribute.KeyValue{attribute.String("enduser.id", user.ID)}
+//		}
+//		return nil
+//	})
+//
+// It can be used more than once, and extractors run in order.  Extractors run on every
+// span, so they must be fast.
+func WithContextExtractor(extractor ContextExtractor) Option {
+	return func(c *config) {
+		c.ContextExtractors = append(c.ContextExtractors, extractor)
+	}
+}
+
+// WithTenantRouter sends the spans and logs of each tenant to its own Logfire project,
+// so a multi-tenant service can give every customer their own telemetry.  router
+// returns the tenant of the context a span starts in, e.g. from the request's auth
+// claims, and tokens maps tenants to the write tokens of their projects:
+//
+//	logfire.WithTenantRouter(func(ctx context.Context) string {
+//		return auth.TenantFromContext(ctx)
+//	}, map[string]string{"acme": acmeToken, "globex": globexToken})
+//
+// Spans without a tenant inherit the tenant of their parent.  Spans of tenants not in
+// tokens, and metrics, are sent to the project of the API token.  The tenant is recorded
+// on every span as the "logfire.tenant" attribute.
+func WithTenantRouter(router TenantRouter, tokens map[string]string) Option {
+	return func(c *config) {
+		c.TenantRouter = router
+		c.TenantTokens = tokens
+	}
+}
+
 // newConfigWithDefaults creates a new Config with default values and applies the given options.
 func newConfigWithDefaults(options ...Option) *config {
+	genericOTLP, _ := strconv.ParseBool(os.Getenv("LOGFIRE_GENERIC_OTLP"))
 	config := &config{
-		APIToken: os.Getenv("LOGFIRE_TOKEN"),
-		Endpoint: defaultLogfireEndpoint,
+		APIToken:       os.Getenv("LOGFIRE_TOKEN"),
+		Endpoint:       DefaultEndpoint,
+		OverflowPolicy: DropNewest,
+		GenericOTLP:    genericOTLP,
+		Clock:          systemClock{},
+		MinLevel:       LevelTrace,
 	}
 
 	for _, option := range options {
 		option(config)
 	}
 
+	// Fall back to the credentials of the Logfire CLI, so local development works
+	// without exporting LOGFIRE_TOKEN.
+	if config.APIToken == "" && !config.GenericOTLP && config.SpanExporter == nil {
+		if creds, ok := loadCredentials(); ok {
+			config.APIToken = creds.Token
+			if creds.BaseURL != "" && config.Endpoint == DefaultEndpoint {
+				config.Endpoint = strings.TrimSuffix(creds.BaseURL, "/") + "/v1"
+			}
+			if config.ProjectURL == "" {
+				config.ProjectURL = creds.ProjectURL
+			}
+		}
+	}
+
 	return config
 }
 
@@ -91,23 +633,66 @@ func ServiceName() string {
 func Initialize(ctx context.Context, opts ...Option) (func(), error) {
 	config := newConfigWithDefaults(opts...)
 
-	globalServiceName = config.ServiceName
-
-	if config.APIToken == "" {
-		return nil, errors.New("config.APIToken is required")
+	// Report every problem with the config at once, before changing any global state.
+	errs := config.validate()
+	propagator, err := newPropagator(config.Propagators)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	sampler, err := newSampler(config)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid logfire config: %w", errors.Join(errs...))
+	}
+	for _, err := range envTypos(os.Environ()) {
+		log.Printf("logfire: %v", err)
+	}
+	// The debug socket is opened before changing any global state, so failing to listen
+	// leaves logfire as it was.
+	var socket *debugSocket
+	if config.DebugSocket != "" {
+		socket, err = newDebugSocket(config.DebugSocket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on debug socket: %w", err)
+		}
 	}
 
-	var headers = map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %s", config.APIToken),
+	globalServiceName = config.ServiceName
+	globalProjectURL = config.ProjectURL
+	globalClock = config.Clock
+	globalLeakTimeout = config.LeakTimeout
+	globalMinLevel = config.MinLevel
+	globalForceTraceRule = config.ForceTraceRule
+	globalBlobStore = config.BlobStore
+	globalFatalNotifier = nil
+	if config.FatalNotifierURL != "" {
+		globalFatalNotifier = newFatalNotifier(config.FatalNotifierURL)
+	}
+	globalJSONLogs = nil
+	if config.JSONLogs != nil {
+		globalJSONLogs = newJSONLogWriter(config.JSONLogs)
+	}
+	globalLogSinks = config.LogSinks
+	globalDroppedErrors = nil
+	if config.DroppedErrorLogs != nil {
+		globalDroppedErrors = newJSONLogWriter(config.DroppedErrorLogs)
+	}
+	globalTrailingLogs = nil
+	if config.TrailingLogs > 0 {
+		globalTrailingLogs = newLogBuffer(config.TrailingLogs)
 	}
 
-	exporter, err := otlptracehttp.New(
-		ctx,
-		otlptracehttp.WithEndpointURL(config.Endpoint+"/traces"),
-		otlptracehttp.WithHeaders(headers),
-	)
-	if err != nil {
-		log.Fatalf("Failed to create exporter: %v", err)
+	exporter := config.SpanExporter
+	if exporter == nil {
+		exporter, err = otlptracehttp.New(ctx, traceExporterOptions(config)...)
+		if err != nil {
+			log.Fatalf("Failed to create exporter: %v", err)
+		}
+		if config.TenantRouter != nil {
+			exporter = newTenantExporter(exporter, config)
+		}
 	}
 
 	resources, err := resource.New(
@@ -121,15 +706,68 @@ func Initialize(ctx context.Context, opts ...Option) (func(), error) {
 		log.Fatalf("Failed to create resource: %v", err)
 	}
 
-	provider := sdktrace.NewTracerProvider(
+	globalTraceAttributes = newTraceAttributesProcessor()
+	globalUsage = newUsageExporter(exporter, config.DailyByteBudget, config.Clock)
+	exporters := append([]sdktrace.SpanExporter{globalUsage}, config.AdditionalExporters...)
+	if socket != nil {
+		exporters = append(exporters, socket)
+	}
+	globalExportQueue = newFanoutProcessor(config.OverflowPolicy, exporters...)
+	if config.CompressionMinRun > 1 {
+		globalExportQueue.compressor = newCompressor(config.CompressionMinRun, config.Clock)
+	}
+	if len(config.RequiredAttributes) > 0 {
+		globalExportQueue.required = newRequiredAttributes(config.RequiredAttributes, config.MissingAttributesHook)
+	}
+	if len(config.AttributeAllowlist) > 0 || len(config.AttributeDenylist) > 0 {
+		globalExportQueue.policy = &attributePolicy{
+			allow: config.AttributeAllowlist,
+			deny:  config.AttributeDenylist,
+		}
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSpanProcessor(globalTraceAttributes),
+	}
+	if len(config.ContextExtractors) > 0 {
+		providerOpts = append(providerOpts, sdktrace.WithSpanProcessor(&contextExtractorProcessor{extractors: config.ContextExtractors}))
+	}
+	if config.TenantRouter != nil {
+		providerOpts = append(providerOpts, sdktrace.WithSpanProcessor(&tenantProcessor{route: config.TenantRouter}))
+	}
+	providerOpts = append(providerOpts,
 		// TODO: This doesn't seem to send live log events?
-		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(1*time.Second)),
+		sdktrace.WithSpanProcessor(globalExportQueue),
 		sdktrace.WithResource(resources),
+		sdktrace.WithSampler(sampler),
 	)
+	if config.IDGenerator != nil {
+		providerOpts = append(providerOpts, sdktrace.WithIDGenerator(config.IDGenerator))
+	}
+	provider := sdktrace.NewTracerProvider(providerOpts...)
 
 	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	globalTracerProvider = provider
+
+	meterProvider, err := newMeterProvider(ctx, config, resources)
+	if err != nil {
+		log.Fatalf("Failed to create meter provider: %v", err)
+	}
+	otel.SetMeterProvider(meterProvider)
+	globalMeterProvider = meterProvider
+
+	globalTracer = newTracer(logfireTracerName, []ScopeOption{WithScope(logfireTracerName, serviceVersion)})
+	globalMeter = otel.Meter(logfireTracerName)
+
+	if config.RuntimeMetrics {
+		if err := registerRuntimeMetrics(globalMeter); err != nil {
+			otel.Handle(err)
+		}
+	}
+
+	recordStart(ctx, config)
 
-	globalTracer = otel.Tracer(logfireTracerName)
 	globalLogger = &SpanLogger{
 		spanCtx: context.Background(),
 		// This is unused for the global logger.  You should not
@@ -137,20 +775,90 @@ func Initialize(ctx context.Context, opts ...Option) (func(), error) {
 		span: nil,
 	}
 
+	stopHeartbeat := func() {}
+	if config.HeartbeatInterval > 0 {
+		stopHeartbeat = startHeartbeat(globalMeter, config.HeartbeatInterval)
+	}
+	stopDumpOnSignal := func() {}
+	if config.DumpOnSIGQUIT {
+		stopDumpOnSignal = dumpGoroutinesOnSignal()
+	}
+	stopWatchdog := func() {}
+	if config.Watchdog != nil {
+		stopWatchdog = startWatchdog(*config.Watchdog)
+	}
+	stopMemoryWatcher := func() {}
+	if config.MemoryWatcher != nil {
+		stopMemoryWatcher = startMemoryWatcher(*config.MemoryWatcher)
+	}
+
 	return func() {
-		if err := provider.Shutdown(ctx); err != nil {
+		stopMemoryWatcher()
+		stopWatchdog()
+		stopDumpOnSignal()
+		stopHeartbeat()
+		recordStop(ctx)
+		shutdownCtx := ctx
+		if config.ShutdownTimeout > 0 {
+			var cancel context.CancelFunc
+			shutdownCtx, cancel = context.WithTimeout(ctx, config.ShutdownTimeout)
+			defer cancel()
+		}
+		if err := provider.Shutdown(shutdownCtx); err != nil {
 			log.Printf("Error shutting down tracer provider: %v", err)
 		}
+		if err := meterProvider.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down meter provider: %v", err)
+		}
 	}, nil
 }
 
-func sendLog(ctx context.Context, msg string, severity otellog.Severity) {
-	_, span := globalTracer.Start(ctx, msg)
-	defer span.End()
+func sendLog(ctx context.Context, msg string, severity otellog.Severity, opts []SpanOption) {
+	if globalTrailingLogs != nil {
+		if severity >= LevelFatal {
+			opts = append(opts[:len(opts):len(opts)], WithAttributes(trailingLogsAttribute()))
+		} else {
+			recordTrailingLog(msg, severity, globalClock.Now())
+		}
+	}
+	if severity < globalMinLevel && !debugForced(ctx) {
+		if severity >= LevelError && globalDroppedErrors != nil {
+			config := newSpanConfig(opts)
+			globalDroppedErrors.write(config.Timestamp, severity, msg, oteltrace.SpanContextFromContext(ctx), config.Attributes)
+		}
+		bufferLog(ctx, msg, severity, opts)
+		return
+	}
+	emitLog(ctx, msg, severity, opts)
+}
+
+// emitLog sends a log, regardless of its level.
+func emitLog(ctx context.Context, msg string, severity otellog.Severity, opts []SpanOption) {
+	tracer, componentAttrs := tracerFor(ctx)
+	config := newSpanConfig(append([]SpanOption{WithAttributes(componentAttrs...)}, opts...))
+
+	// The span type is set when the span starts, so samplers can tell logs from spans.
+	_, span := tracer.Start(ctx, msg, append(config.startOptions(),
+		oteltrace.WithAttributes(attribute.String("logfire.span_type", "log")),
+	)...)
+	// A log has no duration, it ends at the same time it started.
+	defer span.End(oteltrace.WithTimestamp(config.Timestamp))
+
+	if globalJSONLogs != nil {
+		globalJSONLogs.write(config.Timestamp, severity, msg, span.SpanContext(), config.Attributes)
+	}
+	if severity >= LevelError && globalDroppedErrors != nil && !span.SpanContext().IsSampled() {
+		globalDroppedErrors.write(config.Timestamp, severity, msg, span.SpanContext(), config.Attributes)
+	}
+	if severity >= LevelError && len(globalLogSinks) > 0 {
+		writeLogSinks(config.Timestamp, severity, msg, span.SpanContext(), config.Attributes)
+	}
+	if severity >= LevelFatal && globalFatalNotifier != nil {
+		globalFatalNotifier.notify(msg, span.SpanContext())
+	}
 
 	// Add some attributes to the span
 	span.SetAttributes(
-		attribute.String("logfire.span_type", "log"),
 		attribute.String("logfire.msg_template", "log message template"),
 		attribute.String("logfire.msg", msg),
 		attribute.Int("logfire.level_num", int(severity)),
@@ -160,41 +868,78 @@ func sendLog(ctx context.Context, msg string, severity otellog.Severity) {
 // Tracer returns an OpenTelemetry Tracer that can be used to hook into other
 // OpenTelemetry integrations.  Integrations using this tracer will send logs directly
 // to Logfire.
-func Tracer() oteltrace.Tracer {
+//
+// The tracer has the "logfire" instrumentation scope, unless it is changed with
+// WithScope, e.g. to the name and version of the library creating the spans.
+func Tracer(opts ...ScopeOption) oteltrace.Tracer {
 	if globalTracer == nil {
 		panic("did you forget to call Initialize()?")
 	}
-	return globalTracer
+	if len(opts) == 0 {
+		return globalTracer
+	}
+	return newTracer(logfireTracerName, opts)
+}
+
+// Flush synchronously exports every span that has ended, and the current metrics, e.g.
+// before a process exits without calling the closer, or before inspecting exported
+// spans in a test.
+func Flush(ctx context.Context) error {
+	if globalTracerProvider == nil {
+		return nil
+	}
+	return errors.Join(
+		globalTracerProvider.ForceFlush(ctx),
+		globalMeterProvider.ForceFlush(ctx),
+	)
+}
+
+// Level is the severity of a log.
+type Level = otellog.Severity
+
+// Log levels, from least to most severe.
+const (
+	LevelTrace = otellog.SeverityTrace
+	LevelDebug = otellog.SeverityDebug
+	LevelInfo  = otellog.SeverityInfo
+	LevelWarn  = otellog.SeverityWarn
+	LevelError = otellog.SeverityError
+	LevelFatal = otellog.SeverityFatal
+)
+
+// Log logs a message to Logfire with the given severity.
+func Log(level Level, msg string, opts ...SpanOption) {
+	globalLogger.Log(level, msg, opts...)
 }
 
 // Trace logs a message to Logfire with severity Trace.
-func Trace(msg string) {
-	globalLogger.Trace(msg)
+func Trace(msg string, opts ...SpanOption) {
+	globalLogger.Trace(msg, opts...)
 }
 
 // Debug logs a message to Logfire with severity Debug.
-func Debug(msg string) {
-	globalLogger.Debug(msg)
+func Debug(msg string, opts ...SpanOption) {
+	globalLogger.Debug(msg, opts...)
 }
 
 // Info logs a message to Logfire with severity Info.
-func Info(msg string) {
-	globalLogger.Info(msg)
+func Info(msg string, opts ...SpanOption) {
+	globalLogger.Info(msg, opts...)
 }
 
 // Warn logs a message to Logfire with severity Warn.
-func Warn(msg string) {
-	globalLogger.Warn(msg)
+func Warn(msg string, opts ...SpanOption) {
+	globalLogger.Warn(msg, opts...)
 }
 
 // Error logs a message to Logfire with severity Error.
-func Error(msg string) {
-	globalLogger.Error(msg)
+func Error(msg string, opts ...SpanOption) {
+	globalLogger.Error(msg, opts...)
 }
 
 // Fatal logs a message to Logfire with severity Fatal.
-func Fatal(msg string) {
-	globalLogger.Fatal(msg)
+func Fatal(msg string, opts ...SpanOption) {
+	globalLogger.Fatal(msg, opts...)
 }
 
 // SpanLogger creates a span for the current context.  The SpanLogger is also aware of
@@ -202,36 +947,62 @@ func Fatal(msg string) {
 type SpanLogger struct {
 	spanCtx context.Context
 	span    oteltrace.Span
+
+	// stopAutoClose stops the span from being ended when its parent context is done.
+	stopAutoClose func() bool
+	// leakTimer reports the span as leaked if it is not closed in time.
+	leakTimer *time.Timer
+	// debugBuffer keeps logs below the minimum level, to send if the span fails.
+	debugBuffer *logBuffer
+}
+
+// Log logs a message in the current span context to Logfire with the given severity.
+func (s *SpanLogger) Log(level Level, msg string, opts ...SpanOption) {
+	sendLog(s.spanCtx, msg, level, opts)
 }
 
 // Trace logs a message in the current span context to Logfire with severity Trace.
-func (s *SpanLogger) Trace(msg string) {
-	sendLog(s.spanCtx, msg, otellog.SeverityTrace)
+func (s *SpanLogger) Trace(msg string, opts ...SpanOption) {
+	sendLog(s.spanCtx, msg, otellog.SeverityTrace, opts)
 }
 
 // Debug logs a message in the current span context to Logfire with severity Debug.
-func (s *SpanLogger) Debug(msg string) {
-	sendLog(s.spanCtx, msg, otellog.SeverityDebug)
+func (s *SpanLogger) Debug(msg string, opts ...SpanOption) {
+	sendLog(s.spanCtx, msg, otellog.SeverityDebug, opts)
 }
 
 // Info logs a message in the current span context to Logfire with severity Info.
-func (s *SpanLogger) Info(msg string) {
-	sendLog(s.spanCtx, msg, otellog.SeverityInfo)
+func (s *SpanLogger) Info(msg string, opts ...SpanOption) {
+	sendLog(s.spanCtx, msg, otellog.SeverityInfo, opts)
 }
 
 // Warn logs a message in the current span context to Logfire with severity Warn.
-func (s *SpanLogger) Warn(msg string) {
-	sendLog(s.spanCtx, msg, otellog.SeverityWarn)
+func (s *SpanLogger) Warn(msg string, opts ...SpanOption) {
+	sendLog(s.spanCtx, msg, otellog.SeverityWarn, opts)
 }
 
 // Error logs a message in the current span context to Logfire with severity Error.
-func (s *SpanLogger) Error(msg string) {
-	sendLog(s.spanCtx, msg, otellog.SeverityError)
+func (s *SpanLogger) Error(msg string, opts ...SpanOption) {
+	sendLog(s.spanCtx, msg, otellog.SeverityError, opts)
 }
 
 // Fatal logs a message in the current span context to Logfire with severity Fatal.
-func (s *SpanLogger) Fatal(msg string) {
-	sendLog(s.spanCtx, msg, otellog.SeverityFatal)
+func (s *SpanLogger) Fatal(msg string, opts ...SpanOption) {
+	sendLog(s.spanCtx, msg, otellog.SeverityFatal, opts)
+}
+
+// SetAttributes sets attributes on the current span.
+func (s *SpanLogger) SetAttributes(attrs ...attribute.KeyValue) {
+	s.span.SetAttributes(attrs...)
+}
+
+// Event records a discrete milestone of the current span, such as a cache miss or a
+// retry being scheduled, as a span event with attributes.  Unlike logs, events don't
+// create spans of their own, and are shown on the timeline of the span.
+//
+//	logger.Event("email_sent", attribute.String("email.template", "welcome"))
+func (s *SpanLogger) Event(name string, attrs ...attribute.KeyValue) {
+	s.span.AddEvent(name, oteltrace.WithTimestamp(globalClock.Now()), oteltrace.WithAttributes(attrs...))
 }
 
 // Context returns the context of the current span.
@@ -241,17 +1012,177 @@ func (s *SpanLogger) Context() context.Context {
 
 // Close ends the current span.
 func (s *SpanLogger) Close() {
-	s.span.End()
+	s.CloseWithOptions()
+}
+
+// closeConfig is the config used to end a SpanLogger.
+type closeConfig struct {
+	// Err is the outcome of the work done in the span.
+	Err error
+	// EndTime overrides the time the span ended.
+	EndTime time.Time
+}
+
+// CloseOption is a function type that modifies how a SpanLogger is closed.
+type CloseOption func(*closeConfig)
+
+// WithError records err on the span and marks the span as errored.  A nil error
+// leaves the span status untouched, so this can be given a function's returned error:
+//
+//	defer func() { logger.CloseWithOptions(logfire.WithError(err)) }()
+func WithError(err error) CloseOption {
+	return func(c *closeConfig) {
+		c.Err = err
+	}
+}
+
+// WithEndTime sets the time the span ended, instead of now.
+func WithEndTime(t time.Time) CloseOption {
+	return func(c *closeConfig) {
+		c.EndTime = t
+	}
+}
+
+// CloseWithOptions ends the current span, applying the given options first.
+func (s *SpanLogger) CloseWithOptions(opts ...CloseOption) {
+	config := &closeConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if s.stopAutoClose != nil {
+		s.stopAutoClose()
+	}
+	if s.leakTimer != nil {
+		s.leakTimer.Stop()
+	}
+
+	if config.Err != nil {
+		s.span.RecordError(config.Err)
+		s.span.SetStatus(codes.Error, config.Err.Error())
+	}
+	if s.debugBuffer != nil {
+		records := s.debugBuffer.drain()
+		if config.Err != nil {
+			for _, r := range records {
+				r.send()
+			}
+		}
+	}
+
+	if config.EndTime.IsZero() {
+		config.EndTime = globalClock.Now()
+	}
+	s.span.End(oteltrace.WithTimestamp(config.EndTime))
+}
+
+// spanConfig is the config used to create a new SpanLogger, or to send a log.
+type spanConfig struct {
+	// AutoClose ends the span when the parent context is done.
+	AutoClose bool
+	// Timestamp overrides the time the span started, or the time of the log.
+	Timestamp time.Time
+	// ObservedTimestamp is the time the event was observed, if different from when
+	// it happened.
+	ObservedTimestamp time.Time
+	// Attributes are set on the span or log when it starts.
+	Attributes []attribute.KeyValue
+	// DebugBufferSize is how many logs below the minimum level the span keeps.
+	DebugBufferSize int
+}
+
+// SpanOption is a function type that modifies the config of a new SpanLogger or log.
+type SpanOption func(*spanConfig)
+
+func newSpanConfig(opts []SpanOption) *spanConfig {
+	config := &spanConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.Timestamp.IsZero() {
+		config.Timestamp = globalClock.Now()
+	}
+	return config
+}
+
+// startOptions returns the options to start the span with.
+func (c *spanConfig) startOptions() []oteltrace.SpanStartOption {
+	var opts []oteltrace.SpanStartOption
+	if !c.Timestamp.IsZero() {
+		opts = append(opts, oteltrace.WithTimestamp(c.Timestamp))
+	}
+	if !c.ObservedTimestamp.IsZero() {
+		opts = append(opts, oteltrace.WithAttributes(
+			attribute.String("logfire.observed_timestamp", c.ObservedTimestamp.Format(time.RFC3339Nano)),
+		))
+	}
+	if len(c.Attributes) > 0 {
+		opts = append(opts, oteltrace.WithAttributes(c.Attributes...))
+	}
+	return opts
+}
+
+// WithAttributes sets attributes on the span or log.
+func WithAttributes(attrs ...attribute.KeyValue) SpanOption {
+	return func(c *spanConfig) {
+		c.Attributes = append(c.Attributes, attrs...)
+	}
+}
+
+// WithTimestamp sets the time a span started, or the time a log happened, instead of
+// now.  Use this to preserve the original time of events that are replayed or
+// backfilled.  Use WithEndTime to set when a span ended.
+func WithTimestamp(t time.Time) SpanOption {
+	return func(c *spanConfig) {
+		c.Timestamp = t
+	}
+}
+
+// WithObservedTimestamp records the time an event was observed, e.g. when it was read
+// by a log shipper, alongside the time it happened.
+func WithObservedTimestamp(t time.Time) SpanOption {
+	return func(c *spanConfig) {
+		c.ObservedTimestamp = t
+	}
+}
+
+// WithAutoClose ends the span, marking it as cancelled, when the parent context is
+// cancelled or times out.  This prevents spans from being left open when a handler
+// returns early.  Close should still be called when the work is done.
+func WithAutoClose() SpanOption {
+	return func(c *spanConfig) {
+		c.AutoClose = true
+	}
 }
 
 // NewSpanLogger creates a new child SpanLogger from the given context.
 // Use this if you want to create or "nest" a new Span.
-func NewSpanLogger(ctx context.Context, spanName string) *SpanLogger {
-	spanCtx, span := globalTracer.Start(ctx, spanName)
-	return &SpanLogger{
+func NewSpanLogger(ctx context.Context, spanName string, opts ...SpanOption) *SpanLogger {
+	tracer, componentAttrs := tracerFor(ctx)
+	config := newSpanConfig(append([]SpanOption{WithAttributes(componentAttrs...)}, opts...))
+
+	spanCtx, span := tracer.Start(ctx, spanName, config.startOptions()...)
+	logger := &SpanLogger{
 		spanCtx: spanCtx,
 		span:    span,
 	}
+	if config.DebugBufferSize > 0 {
+		logger.debugBuffer = newLogBuffer(config.DebugBufferSize)
+		logger.spanCtx = context.WithValue(spanCtx, debugBufferKey{}, logger.debugBuffer)
+	}
+	if globalLeakTimeout > 0 {
+		logger.leakTimer = watchForLeak(spanName, globalLeakTimeout)
+	}
+	if config.AutoClose {
+		logger.stopAutoClose = context.AfterFunc(ctx, func() {
+			span.SetStatus(codes.Error, fmt.Sprintf("cancelled: %v", context.Cause(ctx)))
+			span.End(oteltrace.WithTimestamp(globalClock.Now()))
+			if logger.leakTimer != nil {
+				logger.leakTimer.Stop()
+			}
+		})
+	}
+	return logger
 }
 
 // FromContext creates a new SpanLogger from the given context.
@@ -0,0 +1,166 @@
+// Package logfireblob records object storage operations, such as S3 uploads and
+// downloads, in Logfire spans.
+//
+// The helpers don't depend on any storage client.  They wrap a function doing the
+// actual work, and count the bytes that flow through it, so spans record the bucket,
+// key, size and throughput of every transfer.
+package logfireblob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// config is the config used by the helpers.
+type config struct {
+	// System is the storage system, e.g. "s3".
+	System string
+	// HashKeys records a hash of object keys instead of the key.
+	HashKeys bool
+}
+
+// Option is a function type that modifies the helper config.
+type Option func(*config)
+
+// WithSystem sets the storage system recorded on spans, e.g. "s3" or "gcs".
+func WithSystem(system string) Option {
+	return func(c *config) {
+		c.System = system
+	}
+}
+
+// WithHashedKeys records a SHA-256 hash of object keys instead of the keys, for keys
+// that contain user data.  Equal keys still have equal hashes.
+func WithHashedKeys() Option {
+	return func(c *config) {
+		c.HashKeys = true
+	}
+}
+
+// Upload records an upload of r to the key in bucket.  fn does the upload, and must
+// read the object from the reader it is given.
+//
+//	err := logfireblob.Upload(ctx, "my-bucket", key, file, func(ctx context.Context, r io.Reader) error {
+//		_, err := client.PutObject(ctx, &s3.PutObjectInput{Bucket: &bucket, Key: &key, Body: r})
+//		return err
+//	}, logfireblob.WithSystem("s3"))
+func Upload(ctx context.Context, bucket, key string, r io.Reader, fn func(ctx context.Context, r io.Reader) error, opts ...Option) error {
+	t := start(ctx, "upload", bucket, key, opts)
+	counter := &countingReader{Reader: r}
+	err := fn(t.logger.Context(), counter)
+	t.end(counter.count(), err)
+	return err
+}
+
+// Download records a download of the key in bucket.  fn starts the download, and the
+// span ends when the returned reader is closed.
+//
+//	body, err := logfireblob.Download(ctx, "my-bucket", key, func(ctx context.Context) (io.ReadCloser, error) {
+//		out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &key})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return out.Body, nil
+//	})
+func Download(ctx context.Context, bucket, key string, fn func(ctx context.Context) (io.ReadCloser, error), opts ...Option) (io.ReadCloser, error) {
+	t := start(ctx, "download", bucket, key, opts)
+	body, err := fn(t.logger.Context())
+	if err != nil {
+		t.end(0, err)
+		return nil, err
+	}
+	return &downloadBody{countingReader: countingReader{Reader: body}, closer: body, transfer: t}, nil
+}
+
+// transfer is the span of a single upload or download.
+type transfer struct {
+	logger *logfire.SpanLogger
+	start  time.Time
+}
+
+func start(ctx context.Context, operation, bucket, key string, opts []Option) *transfer {
+	config := &config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("storage.operation", operation),
+		attribute.String("storage.bucket", bucket),
+	}
+	if config.System != "" {
+		attrs = append(attrs, attribute.String("storage.system", config.System))
+	}
+	if config.HashKeys {
+		sum := sha256.Sum256([]byte(key))
+		attrs = append(attrs, attribute.String("storage.key_sha256", hex.EncodeToString(sum[:])))
+	} else {
+		attrs = append(attrs, attribute.String("storage.key", key))
+	}
+
+	return &transfer{
+		logger: logfire.NewSpanLogger(ctx, operation+" "+bucket, logfire.WithAttributes(attrs...)),
+		start:  time.Now(),
+	}
+}
+
+func (t *transfer) end(size int64, err error) {
+	attrs := []attribute.KeyValue{attribute.Int64("storage.size_bytes", size)}
+	if elapsed := time.Since(t.start).Seconds(); elapsed > 0 && size > 0 {
+		attrs = append(attrs, attribute.Float64("storage.throughput_bytes_per_second", float64(size)/elapsed))
+	}
+	t.logger.SetAttributes(attrs...)
+	t.logger.CloseWithOptions(logfire.WithError(err))
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+
+	mu  sync.Mutex
+	n   int64
+	err error
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.mu.Lock()
+	r.n += int64(n)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	r.mu.Unlock()
+	return n, err
+}
+
+func (r *countingReader) count() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.n
+}
+
+// downloadBody ends the download span when it is closed.
+type downloadBody struct {
+	countingReader
+	closer   io.Closer
+	transfer *transfer
+	once     sync.Once
+}
+
+func (b *downloadBody) Close() error {
+	err := b.closer.Close()
+	b.once.Do(func() {
+		b.mu.Lock()
+		readErr := b.err
+		b.mu.Unlock()
+		b.transfer.end(b.count(), readErr)
+	})
+	return err
+}
@@ -0,0 +1,93 @@
+// Package logfirebreaker logs circuit breaker state changes to Logfire.
+//
+// Any circuit breaker can be observed by implementing the Breaker interface, and an
+// adapter is provided for sony/gobreaker.
+package logfirebreaker
+
+import (
+	"context"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Breaker is a circuit breaker whose state can be observed.
+type Breaker interface {
+	// Name is the name of the breaker.
+	Name() string
+	// State is the current state of the breaker, e.g. "closed", "half-open" or "open".
+	State() string
+	// Counts are the requests of the current generation of the breaker.
+	Counts() Counts
+}
+
+// Counts are the requests a circuit breaker has seen since it last changed state.
+type Counts struct {
+	Requests            uint32
+	Failures            uint32
+	ConsecutiveFailures uint32
+}
+
+// FailureRate returns the ratio of requests that failed.
+func (c Counts) FailureRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Failures) / float64(c.Requests)
+}
+
+// Transition is a change in the state of a circuit breaker.
+type Transition struct {
+	// Name is the name of the breaker.
+	Name string
+	// From is the state before the change.
+	From string
+	// To is the state after the change.
+	To string
+	// Counts are the requests that led to the change.
+	Counts Counts
+}
+
+// RecordTransition logs the transition as a Warn in the span of ctx, with the failure
+// rate that led to it.
+func RecordTransition(ctx context.Context, t Transition) {
+	logfire.FromContext(ctx).Warn("circuit breaker "+t.Name+" is "+t.To, logfire.WithAttributes(
+		attribute.String("breaker.name", t.Name),
+		attribute.String("breaker.from", t.From),
+		attribute.String("breaker.to", t.To),
+		attribute.Int("breaker.requests", int(t.Counts.Requests)),
+		attribute.Int("breaker.failures", int(t.Counts.Failures)),
+		attribute.Int("breaker.consecutive_failures", int(t.Counts.ConsecutiveFailures)),
+		attribute.Float64("breaker.failure_rate", t.Counts.FailureRate()),
+	))
+}
+
+// Observe calls fn, which should make a request through b, and logs any state change of
+// b as a Warn in the span of ctx, see RecordTransition.
+func Observe(ctx context.Context, b Breaker, fn func() error) error {
+	from := b.State()
+	counts := b.Counts()
+
+	err := fn()
+
+	to := b.State()
+	if to == from {
+		return err
+	}
+	// Breakers reset their counts when they change state, so the counts that led to the
+	// change are the counts before the request, and the request itself.
+	counts.Requests++
+	if err != nil {
+		counts.Failures++
+		counts.ConsecutiveFailures++
+	} else {
+		counts.ConsecutiveFailures = 0
+	}
+	RecordTransition(ctx, Transition{
+		Name:   b.Name(),
+		From:   from,
+		To:     to,
+		Counts: counts,
+	})
+	return err
+}
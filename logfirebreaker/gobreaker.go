@@ -0,0 +1,51 @@
+package logfirebreaker
+
+import (
+	"context"
+
+	"github.com/sony/gobreaker"
+)
+
+// goBreaker adapts a gobreaker.CircuitBreaker to Breaker.
+type goBreaker struct {
+	cb *gobreaker.CircuitBreaker
+}
+
+// GoBreaker adapts cb to a Breaker.
+func GoBreaker(cb *gobreaker.CircuitBreaker) Breaker {
+	return goBreaker{cb: cb}
+}
+
+// Name returns the name of the breaker.
+func (b goBreaker) Name() string { return b.cb.Name() }
+
+// State returns the state of the breaker.
+func (b goBreaker) State() string { return b.cb.State().String() }
+
+// Counts returns the requests of the current generation of the breaker.
+func (b goBreaker) Counts() Counts {
+	counts := b.cb.Counts()
+	return Counts{
+		Requests:            counts.Requests,
+		Failures:            counts.TotalFailures,
+		ConsecutiveFailures: counts.ConsecutiveFailures,
+	}
+}
+
+// Execute runs fn through cb, like cb.Execute, and logs any state change it causes as a
+// Warn in the span of ctx.
+//
+//	_, err := logfirebreaker.Execute(ctx, cb, func(ctx context.Context) (any, error) {
+//		return client.Get(ctx, key)
+//	})
+func Execute(ctx context.Context, cb *gobreaker.CircuitBreaker, fn func(ctx context.Context) (any, error)) (any, error) {
+	var result any
+	err := Observe(ctx, GoBreaker(cb), func() error {
+		var err error
+		result, err = cb.Execute(func() (any, error) {
+			return fn(ctx)
+		})
+		return err
+	})
+	return result, err
+}
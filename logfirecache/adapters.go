@@ -0,0 +1,84 @@
+package logfirecache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/allegro/bigcache/v3"
+)
+
+// RistrettoCache is the subset of a ristretto cache used by Ristretto.  Both
+// *ristretto.Cache[K, V] and the non-generic *ristretto.Cache, with K and V of
+// interface{}, implement it.
+type RistrettoCache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Set(key K, value V, cost int64) bool
+	Del(key K)
+}
+
+// ristretto adapts a ristretto cache to Cache.
+type ristretto[K comparable, V any] struct {
+	cache RistrettoCache[K, V]
+	cost  int64
+}
+
+// Ristretto adapts a ristretto cache to a Cache, to be instrumented with Wrap.  Every
+// value is set with the given cost.
+//
+//	cache := logfirecache.Wrap(logfirecache.Ristretto[string, []byte](rc, 1))
+func Ristretto[K comparable, V any](c RistrettoCache[K, V], cost int64) Cache[K, V] {
+	return &ristretto[K, V]{cache: c, cost: cost}
+}
+
+func (r *ristretto[K, V]) Get(_ context.Context, key K) (V, bool, error) {
+	value, ok := r.cache.Get(key)
+	return value, ok, nil
+}
+
+// Set sets the value of key.  Ristretto may drop sets, which is not an error.
+func (r *ristretto[K, V]) Set(_ context.Context, key K, value V) error {
+	r.cache.Set(key, value, r.cost)
+	return nil
+}
+
+func (r *ristretto[K, V]) Delete(_ context.Context, key K) error {
+	r.cache.Del(key)
+	return nil
+}
+
+// bigCache adapts a *bigcache.BigCache to Cache.
+type bigCache struct {
+	cache *bigcache.BigCache
+}
+
+// BigCache adapts a bigcache to a Cache, to be instrumented with Wrap.
+//
+//	cache := logfirecache.Wrap(logfirecache.BigCache(bc))
+func BigCache(c *bigcache.BigCache) Cache[string, []byte] {
+	return &bigCache{cache: c}
+}
+
+func (b *bigCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	value, err := b.cache.Get(key)
+	if errors.Is(err, bigcache.ErrEntryNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (b *bigCache) Set(_ context.Context, key string, value []byte) error {
+	return b.cache.Set(key, value)
+}
+
+// Delete removes key from the cache.  Deleting a key that doesn't exist is not an
+// error.
+func (b *bigCache) Delete(_ context.Context, key string) error {
+	err := b.cache.Delete(key)
+	if errors.Is(err, bigcache.ErrEntryNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,151 @@
+// Package logfirecache instruments caches with Logfire spans and hit rate metrics.
+//
+// Any cache can be instrumented by implementing the Cache interface, and adapters are
+// provided for ristretto and bigcache.
+package logfirecache
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Cache is a key value cache.
+type Cache[K comparable, V any] interface {
+	// Get returns the value of key, and whether it was found.
+	Get(ctx context.Context, key K) (V, bool, error)
+	// Set sets the value of key.
+	Set(ctx context.Context, key K, value V) error
+	// Delete removes key from the cache.
+	Delete(ctx context.Context, key K) error
+}
+
+// config is the config used by Wrap.
+type config struct {
+	// Name is the name of the cache, used to tell caches apart.
+	Name string
+}
+
+// Option is a function type that modifies the Wrap config.
+type Option func(*config)
+
+// WithName sets the name of the cache, recorded as the cache.name attribute on spans
+// and metrics.  Defaults to "cache".
+func WithName(name string) Option {
+	return func(c *config) {
+		c.Name = name
+	}
+}
+
+// instrumented is a Cache that records every operation in a span, and counts hits and
+// misses.
+type instrumented[K comparable, V any] struct {
+	cache Cache[K, V]
+	name  attribute.KeyValue
+
+	requests     metric.Int64Counter
+	hits, misses atomic.Int64
+}
+
+// Wrap returns a Cache that records every operation on c in a span, with a cache.hit
+// attribute for gets.  Hits and misses are counted in the cache.requests metric, and
+// the hit ratio since the cache was wrapped is reported in the cache.hit_ratio metric
+// every time metrics are exported.
+func Wrap[K comparable, V any](c Cache[K, V], opts ...Option) Cache[K, V] {
+	config := &config{Name: "cache"}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	w := &instrumented[K, V]{
+		cache: c,
+		name:  attribute.String("cache.name", config.Name),
+	}
+
+	meter := logfire.Meter()
+	var err error
+	w.requests, err = meter.Int64Counter("cache.requests",
+		metric.WithDescription("Number of cache gets, by result."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create cache.requests metric: " + err.Error())
+	}
+	_, err = meter.Float64ObservableGauge("cache.hit_ratio",
+		metric.WithDescription("Ratio of cache gets that were hits."),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			hits, misses := w.hits.Load(), w.misses.Load()
+			if hits+misses > 0 {
+				o.Observe(float64(hits)/float64(hits+misses), metric.WithAttributes(w.name))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		logfire.Warn("failed to create cache.hit_ratio metric: " + err.Error())
+	}
+	return w
+}
+
+func (w *instrumented[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	ctx, span := w.start(ctx, "get")
+	defer span.End()
+
+	value, ok, err := w.cache.Get(ctx, key)
+	if err != nil {
+		recordError(span, err)
+		return value, ok, err
+	}
+
+	result := "miss"
+	if ok {
+		result = "hit"
+		w.hits.Add(1)
+	} else {
+		w.misses.Add(1)
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	if w.requests != nil {
+		w.requests.Add(ctx, 1, metric.WithAttributes(w.name, attribute.String("cache.result", result)))
+	}
+	return value, ok, nil
+}
+
+func (w *instrumented[K, V]) Set(ctx context.Context, key K, value V) error {
+	ctx, span := w.start(ctx, "set")
+	defer span.End()
+
+	err := w.cache.Set(ctx, key, value)
+	if err != nil {
+		recordError(span, err)
+	}
+	return err
+}
+
+func (w *instrumented[K, V]) Delete(ctx context.Context, key K) error {
+	ctx, span := w.start(ctx, "delete")
+	defer span.End()
+
+	err := w.cache.Delete(ctx, key)
+	if err != nil {
+		recordError(span, err)
+	}
+	return err
+}
+
+func (w *instrumented[K, V]) start(ctx context.Context, operation string) (context.Context, oteltrace.Span) {
+	return logfire.Tracer().Start(ctx, "cache "+operation, oteltrace.WithAttributes(
+		w.name,
+		attribute.String("cache.operation", operation),
+	))
+}
+
+func recordError(span oteltrace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(otelcodes.Error, err.Error())
+}
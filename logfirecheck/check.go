@@ -0,0 +1,165 @@
+// Package logfirecheck runs synthetic checks, such as HTTP or TCP probes, on an
+// interval and reports their results to Logfire, so small services get uptime checks
+// without another tool.
+//
+// Every run of a check is recorded in a span, with the failure reason if it failed,
+// and counted in the check.runs and check.duration metrics.
+package logfirecheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultTimeout  = 10 * time.Second
+)
+
+// Probe checks that something is healthy, returning an error describing why it isn't.
+type Probe func(ctx context.Context) error
+
+// Check is a probe that is run on an interval.
+type Check struct {
+	// Name is the name of the check, recorded as the check.name attribute.
+	Name string
+	// Probe is run on every check.
+	Probe Probe
+	// Interval is how often the check runs.  Defaults to a minute.
+	Interval time.Duration
+	// Timeout is how long a single run can take before it fails.  Defaults to 10
+	// seconds.
+	Timeout time.Duration
+}
+
+// HTTP returns a Probe that requests url with GET, and fails unless the response
+// status is below 400.
+func HTTP(url string) Probe {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// TCP returns a Probe that fails unless a TCP connection can be opened to address.
+func TCP(address string) Probe {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// runner runs checks and records their results.
+type runner struct {
+	runs     metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// Start runs every check on its interval, until the returned function is called.
+// Checks run once immediately.
+//
+//	stop := logfirecheck.Start(
+//		logfirecheck.Check{Name: "api", Probe: logfirecheck.HTTP("https://api.example.com/healthz")},
+//		logfirecheck.Check{Name: "db", Probe: logfirecheck.TCP("db:5432"), Interval: 30 * time.Second},
+//	)
+//	defer stop()
+func Start(checks ...Check) func() {
+	meter := logfire.Meter()
+	r := &runner{}
+	var err error
+	r.runs, err = meter.Int64Counter("check.runs",
+		metric.WithDescription("Number of synthetic check runs, by result."),
+		metric.WithUnit("{run}"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create check.runs metric: " + err.Error())
+	}
+	r.duration, err = meter.Float64Histogram("check.duration",
+		metric.WithDescription("Duration of synthetic check runs."),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create check.duration metric: " + err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, check := range checks {
+		if check.Interval <= 0 {
+			check.Interval = defaultInterval
+		}
+		if check.Timeout <= 0 {
+			check.Timeout = defaultTimeout
+		}
+		go func() {
+			ticker := time.NewTicker(check.Interval)
+			defer ticker.Stop()
+			for {
+				r.run(ctx, check)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+	return cancel
+}
+
+// run runs a check once, recording the result.
+func (r *runner) run(ctx context.Context, check Check) {
+	name := attribute.String("check.name", check.Name)
+	ctx, span := logfire.Tracer().Start(ctx, "check "+check.Name, oteltrace.WithAttributes(name))
+	defer span.End()
+
+	probeCtx, cancel := context.WithTimeout(ctx, check.Timeout)
+	start := time.Now()
+	err := check.Probe(probeCtx)
+	duration := time.Since(start)
+	cancel()
+	if ctx.Err() != nil {
+		// The checks were stopped during the run.
+		return
+	}
+
+	result := "success"
+	span.SetAttributes(attribute.Bool("check.success", err == nil))
+	if err != nil {
+		result = "failure"
+		span.SetAttributes(attribute.String("check.failure_reason", err.Error()))
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+
+	attrs := metric.WithAttributes(name, attribute.String("check.result", result))
+	if r.runs != nil {
+		r.runs.Add(ctx, 1, attrs)
+	}
+	if r.duration != nil {
+		r.duration.Record(ctx, float64(duration)/float64(time.Millisecond), attrs)
+	}
+}
@@ -0,0 +1,184 @@
+// Package logfireelastic instruments the official Elasticsearch and OpenSearch Go
+// clients with Logfire spans, through their HTTP transport.
+package logfireelastic
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// tookPrefixSize is how much of a response is read to find the "took" field, which
+// Elasticsearch writes at the start of the response.
+const tookPrefixSize = 128
+
+var tookPattern = regexp.MustCompile(`"took"\s*:\s*(\d+)`)
+
+// Well known values of db.system.
+const (
+	SystemElasticsearch = "elasticsearch"
+	SystemOpenSearch    = "opensearch"
+)
+
+// config is the config used by Transport.
+type config struct {
+	// System is the value of db.system.
+	System string
+}
+
+// Option is a function type that modifies the Transport config.
+type Option func(*config)
+
+// WithSystem sets the database system recorded on spans.  Defaults to
+// SystemElasticsearch.
+func WithSystem(system string) Option {
+	return func(c *config) {
+		c.System = system
+	}
+}
+
+// Transport is an http.RoundTripper that records every request to Elasticsearch or
+// OpenSearch in a span, with the index, operation, status and server side duration.
+type Transport struct {
+	base   http.RoundTripper
+	config *config
+}
+
+// NewTransport wraps base, or http.DefaultTransport if base is nil, in a Transport.
+// Use it as the transport of the client:
+//
+//	es, err := elasticsearch.NewClient(elasticsearch.Config{
+//		Transport: logfireelastic.NewTransport(nil),
+//	})
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	config := &config{System: SystemElasticsearch}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &Transport{base: base, config: config}
+}
+
+// RoundTrip sends the request in a new span.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	index, operation := parsePath(req.Method, req.URL.Path)
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", t.config.System),
+		attribute.String("db.operation", operation),
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.path", req.URL.Path),
+	}
+	name := t.config.System + " " + operation
+	if index != "" {
+		attrs = append(attrs, attribute.String("db.collection.name", index))
+		name += " " + index
+	}
+
+	ctx, span := logfire.Tracer().Start(req.Context(), name,
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+		oteltrace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 && !(req.Method == http.MethodHead && resp.StatusCode == http.StatusNotFound) {
+		span.SetStatus(otelcodes.Error, resp.Status)
+	}
+	if took, ok := peekTook(resp); ok {
+		span.SetAttributes(attribute.Int64("db.elasticsearch.took_ms", took))
+	}
+	return resp, nil
+}
+
+// peekTook reads the "took" field from the start of the response body, leaving the
+// body intact for the caller.
+func peekTook(resp *http.Response) (int64, bool) {
+	if resp.Body == nil || !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return 0, false
+	}
+	prefix := make([]byte, tookPrefixSize)
+	n, err := io.ReadFull(resp.Body, prefix)
+	prefix = prefix[:n]
+	resp.Body = &prefixedBody{Reader: io.MultiReader(bytes.NewReader(prefix), resp.Body), Closer: resp.Body}
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return 0, false
+	}
+
+	match := tookPattern.FindSubmatch(prefix)
+	if match == nil {
+		return 0, false
+	}
+	took, err := strconv.ParseInt(string(match[1]), 10, 64)
+	return took, err == nil
+}
+
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// parsePath returns the index and operation of a request from its path, e.g.
+// "/orders/_search" is the search operation on the orders index.
+func parsePath(method, path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 1 && parts[0] == "" {
+		return "", "info"
+	}
+
+	var index string
+	if !strings.HasPrefix(parts[0], "_") {
+		index = parts[0]
+		parts = parts[1:]
+	}
+	for _, part := range parts {
+		if strings.HasPrefix(part, "_") {
+			operation := strings.TrimPrefix(part, "_")
+			if operation == "doc" || operation == "create" {
+				return index, documentOperation(method)
+			}
+			return index, operation
+		}
+	}
+
+	// Requests on the index itself.
+	switch method {
+	case http.MethodPut:
+		return index, "indices.create"
+	case http.MethodDelete:
+		return index, "indices.delete"
+	case http.MethodHead:
+		return index, "indices.exists"
+	default:
+		return index, "indices.get"
+	}
+}
+
+func documentOperation(method string) string {
+	switch method {
+	case http.MethodGet:
+		return "get"
+	case http.MethodHead:
+		return "exists"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "index"
+	}
+}
@@ -0,0 +1,170 @@
+// Package logfireexec runs subprocesses inside a Logfire span.
+//
+// The span records the command, its scrubbed arguments, the exit code and how long it
+// took, and can optionally send the output of the command as logs.  The trace context is
+// passed to the command in environment variables, see logfire.TraceEnv, so commands
+// instrumented with Logfire continue the trace.
+package logfireexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveFlag matches flags whose value should never be recorded.
+var sensitiveFlag = regexp.MustCompile(`(?i)^-{1,2}[\w-]*(password|passwd|secret|token|api[_-]?key|auth|credential|private[_-]?key)[\w-]*$`)
+
+// Cmd is an exec.Cmd that runs inside a span.
+type Cmd struct {
+	*exec.Cmd
+
+	// StreamOutput sends each line of stdout as an Info log, and each line of stderr
+	// as a Warn log, in the span of the command.  Stdout and Stderr that are already
+	// set are left alone.
+	StreamOutput bool
+
+	ctx     context.Context
+	logger  *logfire.SpanLogger
+	writers []*logfire.LineWriter
+}
+
+// Command returns a Cmd to run the named program with the given arguments, like
+// exec.CommandContext.
+func Command(ctx context.Context, name string, args ...string) *Cmd {
+	return &Cmd{
+		Cmd: exec.CommandContext(ctx, name, args...),
+		ctx: ctx,
+	}
+}
+
+// Start starts the command in a new span.  Wait must be called to end the span.
+func (c *Cmd) Start() error {
+	c.logger = logfire.NewSpanLogger(c.ctx, "exec "+filepath.Base(c.Path), logfire.WithAttributes(
+		attribute.String("process.executable.name", filepath.Base(c.Path)),
+		attribute.String("process.executable.path", c.Path),
+		attribute.StringSlice("process.command_args", ScrubArgs(c.Args)),
+	))
+
+	// Pass the trace context to the command, so a command instrumented with Logfire
+	// continues the trace.
+	env := c.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	c.Env = append(env, logfire.TraceEnv(c.logger.Context())...)
+
+	if c.StreamOutput {
+		if c.Stdout == nil {
+			w := c.logger.Writer(logfire.LevelInfo)
+			c.writers = append(c.writers, w)
+			c.Stdout = w
+		}
+		if c.Stderr == nil {
+			w := c.logger.Writer(logfire.LevelWarn)
+			c.writers = append(c.writers, w)
+			c.Stderr = w
+		}
+	}
+
+	if err := c.Cmd.Start(); err != nil {
+		c.end(err)
+		return err
+	}
+	c.logger.SetAttributes(attribute.Int("process.pid", c.Process.Pid))
+	return nil
+}
+
+// Wait waits for the command to exit, and ends its span.
+func (c *Cmd) Wait() error {
+	err := c.Cmd.Wait()
+	c.end(err)
+	return err
+}
+
+// Run starts the command and waits for it to exit.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output.
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("logfireexec: Stdout already set")
+	}
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	err := c.Run()
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its combined standard output and
+// standard error.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("logfireexec: Stdout already set")
+	}
+	if c.Stderr != nil {
+		return nil, errors.New("logfireexec: Stderr already set")
+	}
+	var output bytes.Buffer
+	c.Stdout = &output
+	c.Stderr = &output
+	err := c.Run()
+	return output.Bytes(), err
+}
+
+func (c *Cmd) end(err error) {
+	for _, w := range c.writers {
+		w.Close()
+	}
+	if c.ProcessState != nil {
+		c.logger.SetAttributes(attribute.Int("process.exit.code", c.ProcessState.ExitCode()))
+	}
+	c.logger.CloseWithOptions(logfire.WithError(err))
+}
+
+// ScrubArgs returns a copy of args with the values of sensitive flags, such as
+// --password, and passwords in URLs replaced.
+func ScrubArgs(args []string) []string {
+	scrubbed := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if flag, _, ok := strings.Cut(arg, "="); ok && sensitiveFlag.MatchString(flag) {
+			scrubbed[i] = flag + "=" + redacted
+			continue
+		}
+		scrubbed[i] = scrubURL(arg)
+		if sensitiveFlag.MatchString(arg) && i+1 < len(args) {
+			i++
+			scrubbed[i] = redacted
+		}
+	}
+	return scrubbed
+}
+
+// scrubURL replaces the password of a URL with credentials.
+func scrubURL(arg string) string {
+	if !strings.Contains(arg, "://") {
+		return arg
+	}
+	u, err := url.Parse(arg)
+	if err != nil || u.User == nil {
+		return arg
+	}
+	return u.Redacted()
+}
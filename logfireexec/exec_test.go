@@ -0,0 +1,109 @@
+package logfireexec
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jerechua/logfire-go/logfiretest"
+)
+
+func TestScrubArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args, want []string
+	}{
+		{
+			args: []string{"pg_dump", "--password", "hunter2", "--dbname", "shop"},
+			want: []string{"pg_dump", "--password", redacted, "--dbname", "shop"},
+		},
+		{
+			args: []string{"curl", "--api-key=abc", "-H", "Accept: */*"},
+			want: []string{"curl", "--api-key=" + redacted, "-H", "Accept: */*"},
+		},
+		{
+			args: []string{"psql", "postgres://admin:hunter2@db:5432/shop"},
+			want: []string{"psql", "postgres://admin:xxxxx@db:5432/shop"},
+		},
+		{
+			// A sensitive flag at the end has no value to scrub.
+			args: []string{"login", "--token"},
+			want: []string{"login", "--token"},
+		},
+	} {
+		if got := ScrubArgs(tc.args); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ScrubArgs(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestCommandSpan(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	out, err := Command(context.Background(), "sh", "-c", "echo $LOGFIRE_TRACEPARENT", "--secret", "hunter2").Output()
+	if err != nil {
+		t.Fatalf("Output failed: %v", err)
+	}
+	if !strings.HasPrefix(string(out), "00-") {
+		t.Errorf("the command saw LOGFIRE_TRACEPARENT=%q, want the trace context", out)
+	}
+
+	spans := rec.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want the command span", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "exec sh" || span.Status != "" {
+		t.Errorf("span %q has status %q, want exec sh without an error", span.Name, span.Status)
+	}
+	if span.Attributes["process.exit.code"] != int64(0) {
+		t.Errorf("exit code = %v, want 0", span.Attributes["process.exit.code"])
+	}
+	args, _ := span.Attributes["process.command_args"].([]string)
+	if strings.Contains(strings.Join(args, " "), "hunter2") {
+		t.Errorf("recorded the secret in the arguments %q", args)
+	}
+}
+
+func TestCommandFailure(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	if err := Command(context.Background(), "sh", "-c", "exit 3").Run(); err == nil {
+		t.Fatalf("Run succeeded, want the exit status as an error")
+	}
+	span := rec.Spans()[0]
+	if span.Attributes["process.exit.code"] != int64(3) || span.Status == "" {
+		t.Errorf("span has exit code %v and status %q, want 3 and an error", span.Attributes["process.exit.code"], span.Status)
+	}
+
+	rec.Reset()
+	if err := Command(context.Background(), "logfireexec-missing-command").Run(); err == nil {
+		t.Fatalf("Run of a missing command succeeded")
+	}
+	if spans := rec.Spans(); len(spans) != 1 || spans[0].Status == "" {
+		t.Errorf("recorded %+v, want one span with an error for a command that didn't start", spans)
+	}
+}
+
+func TestStreamOutput(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	cmd := Command(context.Background(), "sh", "-c", "echo built; echo careful >&2")
+	cmd.StreamOutput = true
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	logs := map[string]int{}
+	var command int
+	for _, span := range rec.Spans() {
+		if span.Name == "exec sh" {
+			command = span.ID
+			continue
+		}
+		if span.Parent != command {
+			t.Errorf("log %q is not in the span of the command", span.Name)
+		}
+		logs[span.Attributes["logfire.msg"].(string)]++
+	}
+	if logs["built"] != 1 || logs["careful"] != 1 {
+		t.Errorf("logged %v, want a log for each line of output", logs)
+	}
+}
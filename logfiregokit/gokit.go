@@ -0,0 +1,120 @@
+// Package logfiregokit provides a go-kit log.Logger that sends logs to Logfire, so go-kit
+// services can switch to Logfire with one line:
+//
+//	logger := logfiregokit.NewLogger()
+//
+// Key value pairs are recorded as attributes, the "msg" key is the message of the log,
+// and the "level" key, e.g. as set by the go-kit level package, is its level.
+package logfiregokit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	levelKey   = "level"
+	messageKey = "msg"
+)
+
+// levels maps go-kit level names to Logfire levels.
+var levels = map[string]logfire.Level{
+	"debug": logfire.LevelDebug,
+	"info":  logfire.LevelInfo,
+	"warn":  logfire.LevelWarn,
+	"error": logfire.LevelError,
+}
+
+// config is the config used by NewLogger.
+type config struct {
+	// Context is the context logs are sent in.
+	Context context.Context
+	// DefaultLevel is the level of logs without a level key.
+	DefaultLevel logfire.Level
+}
+
+// Option is a function type that modifies the NewLogger config.
+type Option func(*config)
+
+// WithContext sends logs in the span of ctx, so they are part of its trace.
+func WithContext(ctx context.Context) Option {
+	return func(c *config) {
+		c.Context = ctx
+	}
+}
+
+// WithDefaultLevel sets the level of logs without a level key.  Defaults to
+// logfire.LevelInfo.
+func WithDefaultLevel(level logfire.Level) Option {
+	return func(c *config) {
+		c.DefaultLevel = level
+	}
+}
+
+// logger is a log.Logger that sends logs to Logfire.
+type logger struct {
+	config *config
+}
+
+var _ log.Logger = (*logger)(nil)
+
+// NewLogger returns a log.Logger that sends logs to Logfire.
+func NewLogger(opts ...Option) log.Logger {
+	config := &config{
+		Context:      context.Background(),
+		DefaultLevel: logfire.LevelInfo,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &logger{config: config}
+}
+
+// Log sends keyvals as a log.  A key without a value is recorded with the value
+// "<missing>".
+func (l *logger) Log(keyvals ...any) error {
+	level := l.config.DefaultLevel
+	var msg string
+	var attrs []attribute.KeyValue
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if i+1 >= len(keyvals) {
+			attrs = append(attrs, attribute.String(key, "<missing>"))
+			break
+		}
+		value := keyvals[i+1]
+		switch key {
+		case levelKey:
+			if parsed, ok := levels[strings.ToLower(fmt.Sprint(value))]; ok {
+				level = parsed
+				continue
+			}
+		case messageKey:
+			msg = fmt.Sprint(value)
+			continue
+		}
+		switch v := value.(type) {
+		case error:
+			attrs = append(attrs, attribute.String(key, v.Error()))
+		case fmt.Stringer:
+			attrs = append(attrs, logfire.StringerAttr(key, v))
+		default:
+			attrs = append(attrs, logfire.Flatten(key, v)...)
+		}
+	}
+	if msg == "" {
+		// Logs without a message are named after their keys.
+		keys := make([]string, len(attrs))
+		for i, kv := range attrs {
+			keys[i] = string(kv.Key)
+		}
+		msg = strings.Join(keys, " ")
+	}
+	logfire.FromContext(l.config.Context).Log(level, msg, logfire.WithAttributes(attrs...))
+	return nil
+}
@@ -0,0 +1,154 @@
+// Package logfiregroup runs a group of goroutines working on subtasks of a common task,
+// like golang.org/x/sync/errgroup, recording the task in a Logfire span with a child
+// span for every subtask:
+//
+//	g, ctx := logfiregroup.WithContext(ctx, logfiregroup.WithName("fetch prices"))
+//	for _, supplier := range suppliers {
+//		g.Go("fetch "+supplier, func(ctx context.Context) error {
+//			return fetchPrices(ctx, supplier)
+//		})
+//	}
+//	err := g.Wait()
+//
+// Unlike errgroup, subtasks are named, and take the context of their span, so the work
+// they do is nested under it.
+package logfiregroup
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// config is the config used by WithContext.
+type config struct {
+	// Name is the name of the span of the group.
+	Name string
+}
+
+// Option is a function type that modifies the WithContext config.
+type Option func(*config)
+
+// WithName sets the name of the span of the group.  Defaults to "task group".
+func WithName(name string) Option {
+	return func(c *config) {
+		c.Name = name
+	}
+}
+
+// Group is a collection of goroutines working on subtasks of a common task.
+type Group struct {
+	logger *logfire.SpanLogger
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	mu        sync.Mutex
+	tasks     int
+	failed    int
+	err       error
+	firstFail string
+	panicked  any
+	closed    bool
+}
+
+// WithContext returns a new Group, in a span started from ctx, and a derived context
+// that is canceled the first time a subtask returns an error or panics, or when Wait
+// returns.
+func WithContext(ctx context.Context, opts ...Option) (*Group, context.Context) {
+	config := &config{Name: "task group"}
+	for _, opt := range opts {
+		opt(config)
+	}
+	logger := logfire.NewSpanLogger(ctx, config.Name)
+	ctx, cancel := context.WithCancelCause(logger.Context())
+	return &Group{logger: logger, ctx: ctx, cancel: cancel}, ctx
+}
+
+// Go runs fn in a new goroutine, in a child span of the group named name.  The first
+// subtask to return an error, or to panic, cancels the context of the group, and its
+// error is returned by Wait.  A panic is recorded on the span of the subtask, and
+// panics again in Wait.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	g.mu.Lock()
+	g.tasks++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		g.run(name, fn)
+	}()
+}
+
+// run runs fn in the span of a subtask.
+func (g *Group) run(name string, fn func(ctx context.Context) error) {
+	logger := logfire.NewSpanLogger(g.ctx, name, logfire.WithAttributes(
+		attribute.String("logfire.group.task", name),
+	))
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			logger.SetAttributes(
+				attribute.String("exception.type", fmt.Sprintf("%T", r)),
+				attribute.String("exception.message", fmt.Sprint(r)),
+				attribute.String("exception.stacktrace", string(debug.Stack())),
+			)
+			g.fail(name, err, r)
+		}
+		logger.CloseWithOptions(logfire.WithError(err))
+	}()
+
+	if err = fn(logger.Context()); err != nil {
+		g.fail(name, err, nil)
+	}
+}
+
+// fail records that a subtask failed, canceling the group if it is the first.
+func (g *Group) fail(name string, err error, panicked any) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failed++
+	if panicked != nil && g.panicked == nil {
+		g.panicked = panicked
+	}
+	if g.err == nil {
+		g.err = err
+		g.firstFail = name
+		g.cancel(err)
+	}
+}
+
+// Wait blocks until every subtask has returned, ends the span of the group, and returns
+// the first error, if any.  The span records the number of subtasks, how many failed,
+// and the name of the first to fail.  If a subtask panicked, Wait panics with the same
+// value.  Later calls only return the first error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return g.err
+	}
+	g.closed = true
+	attrs := []attribute.KeyValue{
+		attribute.Int("logfire.group.tasks", g.tasks),
+		attribute.Int("logfire.group.failed", g.failed),
+	}
+	if g.err != nil {
+		attrs = append(attrs, attribute.String("logfire.group.first_failure", g.firstFail))
+	}
+	g.logger.SetAttributes(attrs...)
+	g.logger.CloseWithOptions(logfire.WithError(g.err))
+	if g.panicked != nil {
+		panic(g.panicked)
+	}
+	return g.err
+}
@@ -0,0 +1,98 @@
+package logfiregroup
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jerechua/logfire-go/logfiretest"
+)
+
+func findSpan(t *testing.T, spans []logfiretest.Span, name string) logfiretest.Span {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no span named %q in %+v", name, spans)
+	return logfiretest.Span{}
+}
+
+func TestGroup(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+
+	g, ctx := WithContext(context.Background(), WithName("fetch prices"))
+	g.Go("fetch a", func(ctx context.Context) error { return nil })
+	g.Go("fetch b", func(ctx context.Context) error { return nil })
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait() = %v, want nil", err)
+	}
+	if ctx.Err() == nil {
+		t.Errorf("the context of the group wasn't canceled by Wait")
+	}
+
+	spans := rec.Spans()
+	group := findSpan(t, spans, "fetch prices")
+	if group.Attributes["logfire.group.tasks"] != int64(2) || group.Attributes["logfire.group.failed"] != int64(0) {
+		t.Errorf("group span attributes = %v, want 2 tasks and 0 failed", group.Attributes)
+	}
+	if _, ok := group.Attributes["logfire.group.first_failure"]; ok {
+		t.Errorf("group span has a first failure without failures")
+	}
+	for _, name := range []string{"fetch a", "fetch b"} {
+		if task := findSpan(t, spans, name); task.Parent != group.ID {
+			t.Errorf("span %q has parent %d, want the group span %d", name, task.Parent, group.ID)
+		}
+	}
+}
+
+func TestGroupFailure(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	errFailed := errors.New("supplier down")
+
+	g, _ := WithContext(context.Background())
+	g.Go("fails", func(ctx context.Context) error { return errFailed })
+	// The failure cancels the context of the other tasks.
+	g.Go("waits", func(ctx context.Context) error {
+		<-ctx.Done()
+		return context.Cause(ctx)
+	})
+	if err := g.Wait(); !errors.Is(err, errFailed) {
+		t.Fatalf("Wait() = %v, want %v", err, errFailed)
+	}
+	if err := g.Wait(); !errors.Is(err, errFailed) {
+		t.Errorf("second Wait() = %v, want %v", err, errFailed)
+	}
+
+	spans := rec.Spans()
+	group := findSpan(t, spans, "task group")
+	if group.Attributes["logfire.group.failed"] != int64(2) {
+		t.Errorf("group span failed = %v, want 2", group.Attributes["logfire.group.failed"])
+	}
+	if group.Attributes["logfire.group.first_failure"] != "fails" {
+		t.Errorf("group span first failure = %v, want fails", group.Attributes["logfire.group.first_failure"])
+	}
+	if group.Status == "" || findSpan(t, spans, "fails").Status == "" {
+		t.Errorf("group and failed task spans have no error status")
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+
+	g, _ := WithContext(context.Background())
+	g.Go("panics", func(ctx context.Context) error { panic("boom") })
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("Wait() panicked with %v, want boom", r)
+		}
+		task := findSpan(t, rec.Spans(), "panics")
+		if task.Attributes["exception.message"] != "boom" {
+			t.Errorf("task span attributes = %v, want the panic recorded", task.Attributes)
+		}
+	}()
+	g.Wait()
+	t.Errorf("Wait() returned, want a panic")
+}
@@ -0,0 +1,116 @@
+package logfiregrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/stats"
+)
+
+type callKey struct{}
+
+type attemptKey struct{}
+
+// callStats are the attempts of an RPC made by a client interceptor, as seen by the
+// ClientStatsHandler.
+type callStats struct {
+	mu          sync.Mutex
+	attempts    int
+	delayedPick bool
+	pickLatency time.Duration
+	peer        string
+}
+
+// withCallStats returns a context that the ClientStatsHandler records the attempts of
+// the RPC in.
+func withCallStats(ctx context.Context) (context.Context, *callStats) {
+	call := &callStats{}
+	return context.WithValue(ctx, callKey{}, call), call
+}
+
+// attributes returns the attributes of the attempts, or nil if no ClientStatsHandler
+// recorded them.
+func (c *callStats) attributes() []attribute.KeyValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.attempts == 0 {
+		return nil
+	}
+	attrs := []attribute.KeyValue{
+		attribute.Int("rpc.grpc.attempts", c.attempts),
+		attribute.Float64("rpc.grpc.pick_latency_ms", float64(c.pickLatency)/float64(time.Millisecond)),
+		attribute.Bool("rpc.grpc.delayed_pick", c.delayedPick),
+	}
+	if c.peer != "" {
+		attrs = append(attrs, attribute.String("network.peer.address", c.peer))
+	}
+	return attrs
+}
+
+// attempt is a single attempt of an RPC.
+type attempt struct {
+	call  *callStats
+	begin time.Time
+}
+
+// clientStatsHandler is a stats.Handler that records the attempts of RPCs.
+type clientStatsHandler struct{}
+
+var _ stats.Handler = clientStatsHandler{}
+
+// ClientStatsHandler returns a stats.Handler that records how the RPCs traced by the
+// client interceptors were load balanced and retried, to diagnose uneven load or bad
+// backends.  Client spans get the number of attempts, the address of the backend that
+// served the last attempt, and how long picking its backend took, including waiting for
+// a ready connection.
+//
+//	grpc.NewClient(target,
+//		grpc.WithUnaryInterceptor(logfiregrpc.UnaryClientInterceptor()),
+//		grpc.WithStatsHandler(logfiregrpc.ClientStatsHandler()),
+//	)
+func ClientStatsHandler() stats.Handler {
+	return clientStatsHandler{}
+}
+
+// TagRPC starts an attempt of an RPC made by a client interceptor.
+func (clientStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	call, ok := ctx.Value(callKey{}).(*callStats)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, attemptKey{}, &attempt{call: call, begin: time.Now()})
+}
+
+// HandleRPC records the progress of an attempt.
+func (clientStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	a, ok := ctx.Value(attemptKey{}).(*attempt)
+	if !ok {
+		return
+	}
+	call := a.call
+	call.mu.Lock()
+	defer call.mu.Unlock()
+	switch s := s.(type) {
+	case *stats.Begin:
+		call.attempts++
+		a.begin = s.BeginTime
+		call.delayedPick = false
+	case *stats.PickerUpdated:
+		call.delayedPick = true
+	case *stats.OutHeader:
+		call.pickLatency = time.Since(a.begin)
+		if s.RemoteAddr != nil {
+			call.peer = s.RemoteAddr.String()
+		}
+	}
+}
+
+// TagConn does nothing.
+func (clientStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn does nothing.
+func (clientStatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {}
@@ -0,0 +1,161 @@
+package logfiregrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor returns an interceptor that records every unary RPC made by
+// the client in a span, and propagates the trace to the server.
+//
+//	grpc.NewClient(target, grpc.WithUnaryInterceptor(logfiregrpc.UnaryClientInterceptor()))
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	config := newConfig(opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if !config.traced(method) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		ctx, span, call := startClientSpan(ctx, method)
+		config.recordMessage(ctx, method, "request", req)
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		if err == nil {
+			config.recordMessage(ctx, method, "response", reply)
+		}
+		endClientSpan(span, call, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns an interceptor that records every streaming RPC
+// made by the client in a span, and propagates the trace to the server.  The span
+// ends when the stream finishes: when the response of a stream without server streaming
+// is received, when receiving or sending fails, or when the context of the stream is
+// done.
+//
+//	grpc.NewClient(target, grpc.WithStreamInterceptor(logfiregrpc.StreamClientInterceptor()))
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	config := newConfig(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !config.traced(method) {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+
+		ctx, span, call := startClientSpan(ctx, method)
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			endClientSpan(span, call, err)
+			return nil, err
+		}
+		s := &clientStream{
+			ClientStream:  cs,
+			serverStreams: desc.ServerStreams,
+			span:          span,
+			call:          call,
+			stats:         &streamStats{span: span},
+			done:          make(chan struct{}),
+		}
+		// The caller may stop reading before the stream finishes, so the span is also
+		// ended when the caller gives up on the stream.
+		go func() {
+			select {
+			case <-ctx.Done():
+				s.end(status.FromContextError(ctx.Err()).Err())
+			case <-s.done:
+			}
+		}()
+		return s, nil
+	}
+}
+
+func startClientSpan(ctx context.Context, method string) (context.Context, oteltrace.Span, *callStats) {
+	name, attrs := spanInfo(method)
+	ctx, span := logfire.Tracer().Start(ctx, name,
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+		oteltrace.WithAttributes(attrs...),
+	)
+	ctx, call := withCallStats(ctx)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md), span, call
+}
+
+func endClientSpan(span oteltrace.Span, call *callStats, err error) {
+	_, attrs := statusAttributes(err)
+	span.SetAttributes(attrs...)
+	span.SetAttributes(call.attributes()...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+// clientStream records the messages of a streaming RPC, and ends its span once the
+// stream finishes.
+type clientStream struct {
+	grpc.ClientStream
+	// serverStreams is whether the server sends more than one message.
+	serverStreams bool
+	span          oteltrace.Span
+	call          *callStats
+	stats         *streamStats
+	once          sync.Once
+	// done is closed when the span ends.
+	done chan struct{}
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	switch {
+	case err == nil:
+		s.stats.messageSent(m)
+	case errors.Is(err, io.EOF):
+		// The stream was ended by the server, and its status is returned by RecvMsg.
+	default:
+		s.end(err)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.stats.messageReceived(m)
+		// Without server streaming the first response is the only one, and the caller
+		// isn't expected to receive again.
+		if !s.serverStreams {
+			s.end(nil)
+		}
+	case errors.Is(err, io.EOF):
+		s.end(nil)
+	default:
+		s.end(err)
+	}
+	return err
+}
+
+func (s *clientStream) end(err error) {
+	s.once.Do(func() {
+		s.span.SetAttributes(s.stats.attributes()...)
+		endClientSpan(s.span, s.call, err)
+		close(s.done)
+	})
+}
@@ -0,0 +1,117 @@
+// Package logfiregrpc instruments gRPC servers and clients with Logfire spans.
+//
+// Health checks and reflection RPCs are not traced by default, as they are frequent
+// and rarely interesting.
+package logfiregrpc
+
+import (
+	"path"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultExcludedMethods are the method patterns that are not traced by default: the
+// gRPC health checking and server reflection services.
+var DefaultExcludedMethods = []string{
+	"/grpc.health.v1.Health/*",
+	"/grpc.reflection.v1.ServerReflection/*",
+	"/grpc.reflection.v1alpha.ServerReflection/*",
+}
+
+// config is the config used by the interceptors.
+type config struct {
+	// ExcludedMethods are patterns of full method names that are not traced.
+	ExcludedMethods []string
+	// PayloadLogging logs the payloads of some methods, if set.
+	PayloadLogging *payloadLogging
+	// ScrubbedFields are the lowercase names of fields redacted from logged payloads.
+	ScrubbedFields map[string]bool
+}
+
+// Option is a function type that modifies the interceptor config.
+type Option func(*config)
+
+// WithExcludedMethods excludes methods matching any of the patterns from tracing, in
+// addition to DefaultExcludedMethods.  Patterns match the full method name, e.g.
+// "/pkg.Service/Method", using path.Match syntax, so "/pkg.Service/*" matches every
+// method of a service.
+func WithExcludedMethods(patterns ...string) Option {
+	return func(c *config) {
+		c.ExcludedMethods = append(c.ExcludedMethods, patterns...)
+	}
+}
+
+// WithAllMethods traces every method, including DefaultExcludedMethods.  Patterns
+// given to WithExcludedMethods after this option are still excluded.
+func WithAllMethods() Option {
+	return func(c *config) {
+		c.ExcludedMethods = nil
+	}
+}
+
+func newConfig(opts []Option) *config {
+	config := &config{
+		ExcludedMethods: append([]string(nil), DefaultExcludedMethods...),
+		ScrubbedFields:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// traced reports whether the method should be traced.
+func (c *config) traced(fullMethod string) bool {
+	for _, pattern := range c.ExcludedMethods {
+		if ok, _ := path.Match(pattern, fullMethod); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// spanInfo returns the span name and attributes for the method.
+func spanInfo(fullMethod string) (string, []attribute.KeyValue) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	attrs := []attribute.KeyValue{attribute.String("rpc.system", "grpc")}
+	if service, method, ok := strings.Cut(name, "/"); ok {
+		attrs = append(attrs,
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		)
+	}
+	return name, attrs
+}
+
+// statusAttributes returns the gRPC status code of err.
+func statusAttributes(err error) (codes.Code, []attribute.KeyValue) {
+	code := status.Code(err)
+	return code, []attribute.KeyValue{attribute.Int("rpc.grpc.status_code", int(code))}
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,231 @@
+package logfiregrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jerechua/logfire-go/logfiretest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// echoService is a hand written service, so the tests don't need generated code.
+var echoService = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(wrapperspb.StringValue)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: "/test.Echo/Echo"}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				if req.(*wrapperspb.StringValue).Value == "fail" {
+					return nil, status.Error(codes.Internal, "echo failed")
+				}
+				return req, nil
+			})
+		},
+	}},
+	Streams: []grpc.StreamDesc{
+		{
+			// Collect receives strings until the client closes, then replies with the last.
+			StreamName:    "Collect",
+			ClientStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				last := new(wrapperspb.StringValue)
+				for {
+					msg := new(wrapperspb.StringValue)
+					err := stream.RecvMsg(msg)
+					if errors.Is(err, io.EOF) {
+						return stream.SendMsg(last)
+					}
+					if err != nil {
+						return err
+					}
+					last = msg
+				}
+			},
+		},
+		{
+			// Watch sends strings until the client goes away.
+			StreamName:    "Watch",
+			ServerStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				if err := stream.RecvMsg(new(wrapperspb.StringValue)); err != nil {
+					return err
+				}
+				for {
+					if err := stream.SendMsg(wrapperspb.String("tick")); err != nil {
+						return err
+					}
+					select {
+					case <-stream.Context().Done():
+						return stream.Context().Err()
+					case <-time.After(time.Millisecond):
+					}
+				}
+			},
+		},
+	},
+}
+
+// dialEcho starts an echoService with the server interceptors on an in-memory
+// listener, and returns a client using the client interceptors.
+func dialEcho(t *testing.T, opts ...Option) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(opts...)),
+		grpc.StreamInterceptor(StreamServerInterceptor(opts...)),
+	)
+	srv.RegisterService(&echoService, struct{}{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts...)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(opts...)),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial the echo server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitForSpan returns the span with the name and kind, waiting for it to end, since
+// server spans can end after the client has its response.
+func waitForSpan(t *testing.T, rec *logfiretest.Recorder, name, kind string) logfiretest.Span {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		for _, s := range rec.Spans() {
+			if s.Name == name && s.Kind == kind {
+				return s
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no %s span named %q in %+v", kind, name, rec.Spans())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUnary(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	conn := dialEcho(t)
+
+	reply := new(wrapperspb.StringValue)
+	if err := conn.Invoke(context.Background(), "/test.Echo/Echo", wrapperspb.String("hi"), reply); err != nil {
+		t.Fatalf("Echo failed: %v", err)
+	}
+	client := waitForSpan(t, rec, "test.Echo/Echo", "client")
+	server := waitForSpan(t, rec, "test.Echo/Echo", "server")
+	if server.Trace != client.Trace || server.Parent != client.ID {
+		t.Errorf("server span is in trace %d with parent %d, want the client span %d of trace %d", server.Trace, server.Parent, client.ID, client.Trace)
+	}
+	if client.Attributes["rpc.service"] != "test.Echo" || client.Attributes["rpc.grpc.status_code"] != int64(codes.OK) {
+		t.Errorf("client span attributes = %v", client.Attributes)
+	}
+}
+
+func TestUnaryError(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	conn := dialEcho(t)
+
+	err := conn.Invoke(context.Background(), "/test.Echo/Echo", wrapperspb.String("fail"), new(wrapperspb.StringValue))
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("Echo = %v, want an Internal error", err)
+	}
+	for _, kind := range []string{"client", "server"} {
+		span := waitForSpan(t, rec, "test.Echo/Echo", kind)
+		if span.Status == "" || span.Attributes["rpc.grpc.status_code"] != int64(codes.Internal) {
+			t.Errorf("%s span has status %q and attributes %v, want an Internal error", kind, span.Status, span.Attributes)
+		}
+	}
+}
+
+func TestExcludedMethods(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	conn := dialEcho(t, WithExcludedMethods("/test.Echo/*"))
+
+	if err := conn.Invoke(context.Background(), "/test.Echo/Echo", wrapperspb.String("hi"), new(wrapperspb.StringValue)); err != nil {
+		t.Fatalf("Echo failed: %v", err)
+	}
+	if spans := rec.Spans(); len(spans) != 0 {
+		t.Errorf("recorded %+v for an excluded method, want nothing", spans)
+	}
+}
+
+func TestClientStreamEndsOnResponse(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	conn := dialEcho(t)
+
+	desc := &echoService.Streams[0]
+	stream, err := conn.NewStream(context.Background(), desc, "/test.Echo/Collect")
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	for _, s := range []string{"a", "b"} {
+		if err := stream.SendMsg(wrapperspb.String(s)); err != nil {
+			t.Fatalf("SendMsg failed: %v", err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend failed: %v", err)
+	}
+	// Like generated CloseAndRecv methods, the response is received once, without
+	// receiving again until io.EOF.
+	reply := new(wrapperspb.StringValue)
+	if err := stream.RecvMsg(reply); err != nil || reply.Value != "b" {
+		t.Fatalf("RecvMsg = %q, %v, want b", reply.Value, err)
+	}
+
+	client := waitForSpan(t, rec, "test.Echo/Collect", "client")
+	if client.Attributes["rpc.stream.sent_messages"] != int64(2) || client.Attributes["rpc.stream.received_messages"] != int64(1) {
+		t.Errorf("client span attributes = %v, want 2 messages sent and 1 received", client.Attributes)
+	}
+	if client.Status != "" {
+		t.Errorf("client span status = %q, want unset", client.Status)
+	}
+}
+
+func TestClientStreamEndsWhenContextIsDone(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	conn := dialEcho(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	desc := &echoService.Streams[1]
+	stream, err := conn.NewStream(ctx, desc, "/test.Echo/Watch")
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if err := stream.SendMsg(wrapperspb.String("start")); err != nil {
+		t.Fatalf("SendMsg failed: %v", err)
+	}
+	if err := stream.RecvMsg(new(wrapperspb.StringValue)); err != nil {
+		t.Fatalf("RecvMsg failed: %v", err)
+	}
+	// The caller stops reading, and gives up on the stream.
+	cancel()
+
+	client := waitForSpan(t, rec, "test.Echo/Watch", "client")
+	if client.Status == "" || client.Attributes["rpc.grpc.status_code"] != int64(codes.Canceled) {
+		t.Errorf("client span has status %q and attributes %v, want Canceled", client.Status, client.Attributes)
+	}
+}
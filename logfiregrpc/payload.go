@@ -0,0 +1,158 @@
+package logfiregrpc
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	redacted        = "[REDACTED]"
+	truncatedSuffix = "...truncated"
+)
+
+// sensitiveField matches the names of fields whose values are never logged.
+var sensitiveField = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[_-]?key|auth|credential|private[_-]?key)`)
+
+// payloadLogging is the config of WithPayloadLogging.
+type payloadLogging struct {
+	// MaxBytes is the longest payload logged, longer payloads are truncated.
+	MaxBytes int
+	// Methods are the patterns of the methods whose payloads are logged.
+	Methods []string
+}
+
+// WithPayloadLogging logs the request and response of methods matching any of the
+// patterns, at Debug, in the span of the RPC, for targeted debugging.  Patterns use the
+// same syntax as WithExcludedMethods.  Payloads are logged as JSON, truncated to
+// maxBytes, with the values of sensitive fields, such as passwords and tokens, and of
+// fields named with WithScrubbedFields, redacted.
+//
+//	logfiregrpc.UnaryServerInterceptor(logfiregrpc.WithPayloadLogging(4096, "/shop.Orders/*"))
+func WithPayloadLogging(maxBytes int, patterns ...string) Option {
+	return func(c *config) {
+		c.PayloadLogging = &payloadLogging{MaxBytes: maxBytes, Methods: patterns}
+	}
+}
+
+// WithScrubbedFields redacts the values of the named fields from logged payloads, in
+// addition to fields that look sensitive.  Names are the JSON names of fields, matched
+// case insensitively.
+func WithScrubbedFields(fields ...string) Option {
+	return func(c *config) {
+		for _, field := range fields {
+			c.ScrubbedFields[strings.ToLower(field)] = true
+		}
+	}
+}
+
+// logsPayload reports whether the payloads of the method are logged.
+func (c *config) logsPayload(fullMethod string) bool {
+	if c.PayloadLogging == nil {
+		return false
+	}
+	for _, pattern := range c.PayloadLogging.Methods {
+		if ok, _ := path.Match(pattern, fullMethod); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// recordMessage records the size of msg, the request or response of an RPC, on the
+// span of ctx, and logs it if the payloads of the method are logged.
+func (c *config) recordMessage(ctx context.Context, fullMethod, name string, msg any) {
+	if size, ok := messageSize(msg); ok {
+		oteltrace.SpanFromContext(ctx).SetAttributes(attribute.Int("rpc."+name+".size", size))
+	}
+	if c.logsPayload(fullMethod) {
+		c.logPayload(ctx, name, msg)
+	}
+}
+
+// logPayload logs msg, the request or response of an RPC, in the span of ctx.
+func (c *config) logPayload(ctx context.Context, name string, msg any) {
+	encoded, err := c.encodePayload(msg)
+	if err != nil {
+		logfire.FromContext(ctx).Debug("grpc "+name, logfire.WithAttributes(
+			attribute.String("rpc.payload.error", err.Error()),
+		))
+		return
+	}
+	logfire.FromContext(ctx).Debug("grpc "+name, logfire.WithAttributes(
+		attribute.String("rpc."+name+".payload", encoded),
+	))
+}
+
+// encodePayload encodes msg as JSON, scrubbed and truncated.
+func (c *config) encodePayload(msg any) (string, error) {
+	var b []byte
+	var err error
+	if m, ok := msg.(proto.Message); ok {
+		b, err = protojson.Marshal(m)
+	} else {
+		b, err = json.Marshal(msg)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var value any
+	if err := json.Unmarshal(b, &value); err != nil {
+		return "", err
+	}
+	b, err = json.Marshal(c.scrub(value))
+	if err != nil {
+		return "", err
+	}
+	return truncate(string(b), c.PayloadLogging.MaxBytes), nil
+}
+
+// scrub redacts the values of sensitive fields in a decoded JSON value.
+func (c *config) scrub(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if sensitiveField.MatchString(key) || c.ScrubbedFields[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = c.scrub(field)
+			}
+		}
+	case []any:
+		for i, elem := range v {
+			v[i] = c.scrub(elem)
+		}
+	}
+	return value
+}
+
+// truncate truncates s to at most maxBytes, on a rune boundary.
+func truncate(s string, maxBytes int) string {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s
+	}
+	s = s[:maxBytes]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s + truncatedSuffix
+}
+
+// messageSize returns the encoded size of msg, if it is a protobuf message.
+func messageSize(msg any) (int, bool) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return 0, false
+	}
+	return proto.Size(m), true
+}
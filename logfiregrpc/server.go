@@ -0,0 +1,115 @@
+package logfiregrpc
+
+import (
+	"context"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryServerInterceptor returns an interceptor that records every unary RPC handled
+// by the server in a span, continuing the trace of the client.
+//
+//	grpc.NewServer(grpc.UnaryInterceptor(logfiregrpc.UnaryServerInterceptor()))
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	config := newConfig(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !config.traced(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, span := startServerSpan(ctx, info.FullMethod)
+		config.recordMessage(ctx, info.FullMethod, "request", req)
+		resp, err := handler(ctx, req)
+		if err == nil {
+			config.recordMessage(ctx, info.FullMethod, "response", resp)
+		}
+		endServerSpan(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that records every streaming RPC
+// handled by the server in a span, continuing the trace of the client.
+//
+//	grpc.NewServer(grpc.StreamInterceptor(logfiregrpc.StreamServerInterceptor()))
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	config := newConfig(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !config.traced(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+		stats := &streamStats{span: span}
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx, stats: stats})
+		span.SetAttributes(stats.attributes()...)
+		endServerSpan(span, err)
+		return err
+	}
+}
+
+func startServerSpan(ctx context.Context, fullMethod string) (context.Context, oteltrace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	name, attrs := spanInfo(fullMethod)
+	return logfire.Tracer().Start(ctx, name,
+		oteltrace.WithSpanKind(oteltrace.SpanKindServer),
+		oteltrace.WithAttributes(attrs...),
+	)
+}
+
+func endServerSpan(span oteltrace.Span, err error) {
+	code, attrs := statusAttributes(err)
+	span.SetAttributes(attrs...)
+	if serverError(code) {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+// serverError reports whether the status code is a server error.  Codes caused by the
+// client, such as NotFound, don't mark the server span as errored.
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal,
+		codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// serverStream overrides the context of a grpc.ServerStream with the span context, and
+// records the messages of the stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	stats *streamStats
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.stats.messageSent(m)
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.stats.messageReceived(m)
+	}
+	return err
+}
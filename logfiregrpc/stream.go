@@ -0,0 +1,51 @@
+package logfiregrpc
+
+import (
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// streamStats counts the messages sent and received on a stream, recording an event on
+// the span of the stream for every message, so long streams aren't one flat span.
+//
+// Spans keep a limited number of events, 128 by default, so the totals are also
+// recorded on the span when the stream ends.
+type streamStats struct {
+	span oteltrace.Span
+
+	sent, received           atomic.Int64
+	sentBytes, receivedBytes atomic.Int64
+}
+
+// messageSent records that msg was sent.
+func (s *streamStats) messageSent(msg any) {
+	size, _ := messageSize(msg)
+	s.event("SENT", s.sent.Add(1), size, s.sentBytes.Add(int64(size)))
+}
+
+// messageReceived records that msg was received.
+func (s *streamStats) messageReceived(msg any) {
+	size, _ := messageSize(msg)
+	s.event("RECEIVED", s.received.Add(1), size, s.receivedBytes.Add(int64(size)))
+}
+
+func (s *streamStats) event(direction string, id int64, size int, total int64) {
+	s.span.AddEvent("message", oteltrace.WithAttributes(
+		attribute.String("message.type", direction),
+		attribute.Int64("message.id", id),
+		attribute.Int("message.uncompressed_size", size),
+		attribute.Int64("message.cumulative_size", total),
+	))
+}
+
+// attributes returns the totals of the stream.
+func (s *streamStats) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int64("rpc.stream.sent_messages", s.sent.Load()),
+		attribute.Int64("rpc.stream.received_messages", s.received.Load()),
+		attribute.Int64("rpc.stream.sent_bytes", s.sentBytes.Load()),
+		attribute.Int64("rpc.stream.received_bytes", s.receivedBytes.Load()),
+	}
+}
@@ -0,0 +1,16 @@
+package logfirehttp
+
+import (
+	"net/http"
+
+	"github.com/jerechua/logfire-go"
+)
+
+// ForceTrace applies the rule set with logfire.WithForceTraceRule to requests handled
+// by next, so matching requests are sampled and send logs of every level.  It must wrap
+// the middleware that starts the span of the request.
+func ForceTrace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(logfire.ForceTraceContext(r)))
+	})
+}
@@ -0,0 +1,79 @@
+package logfirehttp
+
+import (
+	"net/http"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Handler records every request handled by next in a server span, continuing the
+// trace of the client.  Spans are named after the route of the http.ServeMux that
+// handled the request, e.g. "GET /users/{id}", never the raw path, and the rule set
+// with logfire.WithForceTraceRule is applied, so ForceTrace isn't needed as well.
+//
+//	http.ListenAndServe(":8080", logfirehttp.Handler(mux))
+//
+// opts take a StatusMapper, defaulting to ServerStatus.
+func Handler(next http.Handler, opts ...Option) http.Handler {
+	config := &config{StatusMapper: ServerStatus}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(logfire.ForceTraceContext(r), propagation.HeaderCarrier(r.Header))
+		ctx, span := logfire.Tracer().Start(ctx, logfire.HTTPSpanName(r.Method, ""),
+			oteltrace.WithSpanKind(oteltrace.SpanKindServer),
+			oteltrace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		// The ServeMux sets the pattern it matched on the request it is given, so the
+		// route is known once it returns.
+		r = r.WithContext(ctx)
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		if route := logfire.HTTPRoute(r); route != "" {
+			span.SetName(logfire.HTTPSpanName(r.Method, route))
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", sw.code))
+		if code := config.StatusMapper(sw.code); code != codes.Unset {
+			span.SetStatus(code, http.StatusText(sw.code))
+		}
+	})
+}
+
+// statusWriter records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	// Informational responses are followed by the real one.
+	if !w.wroteHeader && code >= 200 {
+		w.code = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped ResponseWriter, for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,74 @@
+package logfirehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jerechua/logfire-go/logfiretest"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func newTestMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database is down", http.StatusServiceUnavailable)
+	})
+	return mux
+}
+
+func serveOne(t *testing.T, rec *logfiretest.Recorder, h http.Handler, method, target string) logfiretest.Span {
+	t.Helper()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, nil))
+	spans := rec.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want the request span", len(spans))
+	}
+	return spans[0]
+}
+
+func TestHandlerNamesSpansAfterRoutes(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	span := serveOne(t, rec, Handler(newTestMux()), http.MethodGet, "/users/42")
+	if span.Name != "GET /users/{id}" || span.Kind != "server" {
+		t.Errorf("span is a %s span named %q, want a server span named after the route", span.Kind, span.Name)
+	}
+	if span.Attributes["http.route"] != "/users/{id}" || span.Attributes["http.response.status_code"] != int64(http.StatusOK) {
+		t.Errorf("span attributes = %v", span.Attributes)
+	}
+	if span.Status != "" {
+		t.Errorf("span status = %q, want unset", span.Status)
+	}
+}
+
+func TestHandlerUnmatchedRequest(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	span := serveOne(t, rec, Handler(newTestMux()), http.MethodGet, "/secret/path/123")
+	if span.Name != "GET" {
+		t.Errorf("span name = %q, want the method alone for a request without a route", span.Name)
+	}
+	if span.Status != "" {
+		t.Errorf("span status of a 404 = %q, want unset", span.Status)
+	}
+}
+
+func TestHandlerStatus(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	if span := serveOne(t, rec, Handler(newTestMux()), http.MethodPost, "/orders"); span.Status == "" {
+		t.Errorf("span status of a 503 is unset, want an error")
+	}
+
+	rec.Reset()
+	expected := WithStatusMapper(func(code int) codes.Code {
+		if code == http.StatusServiceUnavailable {
+			return codes.Unset
+		}
+		return ServerStatus(code)
+	})
+	if span := serveOne(t, rec, Handler(newTestMux(), expected), http.MethodPost, "/orders"); span.Status != "" {
+		t.Errorf("span status of a 503 mapped to Unset = %q, want unset", span.Status)
+	}
+}
@@ -0,0 +1,110 @@
+package logfirehttp
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// connTimings records how long each phase of establishing a connection and waiting for
+// the response took.
+type connTimings struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	dns          time.Duration
+	connectStart time.Time
+	connect      time.Duration
+	tlsStart     time.Time
+	tls          time.Duration
+	ttfb         time.Duration
+	reused       bool
+	gotConn      bool
+}
+
+// withConnTimings returns a context that records the connection phase timings of a
+// request into the returned connTimings.
+func withConnTimings(ctx context.Context) (context.Context, *connTimings) {
+	t := &connTimings{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dns = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// With multiple addresses only the first connection attempt is timed, so
+			// the time includes any failed attempts.
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(string, string, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.connect = time.Since(t.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tls = time.Since(t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.gotConn = true
+			t.reused = info.Reused
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.ttfb = time.Since(t.start)
+		},
+	}
+	return httptrace.WithClientTrace(ctx, trace), t
+}
+
+// attributes returns the timings of the phases that happened, in milliseconds.
+func (t *connTimings) attributes() []attribute.KeyValue {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var attrs []attribute.KeyValue
+	if t.gotConn {
+		attrs = append(attrs, attribute.Bool("http.client.connection_reused", t.reused))
+	}
+	if t.dns > 0 {
+		attrs = append(attrs, attribute.Float64("http.client.dns_ms", milliseconds(t.dns)))
+	}
+	if t.connect > 0 {
+		attrs = append(attrs, attribute.Float64("http.client.connect_ms", milliseconds(t.connect)))
+	}
+	if t.tls > 0 {
+		attrs = append(attrs, attribute.Float64("http.client.tls_ms", milliseconds(t.tls)))
+	}
+	if t.ttfb > 0 {
+		attrs = append(attrs, attribute.Float64("http.client.ttfb_ms", milliseconds(t.ttfb)))
+	}
+	return attrs
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
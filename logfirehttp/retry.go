@@ -0,0 +1,166 @@
+package logfirehttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type attemptKey struct{}
+
+// attempt describes one attempt of a retried request.
+type attempt struct {
+	number  int
+	backoff time.Duration
+}
+
+func (a attempt) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int("http.request.resend_count", a.number-1),
+		attribute.Int("logfire.retry.attempt", a.number),
+		attribute.Float64("logfire.retry.backoff_ms", milliseconds(a.backoff)),
+	}
+}
+
+// WithAttempt marks requests sent with the returned context as the given attempt,
+// starting from 1, of a retried request, made after waiting for backoff.  Transport
+// records both on the span of the attempt.
+//
+// Use this to integrate retry libraries other than RetryTransport, e.g. from the
+// request hook of the library.
+func WithAttempt(ctx context.Context, number int, backoff time.Duration) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt{number: number, backoff: backoff})
+}
+
+func attemptFromContext(ctx context.Context) (attempt, bool) {
+	a, ok := ctx.Value(attemptKey{}).(attempt)
+	return a, ok
+}
+
+// RetryPolicy decides how failed requests are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	MaxAttempts int
+	// Backoff returns how long to wait before the given attempt, starting from 2.
+	Backoff func(attempt int) time.Duration
+	// ShouldRetry reports whether a request should be retried after the response or
+	// error of an attempt.  Defaults to retrying errors, 429 and 5xx responses.
+	ShouldRetry func(resp *http.Response, err error) bool
+}
+
+// ExponentialBackoff returns a Backoff function that doubles the wait after every
+// attempt, starting from base.
+func ExponentialBackoff(base time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		return base << (attempt - 2)
+	}
+}
+
+func defaultShouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// RetryTransport retries failed requests according to a RetryPolicy.  Each request is
+// recorded in a parent span, with a child span for every attempt, so retry storms are
+// visible.  Requests with a body are only retried if they have GetBody set.  The
+// request passed to RoundTrip isn't modified, each attempt sends a clone of it.
+type RetryTransport struct {
+	next   *Transport
+	policy RetryPolicy
+}
+
+// NewRetryTransport wraps base, or http.DefaultTransport if base is nil, in a
+// RetryTransport.  opts configure the Transport that records each attempt, as in
+// NewTransport.
+//
+//	client := &http.Client{Transport: logfirehttp.NewRetryTransport(nil, logfirehttp.RetryPolicy{
+//		MaxAttempts: 3,
+//		Backoff:     logfirehttp.ExponentialBackoff(100 * time.Millisecond),
+//	})}
+func NewRetryTransport(base http.RoundTripper, policy RetryPolicy, opts ...Option) *RetryTransport {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Backoff == nil {
+		policy.Backoff = func(int) time.Duration { return 0 }
+	}
+	if policy.ShouldRetry == nil {
+		policy.ShouldRetry = defaultShouldRetry
+	}
+	return &RetryTransport{
+		next:   NewTransport(base, opts...),
+		policy: policy,
+	}
+}
+
+// RoundTrip sends the request, retrying it as allowed by the policy.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := logfire.NewSpanLogger(req.Context(), "HTTP "+req.Method, logfire.WithAttributes(
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", redactURL(req)),
+	))
+	ctx := logger.Context()
+
+	var (
+		resp    *http.Response
+		err     error
+		backoff time.Duration
+		number  int
+	)
+	body := req.Body
+	for number = 1; ; number++ {
+		attemptReq := req.Clone(WithAttempt(ctx, number, backoff))
+		attemptReq.Body = body
+		resp, err = t.next.RoundTrip(attemptReq)
+		if number >= t.policy.MaxAttempts || !t.policy.ShouldRetry(resp, err) {
+			break
+		}
+		if req.Body != nil && req.GetBody == nil {
+			// The body can't be sent again.
+			break
+		}
+
+		backoff = t.policy.Backoff(number + 1)
+		if sleep(ctx, backoff) != nil {
+			break
+		}
+		if req.Body != nil {
+			var bodyErr error
+			if body, bodyErr = req.GetBody(); bodyErr != nil {
+				break
+			}
+		}
+		if resp != nil {
+			// The response is being discarded for another attempt.
+			resp.Body.Close()
+		}
+	}
+
+	logger.SetAttributes(attribute.Int("logfire.retry.attempts", number))
+	if resp != nil {
+		logger.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	logger.CloseWithOptions(logfire.WithError(err))
+	return resp, err
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,95 @@
+package logfirehttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jerechua/logfire-go/logfiretest"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestRetryTransport(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); string(body) != "order" {
+			t.Errorf("attempt %d sent body %q, want order", attempts.Load()+1, body)
+		}
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	rt := NewRetryTransport(nil, RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     ExponentialBackoff(time.Millisecond),
+	}, WithStatusMapper(func(code int) codes.Code { return codes.Unset }))
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("order"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || attempts.Load() != 3 {
+		t.Errorf("got status %d after %d attempts, want 404 after 3", resp.StatusCode, attempts.Load())
+	}
+	if req.Body != body {
+		t.Errorf("RoundTrip replaced the body of the caller's request")
+	}
+
+	var parent logfiretest.Span
+	var children int
+	for _, s := range rec.Spans() {
+		if s.Parent == 0 {
+			parent = s
+			continue
+		}
+		children++
+		if s.Status != "" {
+			// The status mapper treats every status as OK.
+			t.Errorf("attempt span has status %q, want the status mapper applied", s.Status)
+		}
+	}
+	if children != 3 {
+		t.Errorf("recorded %d attempt spans, want 3", children)
+	}
+	if parent.Attributes["logfire.retry.attempts"] != int64(3) {
+		t.Errorf("parent span attributes = %v, want 3 attempts", parent.Attributes)
+	}
+}
+
+func TestRetryTransportWithoutGetBody(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, io.NopCloser(strings.NewReader("order")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewRetryTransport(nil, RetryPolicy{MaxAttempts: 3}).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip failed: %v", err)
+	}
+	resp.Body.Close()
+	if attempts.Load() != 1 {
+		t.Errorf("sent %d attempts, want 1 as the body can't be sent again", attempts.Load())
+	}
+}
@@ -0,0 +1,97 @@
+package logfirehttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ServerMetrics records the saturation of an HTTP server, complementing the spans of
+// each request:
+//
+//   - http.server.connections is the number of connections, by state.
+//
+//   - http.server.active_requests is the number of requests being handled.
+//
+//     metrics := logfirehttp.NewServerMetrics()
+//     server := &http.Server{
+//     Handler:   metrics.Middleware(mux),
+//     ConnState: metrics.ConnState,
+//     }
+type ServerMetrics struct {
+	connections    metric.Int64UpDownCounter
+	activeRequests metric.Int64UpDownCounter
+
+	mu     sync.Mutex
+	states map[net.Conn]http.ConnState
+}
+
+// NewServerMetrics creates the server metrics.  Initialize must be called first.
+func NewServerMetrics() *ServerMetrics {
+	m := &ServerMetrics{states: make(map[net.Conn]http.ConnState)}
+
+	meter := logfire.Meter()
+	var err error
+	m.connections, err = meter.Int64UpDownCounter("http.server.connections",
+		metric.WithDescription("Number of connections, by state."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create http.server.connections metric: " + err.Error())
+	}
+	m.activeRequests, err = meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithDescription("Number of requests being handled."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create http.server.active_requests metric: " + err.Error())
+	}
+	return m
+}
+
+// ConnState tracks the state of each connection, and should be set as the ConnState of
+// an http.Server.
+func (m *ServerMetrics) ConnState(conn net.Conn, state http.ConnState) {
+	if m.connections == nil {
+		return
+	}
+
+	m.mu.Lock()
+	previous, ok := m.states[conn]
+	if state == http.StateClosed || state == http.StateHijacked {
+		delete(m.states, conn)
+	} else {
+		m.states[conn] = state
+	}
+	m.mu.Unlock()
+
+	ctx := context.Background()
+	if ok {
+		m.connections.Add(ctx, -1, metric.WithAttributes(connStateAttribute(previous)))
+	}
+	if state != http.StateClosed && state != http.StateHijacked {
+		m.connections.Add(ctx, 1, metric.WithAttributes(connStateAttribute(state)))
+	}
+}
+
+// Middleware counts the requests being handled by next.
+func (m *ServerMetrics) Middleware(next http.Handler) http.Handler {
+	if m.activeRequests == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attrs := metric.WithAttributes(attribute.String("http.request.method", r.Method))
+		m.activeRequests.Add(r.Context(), 1, attrs)
+		defer m.activeRequests.Add(r.Context(), -1, attrs)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func connStateAttribute(state http.ConnState) attribute.KeyValue {
+	return attribute.String("http.connection.state", state.String())
+}
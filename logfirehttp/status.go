@@ -0,0 +1,49 @@
+package logfirehttp
+
+import "go.opentelemetry.io/otel/codes"
+
+// StatusMapper maps the status code of a response to the status of its span, e.g. to
+// treat a 404 from a search endpoint as OK.
+type StatusMapper func(code int) codes.Code
+
+// ClientStatus is the default StatusMapper of client spans: 4xx and 5xx responses are
+// errors.
+func ClientStatus(code int) codes.Code {
+	if code >= 400 {
+		return codes.Error
+	}
+	return codes.Unset
+}
+
+// ServerStatus is the default StatusMapper of server spans: 5xx responses are errors,
+// while 4xx responses are the client's mistakes.
+func ServerStatus(code int) codes.Code {
+	if code >= 500 {
+		return codes.Error
+	}
+	return codes.Unset
+}
+
+// config is the config used by NewTransport and Handler.
+type config struct {
+	// StatusMapper maps the status code of responses to the status of their spans.
+	StatusMapper StatusMapper
+}
+
+// Option is a function type that modifies the NewTransport or Handler config.
+type Option func(*config)
+
+// WithStatusMapper sets how the status codes of responses map to the status of their
+// spans.  Defaults to ClientStatus for NewTransport, and ServerStatus for Handler.
+//
+//	logfirehttp.NewTransport(nil, logfirehttp.WithStatusMapper(func(code int) codes.Code {
+//		if code == http.StatusNotFound {
+//			return codes.Unset
+//		}
+//		return logfirehttp.ClientStatus(code)
+//	}))
+func WithStatusMapper(mapper StatusMapper) Option {
+	return func(c *config) {
+		c.StatusMapper = mapper
+	}
+}
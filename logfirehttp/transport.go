@@ -0,0 +1,84 @@
+// Package logfirehttp instruments HTTP clients and servers with Logfire spans and metrics.
+package logfirehttp
+
+import (
+	"net/http"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Transport is an http.RoundTripper that records every request in a client span, and
+// propagates the trace context to the server.  Spans include how long DNS, connecting,
+// the TLS handshake and the time to first byte took, for deep latency diagnosis.
+type Transport struct {
+	base   http.RoundTripper
+	config *config
+}
+
+// NewTransport wraps base, or http.DefaultTransport if base is nil, in a Transport.
+//
+//	client := &http.Client{Transport: logfirehttp.NewTransport(nil)}
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	config := &config{StatusMapper: ClientStatus}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &Transport{base: base, config: config}
+}
+
+// RoundTrip sends the request in a new client span.  When the request is an attempt
+// of a retried request, see WithAttempt, the span records the attempt number and the
+// backoff that preceded it.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", redactURL(req)),
+		attribute.String("server.address", req.URL.Hostname()),
+	}
+	name := "HTTP " + req.Method
+	if attempt, ok := attemptFromContext(req.Context()); ok {
+		name = name + " attempt"
+		attrs = append(attrs, attempt.attributes()...)
+	}
+
+	ctx, span := logfire.Tracer().Start(req.Context(), name,
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+		oteltrace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	traceCtx, timings := withConnTimings(ctx)
+	req = req.Clone(traceCtx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	span.SetAttributes(timings.attributes()...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if code := t.config.StatusMapper(resp.StatusCode); code != codes.Unset {
+		span.SetStatus(code, resp.Status)
+	}
+	return resp, nil
+}
+
+// redactURL returns the URL of the request without credentials or query parameters,
+// which commonly hold secrets.
+func redactURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
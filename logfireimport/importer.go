@@ -0,0 +1,236 @@
+// Package logfireimport ships historical log files to Logfire.
+//
+// Each line of a JSON-lines or logfmt file becomes a log, keeping its original level
+// and timestamp, with every other field attached as an attribute.
+package logfireimport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+)
+
+// maxLineSize is the longest line that can be imported.
+const maxLineSize = 1024 * 1024
+
+// Format is the format of the file being imported.
+type Format int
+
+const (
+	// FormatJSON is one JSON object per line.
+	FormatJSON Format = iota
+	// FormatLogfmt is one logfmt record, i.e. key=value pairs, per line.
+	FormatLogfmt
+)
+
+// Mapping describes which fields of a record hold the message, level and timestamp.
+type Mapping struct {
+	// MessageKey is the field holding the log message.  Defaults to "msg".
+	MessageKey string
+	// LevelKey is the field holding the log level.  Defaults to "level".
+	LevelKey string
+	// TimeKey is the field holding the time of the log.  Defaults to "time".
+	TimeKey string
+	// TimeLayout is the layout used to parse the time field.  Numeric times are always
+	// parsed as Unix seconds.  Defaults to time.RFC3339Nano.
+	TimeLayout string
+	// Levels maps level names, compared case insensitively, to a Logfire level.
+	// Defaults to DefaultLevels.
+	Levels map[string]logfire.Level
+	// DefaultLevel is used when a record has no level, or an unknown one.  Defaults to
+	// logfire.LevelInfo.
+	DefaultLevel logfire.Level
+}
+
+// DefaultLevels are the level names used when Mapping.Levels is not set, the same
+// names as logfire.ParseLevel recognizes.
+var DefaultLevels = logfire.LevelNames()
+
+// config is the config used by an Importer.
+type config struct {
+	// Format is the format of the file.
+	Format Format
+	// Mapping maps record fields to the message, level and timestamp of the log.
+	Mapping Mapping
+	// RateLimit is the maximum number of logs sent per second, or 0 for no limit.
+	RateLimit int
+}
+
+// Option is a function type that modifies the Importer config.
+type Option func(*config)
+
+// WithFormat sets the format of the imported file.  Defaults to FormatJSON.
+func WithFormat(format Format) Option {
+	return func(c *config) {
+		c.Format = format
+	}
+}
+
+// WithMapping sets how record fields map to the message, level and timestamp of the
+// log.  Fields that are not set in the mapping keep their defaults.
+func WithMapping(mapping Mapping) Option {
+	return func(c *config) {
+		c.Mapping = mapping
+	}
+}
+
+// WithRateLimit limits how many logs are sent per second.
+func WithRateLimit(perSecond int) Option {
+	return func(c *config) {
+		c.RateLimit = perSecond
+	}
+}
+
+// Importer reads log records and sends them to Logfire.  logfire.Initialize must be
+// called before importing.
+type Importer struct {
+	config *config
+}
+
+// New creates an Importer with the given options.
+func New(opts ...Option) *Importer {
+	config := &config{Format: FormatJSON}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	m := &config.Mapping
+	if m.MessageKey == "" {
+		m.MessageKey = "msg"
+	}
+	if m.LevelKey == "" {
+		m.LevelKey = "level"
+	}
+	if m.TimeKey == "" {
+		m.TimeKey = "time"
+	}
+	if m.TimeLayout == "" {
+		m.TimeLayout = time.RFC3339Nano
+	}
+	if m.Levels == nil {
+		m.Levels = DefaultLevels
+	}
+	if m.DefaultLevel == 0 {
+		m.DefaultLevel = logfire.LevelInfo
+	}
+
+	return &Importer{config: config}
+}
+
+// ImportFile imports every record in the file at path, returning the number of logs
+// that were sent.
+func (i *Importer) ImportFile(ctx context.Context, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return i.Import(ctx, f)
+}
+
+// Import imports every record read from r, returning the number of logs that were
+// sent.  Blank lines are skipped, and importing stops at the first line that can't be
+// parsed, or when ctx is done.
+func (i *Importer) Import(ctx context.Context, r io.Reader) (int, error) {
+	var tick <-chan time.Time
+	if i.config.RateLimit > 0 {
+		// Rates over one log per nanosecond are as good as no limit.
+		ticker := time.NewTicker(max(time.Second/time.Duration(i.config.RateLimit), time.Nanosecond))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	logger := logfire.FromContext(ctx)
+	observed := time.Now()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	sent := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		fields, err := i.parse(text)
+		if err != nil {
+			return sent, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				return sent, ctx.Err()
+			case <-tick:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+
+		level, msg, opts, err := i.toLog(fields)
+		if err != nil {
+			return sent, fmt.Errorf("line %d: %w", line, err)
+		}
+		opts = append(opts, logfire.WithObservedTimestamp(observed))
+		logger.Log(level, msg, opts...)
+		sent++
+	}
+	return sent, scanner.Err()
+}
+
+func (i *Importer) parse(line string) (map[string]any, error) {
+	switch i.config.Format {
+	case FormatLogfmt:
+		return logfire.ParseLogfmt(line)
+	default:
+		var fields map[string]any
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&fields); err != nil {
+			return nil, err
+		}
+		return fields, nil
+	}
+}
+
+// toLog maps the fields of a record to a log.
+func (i *Importer) toLog(fields map[string]any) (logfire.Level, string, []logfire.SpanOption, error) {
+	m := i.config.Mapping
+
+	level := m.DefaultLevel
+	if v, ok := fields[m.LevelKey]; ok {
+		if l, ok := m.Levels[strings.ToLower(fmt.Sprint(v))]; ok {
+			level = l
+		}
+		delete(fields, m.LevelKey)
+	}
+
+	var msg string
+	if v, ok := fields[m.MessageKey]; ok {
+		msg = fmt.Sprint(v)
+		delete(fields, m.MessageKey)
+	}
+
+	var opts []logfire.SpanOption
+	if v, ok := fields[m.TimeKey]; ok {
+		t, err := logfire.ParseTime(v, m.TimeLayout)
+		if err != nil {
+			return 0, "", nil, err
+		}
+		opts = append(opts, logfire.WithTimestamp(t))
+		delete(fields, m.TimeKey)
+	}
+
+	if attrs := logfire.FieldAttributes(fields); len(attrs) > 0 {
+		opts = append(opts, logfire.WithAttributes(attrs...))
+	}
+	return level, msg, opts, nil
+}
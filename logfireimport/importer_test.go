@@ -0,0 +1,108 @@
+package logfireimport
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jerechua/logfire-go"
+	"github.com/jerechua/logfire-go/logfiretest"
+)
+
+func TestImportJSON(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	input := strings.Join([]string{
+		`{"time": "2024-05-01T12:00:00Z", "level": "ERROR", "msg": "payment failed", "order_id": 7}`,
+		``,
+		`{"msg": "no level"}`,
+	}, "\n")
+
+	sent, err := New().Import(context.Background(), strings.NewReader(input))
+	if err != nil || sent != 2 {
+		t.Fatalf("Import = %d, %v, want 2 logs and no error", sent, err)
+	}
+
+	spans := rec.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2 logs", len(spans))
+	}
+	first := spans[0]
+	if first.Attributes["logfire.msg"] != "payment failed" || first.Attributes["logfire.level_num"] != int64(logfire.LevelError) {
+		t.Errorf("first log attributes = %v, want the message and level of the record", first.Attributes)
+	}
+	if first.Attributes["order_id"] != int64(7) {
+		t.Errorf("order_id = %#v, want the field as an int attribute", first.Attributes["order_id"])
+	}
+	for _, key := range []string{"msg", "level", "time"} {
+		if _, ok := first.Attributes[key]; ok {
+			t.Errorf("mapped field %q was also recorded as an attribute", key)
+		}
+	}
+	if got := spans[1].Attributes["logfire.level_num"]; got != int64(logfire.LevelInfo) {
+		t.Errorf("level of a record without one = %v, want info", got)
+	}
+}
+
+func TestImportLogfmtWithMapping(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	importer := New(WithFormat(FormatLogfmt), WithMapping(Mapping{
+		MessageKey:   "message",
+		LevelKey:     "severity",
+		Levels:       map[string]logfire.Level{"sev2": logfire.LevelWarn},
+		DefaultLevel: logfire.LevelDebug,
+	}))
+
+	input := "severity=SEV2 message=\"disk almost full\" host=db1\nseverity=sev9 message=unknown\n"
+	if _, err := importer.Import(context.Background(), strings.NewReader(input)); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	spans := rec.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2 logs", len(spans))
+	}
+	if spans[0].Attributes["logfire.msg"] != "disk almost full" || spans[0].Attributes["logfire.level_num"] != int64(logfire.LevelWarn) || spans[0].Attributes["host"] != "db1" {
+		t.Errorf("first log attributes = %v", spans[0].Attributes)
+	}
+	if got := spans[1].Attributes["logfire.level_num"]; got != int64(logfire.LevelDebug) {
+		t.Errorf("level of an unknown level name = %v, want the default level", got)
+	}
+}
+
+func TestImportStopsAtInvalidLine(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	input := "{\"msg\": \"ok\"}\nnot json\n{\"msg\": \"never sent\"}\n"
+
+	sent, err := New().Import(context.Background(), strings.NewReader(input))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("Import error = %v, want one naming line 2", err)
+	}
+	if sent != 1 || len(rec.Spans()) != 1 {
+		t.Errorf("sent %d logs, recorded %d, want only the line before the invalid one", sent, len(rec.Spans()))
+	}
+}
+
+func TestImportInvalidTime(t *testing.T) {
+	logfiretest.NewRecorder(t)
+	_, err := New().Import(context.Background(), strings.NewReader(`{"msg": "hi", "time": "yesterday"}`))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 1:") {
+		t.Errorf("Import error = %v, want the time of line 1 rejected", err)
+	}
+}
+
+func TestImportCanceled(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, importer := range []*Importer{New(), New(WithRateLimit(1))} {
+		sent, err := importer.Import(ctx, strings.NewReader(`{"msg": "hi"}`))
+		if !errors.Is(err, context.Canceled) || sent != 0 {
+			t.Errorf("Import with a canceled context = %d, %v, want nothing sent", sent, err)
+		}
+	}
+	if spans := rec.Spans(); len(spans) != 0 {
+		t.Errorf("recorded %d spans after canceling, want none", len(spans))
+	}
+}
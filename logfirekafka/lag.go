@@ -0,0 +1,76 @@
+// Package logfirekafka reports Kafka consumer lag to Logfire.
+//
+// It does not depend on a Kafka client, offsets are fetched by a function that can use
+// any client, e.g. sarama or franz-go.
+package logfirekafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// PartitionOffsets are the offsets of a consumer group in one partition.
+type PartitionOffsets struct {
+	// Group is the consumer group.
+	Group string
+	// Topic is the topic of the partition.
+	Topic string
+	// Partition is the partition number.
+	Partition int32
+	// HighWatermark is the offset of the next message that will be produced.
+	HighWatermark int64
+	// Committed is the offset of the next message the group will consume.
+	Committed int64
+}
+
+// Lag is the number of messages the group has yet to consume.
+func (o PartitionOffsets) Lag() int64 {
+	return max(o.HighWatermark-o.Committed, 0)
+}
+
+// FetchOffsets fetches the offsets of every partition that the consumer groups of
+// interest consume.
+type FetchOffsets func(ctx context.Context) ([]PartitionOffsets, error)
+
+// CollectLag fetches offsets every interval, until the returned function is called,
+// and records the lag of each partition as the messaging.kafka.consumer.lag metric.
+// Errors fetching offsets are logged as warnings.
+func CollectLag(interval time.Duration, fetch FetchOffsets) func() {
+	lag, err := logfire.Meter().Int64Gauge("messaging.kafka.consumer.lag",
+		metric.WithDescription("Number of messages a consumer group has yet to consume, by partition."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create messaging.kafka.consumer.lag metric: " + err.Error())
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			offsets, err := fetch(ctx)
+			if err != nil && ctx.Err() == nil {
+				logfire.Warn("failed to fetch kafka offsets: " + err.Error())
+			}
+			for _, o := range offsets {
+				lag.Record(ctx, o.Lag(), metric.WithAttributes(
+					attribute.String("messaging.kafka.consumer.group", o.Group),
+					attribute.String("messaging.destination.name", o.Topic),
+					attribute.Int("messaging.kafka.destination.partition", int(o.Partition)),
+				))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
@@ -0,0 +1,139 @@
+// Package logfirellm records LLM calls as spans that Logfire displays as LLM spans.
+//
+// Spans follow the OpenTelemetry gen_ai semantic conventions.  Calls can be recorded
+// by hand with StartChat, or automatically for the OpenAI and Anthropic Go clients by
+// giving them an http.Client using OpenAITransport or AnthropicTransport.
+package logfirellm
+
+import (
+	"context"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Well known values of gen_ai.system.
+const (
+	SystemOpenAI    = "openai"
+	SystemAnthropic = "anthropic"
+)
+
+// Message is a single message sent to, or received from, a model.
+type Message struct {
+	// Role is who the message is from, e.g. "system", "user" or "assistant".
+	Role string
+	// Content is the text of the message.
+	Content string
+}
+
+// Request describes a chat request sent to a model.
+type Request struct {
+	// System is the provider of the model, e.g. SystemOpenAI.
+	System string
+	// Model is the model that was requested.
+	Model string
+	// MaxTokens is the maximum number of tokens to generate, if set.
+	MaxTokens int
+	// Temperature is the sampling temperature, if set.
+	Temperature *float64
+	// Messages are the messages sent to the model.
+	Messages []Message
+}
+
+// Response describes the response from a model.
+type Response struct {
+	// ID is the ID of the response assigned by the provider.
+	ID string
+	// Model is the model that generated the response.
+	Model string
+	// InputTokens is the number of tokens in the prompt.
+	InputTokens int
+	// OutputTokens is the number of tokens generated.
+	OutputTokens int
+	// FinishReasons are the reasons the model stopped generating.
+	FinishReasons []string
+	// Messages are the messages generated by the model.
+	Messages []Message
+}
+
+// Span is an in progress LLM call.
+type Span struct {
+	logger *logfire.SpanLogger
+}
+
+// StartChat starts a span for a chat request.  The returned context should be used
+// for the call, and End must be called when the call is done.
+func StartChat(ctx context.Context, req Request) (context.Context, *Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.system", req.System),
+		attribute.String("gen_ai.request.model", req.Model),
+	}
+	if req.MaxTokens > 0 {
+		attrs = append(attrs, attribute.Int("gen_ai.request.max_tokens", req.MaxTokens))
+	}
+	if req.Temperature != nil {
+		attrs = append(attrs, attribute.Float64("gen_ai.request.temperature", *req.Temperature))
+	}
+
+	logger := logfire.NewSpanLogger(ctx, "chat "+req.Model, logfire.WithAttributes(attrs...))
+	span := &Span{logger: logger}
+	for _, msg := range req.Messages {
+		span.addMessage(msg)
+	}
+	return logger.Context(), span
+}
+
+// RecordResponse records the response from the model, including token usage.
+func (s *Span) RecordResponse(resp Response) {
+	var attrs []attribute.KeyValue
+	if resp.ID != "" {
+		attrs = append(attrs, attribute.String("gen_ai.response.id", resp.ID))
+	}
+	if resp.Model != "" {
+		attrs = append(attrs, attribute.String("gen_ai.response.model", resp.Model))
+	}
+	if len(resp.FinishReasons) > 0 {
+		attrs = append(attrs, attribute.StringSlice("gen_ai.response.finish_reasons", resp.FinishReasons))
+	}
+	s.logger.SetAttributes(attrs...)
+	s.RecordUsage(resp.InputTokens, resp.OutputTokens)
+
+	for i, msg := range resp.Messages {
+		s.addEvent("gen_ai.choice",
+			attribute.Int("index", i),
+			attribute.String("role", msg.Role),
+			attribute.String("content", msg.Content),
+		)
+	}
+}
+
+// RecordUsage records the number of prompt and completion tokens used.
+func (s *Span) RecordUsage(inputTokens, outputTokens int) {
+	s.logger.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", inputTokens),
+		attribute.Int("gen_ai.usage.output_tokens", outputTokens),
+	)
+}
+
+// RecordCost records the cost of the call, in US dollars.
+func (s *Span) RecordCost(usd float64) {
+	s.logger.SetAttributes(attribute.Float64("operation.cost", usd))
+}
+
+// End ends the span, recording err if the call failed.
+func (s *Span) End(err error) {
+	s.logger.CloseWithOptions(logfire.WithError(err))
+}
+
+func (s *Span) addMessage(msg Message) {
+	s.addEvent("gen_ai."+msg.Role+".message",
+		attribute.String("role", msg.Role),
+		attribute.String("content", msg.Content),
+	)
+}
+
+func (s *Span) addEvent(name string, attrs ...attribute.KeyValue) {
+	oteltrace.SpanFromContext(s.logger.Context()).AddEvent(name, oteltrace.WithAttributes(attrs...))
+}
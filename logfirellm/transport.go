@@ -0,0 +1,467 @@
+package logfirellm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// config is the config used by the client transports.
+type config struct {
+	// CapturePayloads records the messages sent and received as span events.
+	CapturePayloads bool
+}
+
+// Option is a function type that modifies the transport config.
+type Option func(*config)
+
+// WithPayloads records the messages sent to and received from the model as span
+// events.  They aren't recorded by default, as prompts and responses often contain
+// personal or otherwise sensitive data.  The model, finish reasons and token usage are
+// always recorded.
+func WithPayloads() Option {
+	return func(c *config) {
+		c.CapturePayloads = true
+	}
+}
+
+// OpenAITransport returns an http.RoundTripper that records chat completion requests
+// made through it.  Use it as the transport of the http.Client given to the OpenAI
+// client:
+//
+//	client := openai.NewClient(option.WithHTTPClient(&http.Client{
+//		Transport: logfirellm.OpenAITransport(nil),
+//	}))
+//
+// A nil base uses http.DefaultTransport.
+func OpenAITransport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	return newTransport(base, openAIProvider{}, opts)
+}
+
+// AnthropicTransport returns an http.RoundTripper that records message requests made
+// through it.  Use it as the transport of the http.Client given to the Anthropic client:
+//
+//	client := anthropic.NewClient(option.WithHTTPClient(&http.Client{
+//		Transport: logfirellm.AnthropicTransport(nil),
+//	}))
+//
+// A nil base uses http.DefaultTransport.
+func AnthropicTransport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	return newTransport(base, anthropicProvider{}, opts)
+}
+
+// provider parses the requests and responses of a single LLM API.
+type provider interface {
+	system() string
+	matches(req *http.Request) bool
+	parseRequest(body []byte) (Request, error)
+	parseResponse(body []byte) (Response, error)
+	// parseStreamEvent adds the data of an event of a streamed response to stream.
+	parseStreamEvent(data []byte, stream *streamState)
+}
+
+type transport struct {
+	base     http.RoundTripper
+	provider provider
+	config   *config
+}
+
+func newTransport(base http.RoundTripper, p provider, opts []Option) *transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	config := &config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &transport{
+		base:     base,
+		provider: p,
+		config:   config,
+	}
+}
+
+// RoundTrip records the request in a span if it is a chat request, and passes every
+// other request through untouched.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || req.Body == nil || !t.provider.matches(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	chat, err := t.provider.parseRequest(body)
+	if err != nil {
+		// Not a request we understand, don't get in the way.
+		return t.base.RoundTrip(req)
+	}
+	chat.System = t.provider.system()
+	if !t.config.CapturePayloads {
+		chat.Messages = nil
+	}
+
+	ctx, span := StartChat(req.Context(), chat)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		span.End(fmt.Errorf("%s API returned %s", chat.System, resp.Status))
+		return resp, nil
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		// Streamed responses are recorded as the caller reads them, and the span ends
+		// once the caller is done with them.
+		resp.Body = &streamBody{ReadCloser: resp.Body, span: span, provider: t.provider, config: t.config}
+		return resp, nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if err != nil {
+		span.End(err)
+		return resp, nil
+	}
+	if parsed, err := t.provider.parseResponse(respBody); err == nil {
+		if !t.config.CapturePayloads {
+			parsed.Messages = nil
+		}
+		span.RecordResponse(parsed)
+	}
+	span.End(nil)
+	return resp, nil
+}
+
+// maxStreamLine is the longest line of a streamed response that is parsed.
+const maxStreamLine = 1024 * 1024
+
+// streamState is the response built up from the events of a streamed response.
+type streamState struct {
+	Response
+	role    string
+	content strings.Builder
+}
+
+// streamBody parses the server-sent events of a streamed response as the caller reads
+// them, and records the response and ends the span when the body is read to the end or
+// closed.
+type streamBody struct {
+	io.ReadCloser
+	span     *Span
+	provider provider
+	config   *config
+
+	line []byte
+	// skipping is set while the rest of a line longer than maxStreamLine is skipped.
+	skipping bool
+	stream   streamState
+	once     sync.Once
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.consume(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+// consume parses the complete lines in data, keeping the last partial line.  Lines
+// longer than maxStreamLine are skipped whole, rather than parsing part of them.
+func (b *streamBody) consume(data []byte) {
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			if b.skipping || len(b.line)+len(data) > maxStreamLine {
+				b.line = b.line[:0]
+				b.skipping = true
+			} else {
+				b.line = append(b.line, data...)
+			}
+			return
+		}
+		line := append(b.line, data[:i]...)
+		b.line = b.line[:0]
+		data = data[i+1:]
+		if b.skipping || len(line) > maxStreamLine {
+			b.skipping = false
+			continue
+		}
+
+		payload, ok := bytes.CutPrefix(bytes.TrimRight(line, "\r"), []byte("data:"))
+		if !ok {
+			continue
+		}
+		payload = bytes.TrimSpace(payload)
+		if len(payload) > 0 && payload[0] == '{' {
+			b.provider.parseStreamEvent(payload, &b.stream)
+		}
+	}
+}
+
+// finish records the response and ends the span, once.
+func (b *streamBody) finish() {
+	b.once.Do(func() {
+		resp := b.stream.Response
+		if b.config.CapturePayloads && b.stream.content.Len() > 0 {
+			resp.Messages = []Message{{Role: b.stream.role, Content: b.stream.content.String()}}
+		}
+		if resp.Model != "" {
+			b.span.RecordResponse(resp)
+		}
+		b.span.End(nil)
+	})
+}
+
+// content parses message content that is either a string, or a list of content blocks
+// of which only the text is kept.
+func content(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return string(raw)
+	}
+	var texts []string
+	for _, block := range blocks {
+		if block.Text != "" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+type openAIProvider struct{}
+
+func (openAIProvider) system() string { return SystemOpenAI }
+
+func (openAIProvider) matches(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/chat/completions")
+}
+
+func (openAIProvider) parseRequest(body []byte) (Request, error) {
+	var r struct {
+		Model               string        `json:"model"`
+		Messages            []chatMessage `json:"messages"`
+		MaxTokens           int           `json:"max_tokens"`
+		MaxCompletionTokens int           `json:"max_completion_tokens"`
+		Temperature         *float64      `json:"temperature"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return Request{}, err
+	}
+	req := Request{
+		Model:       r.Model,
+		MaxTokens:   max(r.MaxTokens, r.MaxCompletionTokens),
+		Temperature: r.Temperature,
+	}
+	for _, m := range r.Messages {
+		req.Messages = append(req.Messages, Message{Role: m.Role, Content: content(m.Content)})
+	}
+	return req, nil
+}
+
+func (openAIProvider) parseResponse(body []byte) (Response, error) {
+	var r struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Choices []struct {
+			FinishReason string      `json:"finish_reason"`
+			Message      chatMessage `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return Response{}, err
+	}
+	resp := Response{
+		ID:           r.ID,
+		Model:        r.Model,
+		InputTokens:  r.Usage.PromptTokens,
+		OutputTokens: r.Usage.CompletionTokens,
+	}
+	for _, choice := range r.Choices {
+		resp.FinishReasons = append(resp.FinishReasons, choice.FinishReason)
+		resp.Messages = append(resp.Messages, Message{Role: choice.Message.Role, Content: content(choice.Message.Content)})
+	}
+	return resp, nil
+}
+
+func (openAIProvider) parseStreamEvent(data []byte, stream *streamState) {
+	var r struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Index int `json:"index"`
+			Delta struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"delta"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return
+	}
+	if r.ID != "" {
+		stream.ID = r.ID
+	}
+	if r.Model != "" {
+		stream.Model = r.Model
+	}
+	for _, choice := range r.Choices {
+		// Only the content of the first choice is kept.
+		if choice.Index == 0 {
+			if choice.Delta.Role != "" {
+				stream.role = choice.Delta.Role
+			}
+			stream.content.WriteString(choice.Delta.Content)
+		}
+		if choice.FinishReason != "" {
+			stream.FinishReasons = append(stream.FinishReasons, choice.FinishReason)
+		}
+	}
+	// Usage is only sent, in the last event, if the request sets
+	// stream_options.include_usage.
+	if r.Usage != nil {
+		stream.InputTokens = r.Usage.PromptTokens
+		stream.OutputTokens = r.Usage.CompletionTokens
+	}
+}
+
+type anthropicProvider struct{}
+
+func (anthropicProvider) system() string { return SystemAnthropic }
+
+func (anthropicProvider) matches(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/messages")
+}
+
+func (anthropicProvider) parseRequest(body []byte) (Request, error) {
+	var r struct {
+		Model       string          `json:"model"`
+		System      json.RawMessage `json:"system"`
+		Messages    []chatMessage   `json:"messages"`
+		MaxTokens   int             `json:"max_tokens"`
+		Temperature *float64        `json:"temperature"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return Request{}, err
+	}
+	req := Request{
+		Model:       r.Model,
+		MaxTokens:   r.MaxTokens,
+		Temperature: r.Temperature,
+	}
+	if len(r.System) > 0 {
+		req.Messages = append(req.Messages, Message{Role: "system", Content: content(r.System)})
+	}
+	for _, m := range r.Messages {
+		req.Messages = append(req.Messages, Message{Role: m.Role, Content: content(m.Content)})
+	}
+	return req, nil
+}
+
+func (anthropicProvider) parseResponse(body []byte) (Response, error) {
+	var r struct {
+		ID         string          `json:"id"`
+		Model      string          `json:"model"`
+		Role       string          `json:"role"`
+		StopReason string          `json:"stop_reason"`
+		Content    json.RawMessage `json:"content"`
+		Usage      struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return Response{}, err
+	}
+	resp := Response{
+		ID:           r.ID,
+		Model:        r.Model,
+		InputTokens:  r.Usage.InputTokens,
+		OutputTokens: r.Usage.OutputTokens,
+		Messages:     []Message{{Role: r.Role, Content: content(r.Content)}},
+	}
+	if r.StopReason != "" {
+		resp.FinishReasons = []string{r.StopReason}
+	}
+	return resp, nil
+}
+
+func (anthropicProvider) parseStreamEvent(data []byte, stream *streamState) {
+	var r struct {
+		Type    string `json:"type"`
+		Message struct {
+			ID    string `json:"id"`
+			Model string `json:"model"`
+			Role  string `json:"role"`
+			Usage struct {
+				InputTokens  int `json:"input_tokens"`
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+		Delta struct {
+			Text       string `json:"text"`
+			StopReason string `json:"stop_reason"`
+		} `json:"delta"`
+		Usage struct {
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return
+	}
+	switch r.Type {
+	case "message_start":
+		stream.ID = r.Message.ID
+		stream.Model = r.Message.Model
+		stream.role = r.Message.Role
+		stream.InputTokens = r.Message.Usage.InputTokens
+		stream.OutputTokens = r.Message.Usage.OutputTokens
+	case "content_block_delta":
+		stream.content.WriteString(r.Delta.Text)
+	case "message_delta":
+		if r.Delta.StopReason != "" {
+			stream.FinishReasons = []string{r.Delta.StopReason}
+		}
+		// The output tokens of message_delta are cumulative.
+		stream.OutputTokens = r.Usage.OutputTokens
+	}
+}
@@ -0,0 +1,156 @@
+package logfirellm
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jerechua/logfire-go/logfiretest"
+)
+
+// roundTripFunc is an http.RoundTripper answering with a function, standing in for the
+// model's API.
+type roundTripFunc func(req *http.Request) *http.Response
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req), nil
+}
+
+// respond returns a base transport answering every request with body.
+func respond(contentType, body string) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {contentType}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}
+	})
+}
+
+// chat posts body through the transport and reads the whole response.
+func chat(t *testing.T, transport http.RoundTripper, url, body string) string {
+	t.Helper()
+	resp, err := (&http.Client{Transport: transport}).Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading the response failed: %v", err)
+	}
+	return string(got)
+}
+
+const openAIRequest = `{"model": "gpt-4o", "messages": [{"role": "user", "content": "my card is 4242"}], "max_tokens": 100}`
+
+const openAIResponse = `{
+	"id": "chatcmpl-1",
+	"model": "gpt-4o-2024-08-06",
+	"choices": [{"finish_reason": "stop", "message": {"role": "assistant", "content": "noted"}}],
+	"usage": {"prompt_tokens": 12, "completion_tokens": 3}
+}`
+
+func recordOne(t *testing.T, rec *logfiretest.Recorder) logfiretest.Span {
+	t.Helper()
+	spans := rec.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want the chat span", len(spans))
+	}
+	return spans[0]
+}
+
+func TestOpenAITransport(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	body := chat(t, OpenAITransport(respond("application/json", openAIResponse)), "https://api.openai.com/v1/chat/completions", openAIRequest)
+	if body != openAIResponse {
+		t.Errorf("the caller got %q, want the response untouched", body)
+	}
+
+	span := recordOne(t, rec)
+	if span.Name != "chat gpt-4o" {
+		t.Errorf("span name = %q, want chat gpt-4o", span.Name)
+	}
+	for key, want := range map[string]any{
+		"gen_ai.system":              SystemOpenAI,
+		"gen_ai.request.max_tokens":  int64(100),
+		"gen_ai.response.model":      "gpt-4o-2024-08-06",
+		"gen_ai.usage.input_tokens":  int64(12),
+		"gen_ai.usage.output_tokens": int64(3),
+	} {
+		if got := span.Attributes[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	if len(span.Events) != 0 {
+		t.Errorf("recorded payload events %+v without WithPayloads", span.Events)
+	}
+}
+
+func TestWithPayloads(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	chat(t, OpenAITransport(respond("application/json", openAIResponse), WithPayloads()), "https://api.openai.com/v1/chat/completions", openAIRequest)
+
+	span := recordOne(t, rec)
+	var names []string
+	for _, e := range span.Events {
+		names = append(names, e.Name)
+	}
+	if strings.Join(names, ",") != "gen_ai.user.message,gen_ai.choice" {
+		t.Errorf("recorded events %v, want the user message and the choice", names)
+	}
+}
+
+func TestTransportPassesOtherRequestsThrough(t *testing.T) {
+	rec := logfiretest.NewRecorder(t)
+	chat(t, OpenAITransport(respond("application/json", `{"data": []}`)), "https://api.openai.com/v1/models", `{}`)
+	if spans := rec.Spans(); len(spans) != 0 {
+		t.Errorf("recorded %+v for a request that isn't a chat, want nothing", spans)
+	}
+}
+
+func TestAnthropicTransportStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`event: message_start`,
+		`data: {"type": "message_start", "message": {"id": "msg_1", "model": "claude-x", "role": "assistant", "usage": {"input_tokens": 20, "output_tokens": 1}}}`,
+		``,
+		`data: {"type": "content_block_delta", "delta": {"text": "Hel"}}`,
+		`data: {"type": "content_block_delta", "delta": {"text": "lo"}}`,
+		`data: {"type": "message_delta", "delta": {"stop_reason": "end_turn"}, "usage": {"output_tokens": 5}}`,
+		``,
+	}, "\n")
+
+	rec := logfiretest.NewRecorder(t)
+	transport := AnthropicTransport(respond("text/event-stream", stream), WithPayloads())
+	if body := chat(t, transport, "https://api.anthropic.com/v1/messages", `{"model": "claude-x", "max_tokens": 10, "messages": [{"role": "user", "content": "hi"}]}`); body != stream {
+		t.Errorf("the caller got %q, want the stream untouched", body)
+	}
+
+	span := recordOne(t, rec)
+	if span.Attributes["gen_ai.response.id"] != "msg_1" || span.Attributes["gen_ai.usage.output_tokens"] != int64(5) {
+		t.Errorf("span attributes = %v, want the id and cumulative output tokens", span.Attributes)
+	}
+	choice := span.Events[len(span.Events)-1]
+	if choice.Name != "gen_ai.choice" || choice.Attributes["content"] != "Hello" {
+		t.Errorf("last event = %+v, want the streamed text as the choice", choice)
+	}
+}
+
+func TestStreamBodySkipsLongLines(t *testing.T) {
+	b := &streamBody{provider: openAIProvider{}}
+	// The start of a line that turns out to be too long is valid JSON by itself, and
+	// must not be parsed.
+	b.consume([]byte(`data: {"model": "corrupt"}`))
+	b.consume([]byte(strings.Repeat("x", maxStreamLine)))
+	b.consume([]byte("\n"))
+	if b.stream.Model != "" {
+		t.Errorf("parsed model %q from part of a long line, want it skipped", b.stream.Model)
+	}
+
+	b.consume([]byte("data: {\"model\": \"gpt-4o\"}\n"))
+	if b.stream.Model != "gpt-4o" {
+		t.Errorf("model = %q after the long line, want the next line parsed", b.stream.Model)
+	}
+}
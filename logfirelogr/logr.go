@@ -0,0 +1,130 @@
+// Package logfirelogr sends logs written with logr, the logging API of client-go and
+// controller-runtime, to Logfire.
+//
+// Use it as the logger of a Kubernetes operator, so its logs are sent to Logfire:
+//
+//	ctrl.SetLogger(logfirelogr.New())
+//
+// logr loggers don't carry a context, so logs are correlated with a trace by binding
+// the logger to the context of a span with WithContext.
+package logfirelogr
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// traceVerbosity is the verbosity from which logs are sent as traces rather than
+// debug logs, following the Kubernetes logging conventions.
+const traceVerbosity = 5
+
+// sink is a logr.LogSink that sends logs to Logfire in the span of its context.
+type sink struct {
+	ctx   context.Context
+	name  string
+	attrs []attribute.KeyValue
+}
+
+var _ logr.LogSink = (*sink)(nil)
+
+// New returns a logr.Logger that sends logs to Logfire.  Verbosity 0 is logged with
+// level Info, verbosities below 5 with level Debug, and higher verbosities with level
+// Trace.
+func New() logr.Logger {
+	return logr.New(&sink{ctx: context.Background()})
+}
+
+// WithContext returns logger bound to ctx, so its logs are sent in the span of ctx.
+// Loggers that weren't created by New are returned as they are.
+//
+//	ctx, span := tracer.Start(ctx, "reconcile")
+//	logger := logfirelogr.WithContext(ctx, log.FromContext(ctx))
+func WithContext(ctx context.Context, logger logr.Logger) logr.Logger {
+	s, ok := logger.GetSink().(*sink)
+	if !ok {
+		return logger
+	}
+	c := *s
+	c.ctx = ctx
+	return logger.WithSink(&c)
+}
+
+func (s *sink) Init(logr.RuntimeInfo) {}
+
+// Enabled always returns true, logs below the minimum level are filtered by Logfire.
+func (s *sink) Enabled(level int) bool {
+	return true
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...any) {
+	severity := logfire.LevelInfo
+	switch {
+	case level >= traceVerbosity:
+		severity = logfire.LevelTrace
+	case level > 0:
+		severity = logfire.LevelDebug
+	}
+	logfire.FromContext(s.ctx).Log(severity, msg, logfire.WithAttributes(s.attributes(keysAndValues)...))
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...any) {
+	attrs := s.attributes(keysAndValues)
+	if err != nil {
+		attrs = append(attrs,
+			attribute.String("exception.type", fmt.Sprintf("%T", err)),
+			attribute.String("exception.message", err.Error()),
+		)
+	}
+	logfire.FromContext(s.ctx).Error(msg, logfire.WithAttributes(attrs...))
+}
+
+func (s *sink) WithValues(keysAndValues ...any) logr.LogSink {
+	c := *s
+	c.attrs = append(s.attrs[:len(s.attrs):len(s.attrs)], keyValueAttributes(keysAndValues)...)
+	return &c
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	c := *s
+	if c.name == "" {
+		c.name = name
+	} else {
+		c.name += "/" + name
+	}
+	return &c
+}
+
+// attributes returns the attributes of a log, including those of the logger.
+func (s *sink) attributes(keysAndValues []any) []attribute.KeyValue {
+	attrs := append(s.attrs[:len(s.attrs):len(s.attrs)], keyValueAttributes(keysAndValues)...)
+	if s.name != "" {
+		attrs = append(attrs, attribute.String("logger.name", s.name))
+	}
+	return attrs
+}
+
+// keyValueAttributes converts logr key value pairs to attributes.  A key without a
+// value is recorded with the value "<missing>".
+func keyValueAttributes(keysAndValues []any) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		if i+1 >= len(keysAndValues) {
+			attrs = append(attrs, attribute.String(key, "<missing>"))
+			break
+		}
+		switch value := keysAndValues[i+1].(type) {
+		case error:
+			attrs = append(attrs, attribute.String(key, value.Error()))
+		case fmt.Stringer:
+			attrs = append(attrs, logfire.StringerAttr(key, value))
+		default:
+			attrs = append(attrs, logfire.Flatten(key, value)...)
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,56 @@
+// Package logfireopenfeature records OpenFeature flag evaluations on the active span.
+package logfireopenfeature
+
+import (
+	"context"
+
+	"github.com/jerechua/logfire-go"
+	"github.com/open-feature/go-sdk/openfeature"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Hook is an OpenFeature hook that records every flag evaluation on the span in the
+// evaluation context, using logfire.LogFeatureFlag.
+//
+//	openfeature.AddHooks(logfireopenfeature.NewHook())
+type Hook struct {
+	openfeature.UnimplementedHook
+}
+
+var _ openfeature.Hook = (*Hook)(nil)
+
+// NewHook creates a new Hook.
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+// After records the evaluated variant and the reason it was chosen.
+func (h *Hook) After(ctx context.Context, hookContext openfeature.HookContext, details openfeature.InterfaceEvaluationDetails, hints openfeature.HookHints) error {
+	attrs := evaluationAttributes(hookContext)
+	if details.Reason != "" {
+		attrs = append(attrs, attribute.String("feature_flag.reason", string(details.Reason)))
+	}
+	logfire.LogFeatureFlag(ctx, hookContext.FlagKey(), details.Variant, attrs...)
+	return nil
+}
+
+// Error records that the flag could not be evaluated, and the default value was used.
+func (h *Hook) Error(ctx context.Context, hookContext openfeature.HookContext, err error, hints openfeature.HookHints) {
+	attrs := evaluationAttributes(hookContext)
+	attrs = append(attrs,
+		attribute.String("feature_flag.reason", string(openfeature.ErrorReason)),
+		attribute.String("error.message", err.Error()),
+	)
+	logfire.LogFeatureFlag(ctx, hookContext.FlagKey(), "", attrs...)
+}
+
+func evaluationAttributes(hookContext openfeature.HookContext) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if name := hookContext.ProviderMetadata().Name; name != "" {
+		attrs = append(attrs, attribute.String("feature_flag.provider_name", name))
+	}
+	if key := hookContext.EvaluationContext().TargetingKey(); key != "" {
+		attrs = append(attrs, attribute.String("feature_flag.targeting_key", key))
+	}
+	return attrs
+}
@@ -0,0 +1,67 @@
+// Package logfireoperator instruments the reconcile loops of Kubernetes operators built
+// with controller-runtime, so the behavior of an operator can be traced per object.
+package logfireoperator
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-logr/logr"
+	"github.com/jerechua/logfire-go"
+	"github.com/jerechua/logfire-go/logfirelogr"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// reconciler records every reconcile of the wrapped reconciler in a span.
+type reconciler struct {
+	kind string
+	next reconcile.Reconciler
+}
+
+// Wrap returns a reconcile.Reconciler that records every reconcile of r in a span,
+// with the kind, namespace and name of the object.  Requeues are recorded as events,
+// and errors on the span.  The logger in the context is bound to the span, if it
+// was created by logfirelogr, so logs of the reconcile are part of its trace.
+//
+//	err := ctrl.NewControllerManagedBy(mgr).
+//		For(&appsv1.Deployment{}).
+//		Complete(logfireoperator.Wrap("Deployment", r))
+func Wrap(kind string, r reconcile.Reconciler) reconcile.Reconciler {
+	return &reconciler{kind: kind, next: r}
+}
+
+func (r *reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	ctx, span := logfire.Tracer().Start(ctx, "reconcile "+r.kind, oteltrace.WithAttributes(
+		attribute.String("k8s.object.kind", r.kind),
+		attribute.String("k8s.namespace.name", req.Namespace),
+		attribute.String("k8s.object.name", req.Name),
+	))
+	defer span.End()
+
+	if logger, err := logr.FromContext(ctx); err == nil {
+		ctx = logr.NewContext(ctx, logfirelogr.WithContext(ctx, logger))
+	}
+
+	result, err := r.next.Reconcile(ctx, req)
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+		span.SetAttributes(
+			attribute.String("reconcile.result", "error"),
+			attribute.Bool("reconcile.terminal", errors.Is(err, reconcile.TerminalError(nil))),
+		)
+	case result.RequeueAfter > 0:
+		span.AddEvent("requeue", oteltrace.WithAttributes(logfire.Attr("reconcile.requeue_after_ms", result.RequeueAfter)))
+		span.SetAttributes(attribute.String("reconcile.result", "requeue_after"))
+	case result.Requeue:
+		span.AddEvent("requeue")
+		span.SetAttributes(attribute.String("reconcile.result", "requeue"))
+	default:
+		span.SetAttributes(attribute.String("reconcile.result", "success"))
+	}
+	return result, err
+}
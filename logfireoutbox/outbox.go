@@ -0,0 +1,91 @@
+// Package logfireoutbox carries trace context across a transactional outbox, so that
+// an event written in one trace and published later by a relay stays in that trace.
+package logfireoutbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Carrier is the trace context of an outbox record.  It is stored as JSON by
+// database/sql, so it can be written to a column of the outbox table.
+type Carrier map[string]string
+
+var (
+	_ propagation.TextMapCarrier = Carrier(nil)
+	_ driver.Valuer              = Carrier(nil)
+)
+
+// Get returns the value of key.
+func (c Carrier) Get(key string) string { return c[key] }
+
+// Set sets the value of key.
+func (c Carrier) Set(key, value string) { c[key] = value }
+
+// Keys returns every key.
+func (c Carrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Value encodes the carrier as JSON.
+func (c Carrier) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan decodes the carrier from JSON.
+func (c *Carrier) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	}
+	return fmt.Errorf("logfireoutbox: cannot scan %T into Carrier", src)
+}
+
+// Capture returns the trace context of ctx, to be written with the outbox record in the
+// same transaction as the change it describes.
+//
+//	_, err := tx.ExecContext(ctx, "INSERT INTO outbox (payload, trace) VALUES ($1, $2)",
+//		payload, logfireoutbox.Capture(ctx))
+func Capture(ctx context.Context) Carrier {
+	c := Carrier{}
+	otel.GetTextMapPropagator().Inject(ctx, c)
+	return c
+}
+
+// Restore returns ctx with the trace context captured in c, so spans started with it
+// continue the trace that wrote the record.
+func Restore(ctx context.Context, c Carrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, c)
+}
+
+// Publish calls fn, which should publish the record, in a span named name that
+// continues the trace captured in c.  The span ends with the error returned by fn.
+//
+//	err := logfireoutbox.Publish(ctx, record.Trace, "publish order.created", func(ctx context.Context) error {
+//		return producer.Send(ctx, record.Payload)
+//	})
+func Publish(ctx context.Context, c Carrier, name string, fn func(ctx context.Context) error) error {
+	logger := logfire.NewSpanLogger(Restore(ctx, c), name)
+	err := fn(logger.Context())
+	logger.CloseWithOptions(logfire.WithError(err))
+	return err
+}
@@ -0,0 +1,29 @@
+// Package logfireprometheus exposes the metrics recorded through Logfire to
+// Prometheus, so they can be scraped locally while also being pushed to Logfire.
+package logfireprometheus
+
+import (
+	"github.com/jerechua/logfire-go"
+	"github.com/prometheus/client_golang/prometheus"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+)
+
+// New returns a logfire.Option that registers every metric recorded through Logfire
+// with registerer, or the default Prometheus registerer if it is nil.
+//
+//	promOpt, err := logfireprometheus.New(nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	closer, err := logfire.Initialize(ctx, promOpt)
+//	http.Handle("/metrics", promhttp.Handler())
+func New(registerer prometheus.Registerer) (logfire.Option, error) {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registerer))
+	if err != nil {
+		return nil, err
+	}
+	return logfire.WithMetricReader(exporter), nil
+}
@@ -0,0 +1,145 @@
+// Package logfireretry retries operations, recording every attempt in a Logfire span,
+// so flaky dependencies can be quantified.
+package logfireretry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Outcomes of a retried operation, recorded as the logfire.retry.outcome attribute.
+const (
+	// OutcomeSuccess is an operation that eventually succeeded.
+	OutcomeSuccess = "success"
+	// OutcomeExhausted is an operation that failed every attempt allowed.
+	OutcomeExhausted = "exhausted"
+	// OutcomePermanent is an operation that failed with an error that is not retried.
+	OutcomePermanent = "permanent"
+	// OutcomeCancelled is an operation whose context was done while waiting to retry.
+	OutcomeCancelled = "cancelled"
+)
+
+// Policy decides how a failed operation is retried.
+type Policy struct {
+	// Name is the name of the span of the operation.  Defaults to "retry".
+	Name string
+	// MaxAttempts is the maximum number of attempts, including the first.
+	MaxAttempts int
+	// Backoff returns how long to wait before the given attempt, starting from 2.
+	// Defaults to not waiting.
+	Backoff func(attempt int) time.Duration
+	// ShouldRetry reports whether the operation should be retried after an attempt
+	// failed with err.  Defaults to retrying any error except context cancellation.
+	ShouldRetry func(err error) bool
+}
+
+// ExponentialBackoff returns a Backoff function that doubles the wait after every
+// attempt, starting from base.
+func ExponentialBackoff(base time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		return base << (attempt - 2)
+	}
+}
+
+func defaultShouldRetry(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Do calls fn until it succeeds, or until the policy stops retrying, and returns the
+// error of the last attempt.  The operation is recorded in a parent span with a child
+// span for every attempt, recording the attempt number and the backoff before it.  The
+// parent span records the number of attempts, the total backoff and the outcome.
+//
+//	err := logfireretry.Do(ctx, logfireretry.Policy{
+//		Name:        "fetch prices",
+//		MaxAttempts: 5,
+//		Backoff:     logfireretry.ExponentialBackoff(100 * time.Millisecond),
+//	}, func(ctx context.Context) error {
+//		return fetchPrices(ctx)
+//	})
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	if policy.Name == "" {
+		policy.Name = "retry"
+	}
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Backoff == nil {
+		policy.Backoff = func(int) time.Duration { return 0 }
+	}
+	if policy.ShouldRetry == nil {
+		policy.ShouldRetry = defaultShouldRetry
+	}
+
+	logger := logfire.NewSpanLogger(ctx, policy.Name, logfire.WithAttributes(
+		attribute.Int("logfire.retry.max_attempts", policy.MaxAttempts),
+	))
+	ctx = logger.Context()
+
+	var (
+		err          error
+		backoff      time.Duration
+		totalBackoff time.Duration
+		number       int
+		outcome      string
+	)
+	for number = 1; ; number++ {
+		err = attempt(ctx, policy.Name, number, backoff, fn)
+		if err == nil {
+			outcome = OutcomeSuccess
+			break
+		}
+		if !policy.ShouldRetry(err) {
+			outcome = OutcomePermanent
+			break
+		}
+		if number >= policy.MaxAttempts {
+			outcome = OutcomeExhausted
+			break
+		}
+
+		backoff = policy.Backoff(number + 1)
+		totalBackoff += backoff
+		if sleep(ctx, backoff) != nil {
+			outcome = OutcomeCancelled
+			break
+		}
+	}
+
+	logger.SetAttributes(
+		attribute.Int("logfire.retry.attempts", number),
+		logfire.Attr("logfire.retry.total_backoff_ms", totalBackoff),
+		attribute.String("logfire.retry.outcome", outcome),
+	)
+	logger.CloseWithOptions(logfire.WithError(err))
+	return err
+}
+
+// attempt calls fn in the span of one attempt.
+func attempt(ctx context.Context, name string, number int, backoff time.Duration, fn func(ctx context.Context) error) error {
+	logger := logfire.NewSpanLogger(ctx, name+" attempt", logfire.WithAttributes(
+		attribute.Int("logfire.retry.attempt", number),
+		logfire.Attr("logfire.retry.backoff_ms", backoff),
+	))
+	err := fn(logger.Context())
+	logger.CloseWithOptions(logfire.WithError(err))
+	return err
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,189 @@
+// Package logfiresentry forwards exceptions recorded in Logfire to Sentry, for teams
+// migrating between the two.
+//
+// Exceptions recorded on spans, and Error and Fatal logs, are sent to Sentry as well as
+// Logfire, tagged with the trace ID so the two can be cross referenced:
+//
+//	exporter, err := logfiresentry.NewExporter(os.Getenv("SENTRY_DSN"))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	closer, err := logfire.Initialize(ctx, logfire.WithAdditionalExporter(exporter))
+package logfiresentry
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// defaultFlushTimeout is how long Shutdown waits for events to be sent, if its context
+// has no deadline.
+const defaultFlushTimeout = 5 * time.Second
+
+// Exporter is a SpanExporter that sends the exceptions in spans to Sentry.
+type Exporter struct {
+	client *sentry.Client
+}
+
+var _ sdktrace.SpanExporter = (*Exporter)(nil)
+
+// NewExporter creates an Exporter that sends exceptions to the Sentry project of dsn.
+func NewExporter(dsn string) (*Exporter, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: dsn})
+	if err != nil {
+		return nil, err
+	}
+	return NewExporterWithClient(client), nil
+}
+
+// NewExporterWithClient creates an Exporter that sends exceptions with client, e.g. to
+// share the client used by the rest of the service.
+func NewExporterWithClient(client *sentry.Client) *Exporter {
+	return &Exporter{client: client}
+}
+
+// ExportSpans sends an event to Sentry for every exception recorded on the spans, and
+// for every Error or Fatal log.
+func (e *Exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, s := range spans {
+		level, isLog := logLevel(s)
+		sentryLevel := sentry.LevelError
+		if level >= logfire.LevelFatal {
+			sentryLevel = sentry.LevelFatal
+		}
+
+		var sent bool
+		for _, event := range s.Events() {
+			if event.Name == semconv.ExceptionEventName {
+				e.send(s, sentryLevel, event.Time, event.Attributes)
+				sent = true
+			}
+		}
+		if !sent && isLog && level >= logfire.LevelError {
+			e.send(s, sentryLevel, s.StartTime(), s.Attributes())
+		}
+	}
+	return nil
+}
+
+// Shutdown waits for events to be sent, until ctx is done.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	timeout := defaultFlushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	e.client.Flush(timeout)
+	return nil
+}
+
+// send sends an event for the exception described by attrs, or for the span if attrs
+// don't describe an exception.
+func (e *Exporter) send(s sdktrace.ReadOnlySpan, level sentry.Level, t time.Time, attrs []attribute.KeyValue) {
+	event := sentry.NewEvent()
+	event.Level = level
+	event.Timestamp = t
+	event.Message = s.Name()
+	traceID, spanID := s.SpanContext().TraceID().String(), s.SpanContext().SpanID().String()
+	event.Tags["trace_id"] = traceID
+	event.Tags["span_id"] = spanID
+	event.Contexts["trace"] = sentry.Context{"trace_id": traceID, "span_id": spanID}
+
+	exception := sentry.Exception{}
+	for _, kv := range attrs {
+		switch kv.Key {
+		case semconv.ExceptionTypeKey:
+			exception.Type = kv.Value.AsString()
+		case semconv.ExceptionMessageKey:
+			exception.Value = kv.Value.AsString()
+		case semconv.ExceptionStacktraceKey:
+			exception.Stacktrace = parseStack(kv.Value.AsString())
+		}
+	}
+	if exception.Type != "" || exception.Value != "" {
+		event.Exception = []sentry.Exception{exception}
+	}
+	e.client.CaptureEvent(event, nil, nil)
+}
+
+// logLevel returns the level of a log, and whether the span is a log.
+func logLevel(s sdktrace.ReadOnlySpan) (logfire.Level, bool) {
+	var isLog bool
+	var level logfire.Level
+	for _, kv := range s.Attributes() {
+		switch kv.Key {
+		case "logfire.span_type":
+			isLog = kv.Value.AsString() == "log"
+		case "logfire.level_num":
+			level = logfire.Level(kv.Value.AsInt64())
+		}
+	}
+	return level, isLog
+}
+
+// parseStack parses a stack in the format of runtime/debug.Stack, e.g.
+//
+//	goroutine 1 [running]:
+//	main.main()
+//		/app/main.go:8 +0x1d
+func parseStack(stack string) *sentry.Stacktrace {
+	lines := strings.Split(stack, "\n")
+	var frames []sentry.Frame
+	for i := 0; i+1 < len(lines); i++ {
+		location, ok := strings.CutPrefix(lines[i+1], "\t")
+		if !ok || strings.HasPrefix(lines[i], "\t") {
+			continue
+		}
+		function := strings.TrimPrefix(lines[i], "created by ")
+		function, _, _ = strings.Cut(function, " in goroutine ")
+		if j := strings.LastIndex(function, "("); j > 0 && strings.HasSuffix(function, ")") {
+			function = function[:j]
+		}
+		location, _, _ = strings.Cut(location, " +0x")
+		file, line, _ := strings.Cut(location, ":")
+		lineno, _ := strconv.Atoi(line)
+
+		module, name := splitFunction(function)
+		frames = append(frames, sentry.Frame{
+			Function: name,
+			Module:   module,
+			AbsPath:  file,
+			Lineno:   lineno,
+			InApp:    isInApp(module),
+		})
+		i++
+	}
+	if len(frames) == 0 {
+		return nil
+	}
+	// Sentry expects the oldest frame first.
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return &sentry.Stacktrace{Frames: frames}
+}
+
+// splitFunction splits a function, e.g. "github.com/org/repo/pkg.(*T).Method", into
+// its package and name.
+func splitFunction(function string) (module, name string) {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return "", function
+	}
+	return function[:slash+1+dot], function[slash+1+dot+1:]
+}
+
+// isInApp reports whether a package is part of the application, rather than the
+// standard library.
+func isInApp(module string) bool {
+	first, _, _ := strings.Cut(module, "/")
+	return module == "main" || strings.Contains(first, ".")
+}
@@ -0,0 +1,145 @@
+package logfiresql
+
+import (
+	"strings"
+)
+
+// SanitizeSQL replaces the literals in a SQL statement with "?", so that statements
+// can be recorded without the data in them:
+//
+//	SELECT * FROM users WHERE email = 'a@b.com' AND age > 30
+//	SELECT * FROM users WHERE email = ? AND age > ?
+//
+// Identifiers, placeholders, keywords and comments are kept.  How quotes are read
+// depends on the database system set with WithSystem, other options are ignored:
+//
+//   - Double quoted strings are identifiers in "postgresql", and are kept.  For other
+//     systems they may be string literals, as in MySQL, so they are replaced.
+//   - Backslashes escape quotes in string literals only in "mysql" and "mariadb".
+func SanitizeSQL(query string, opts ...Option) string {
+	config := &config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return sanitizeSQL(query, config.System)
+}
+
+// sanitizeSQL replaces the literals in query, read in the dialect of system.
+func sanitizeSQL(query, system string) string {
+	backslashEscapes := system == "mysql" || system == "mariadb"
+	quotedIdentifiers := system == "postgresql"
+
+	var b strings.Builder
+	b.Grow(len(query))
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || (c == '"' && !quotedIdentifiers):
+			// String literal, with a doubled quote as an escaped quote.  Postgres E'...'
+			// strings take backslash escapes too.
+			escapes := backslashEscapes || (c == '\'' && isEscapeStringPrefix(query[:i]))
+			i++
+			for i < len(query) {
+				if escapes && query[i] == '\\' && i+1 < len(query) {
+					i += 2
+					continue
+				}
+				if query[i] == c {
+					if i+1 < len(query) && query[i+1] == c {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			b.WriteByte('?')
+		case c == '"' || c == '`':
+			// Quoted identifier.
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 2
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+4])
+			i += end + 4
+		case c == '$':
+			// Either a $1 placeholder, or a Postgres $tag$ dollar quoted string.
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			if j < len(query) && query[j] == '$' && !isDigit(query[i+1]) {
+				tag := query[i : j+1]
+				end := strings.Index(query[j+1:], tag)
+				if end < 0 {
+					b.WriteByte('?')
+					return b.String()
+				}
+				b.WriteByte('?')
+				i = j + 1 + end + len(tag)
+				continue
+			}
+			b.WriteString(query[i:j])
+			i = j
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			j := i
+			for j < len(query) && (isDigit(query[j]) || query[j] == '.' || query[j] == 'e' || query[j] == 'E' ||
+				query[j] == 'x' || query[j] == 'X' || isHexDigit(query[j]) ||
+				((query[j] == '+' || query[j] == '-') && j > i && (query[j-1] == 'e' || query[j-1] == 'E'))) {
+				j++
+			}
+			b.WriteByte('?')
+			i = j
+		case isIdentChar(c) || c == ':' || c == '@':
+			// Identifiers, keywords and named placeholders, which may contain digits.
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			b.WriteString(query[i:j])
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// isEscapeStringPrefix reports whether before ends with the E of a Postgres escape
+// string constant.
+func isEscapeStringPrefix(before string) bool {
+	n := len(before)
+	return n > 0 && (before[n-1] == 'E' || before[n-1] == 'e') && (n == 1 || !isIdentChar(before[n-2]))
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
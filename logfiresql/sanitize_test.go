@@ -0,0 +1,101 @@
+package logfiresql
+
+import "testing"
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		system string
+		query  string
+		want   string
+	}{
+		{
+			name:  "literals",
+			query: "SELECT * FROM users WHERE email = 'a@b.com' AND age > 30",
+			want:  "SELECT * FROM users WHERE email = ? AND age > ?",
+		},
+		{
+			name:  "numbers",
+			query: "SELECT 1.5, .5, 1e-3, 0xFF",
+			want:  "SELECT ?, ?, ?, ?",
+		},
+		{
+			name:  "doubled quote",
+			query: "SELECT 'it''s', 'secret'",
+			want:  "SELECT ?, ?",
+		},
+		{
+			name:  "backslash is not an escape",
+			query: `SELECT 'C:\', 'secret-value'`,
+			want:  "SELECT ?, ?",
+		},
+		{
+			name:   "backslash escape in mysql",
+			system: "mysql",
+			query:  `SELECT 'it\'s', 'secret'`,
+			want:   "SELECT ?, ?",
+		},
+		{
+			name:   "postgres escape string",
+			system: "postgresql",
+			query:  `SELECT E'it\'s', 'C:\'`,
+			want:   "SELECT E?, ?",
+		},
+		{
+			name:   "double quotes are strings in mysql",
+			system: "mysql",
+			query:  `SELECT * FROM users WHERE email = "alice@example.com"`,
+			want:   "SELECT * FROM users WHERE email = ?",
+		},
+		{
+			name:  "double quotes are strings without a system",
+			query: `SELECT * FROM users WHERE email = "alice@example.com"`,
+			want:  "SELECT * FROM users WHERE email = ?",
+		},
+		{
+			name:   "double quotes are identifiers in postgres",
+			system: "postgresql",
+			query:  `SELECT "Email" FROM "Users" WHERE id = 1`,
+			want:   `SELECT "Email" FROM "Users" WHERE id = ?`,
+		},
+		{
+			name:   "backquoted identifiers",
+			system: "mysql",
+			query:  "SELECT `order` FROM t WHERE `key` = 'v'",
+			want:   "SELECT `order` FROM t WHERE `key` = ?",
+		},
+		{
+			name:  "placeholders",
+			query: "SELECT * FROM t WHERE a = $1 AND b = :name AND c = @p1 AND d = ?",
+			want:  "SELECT * FROM t WHERE a = $1 AND b = :name AND c = @p1 AND d = ?",
+		},
+		{
+			name:   "dollar quoted string",
+			system: "postgresql",
+			query:  "SELECT $body$it's secret$body$, $$x$$",
+			want:   "SELECT ?, ?",
+		},
+		{
+			name:  "identifiers with digits",
+			query: "SELECT col1 FROM table2",
+			want:  "SELECT col1 FROM table2",
+		},
+		{
+			name:  "comments",
+			query: "SELECT 1 -- it's 2\n/* 'three' */ FROM t",
+			want:  "SELECT ? -- it's 2\n/* 'three' */ FROM t",
+		},
+		{
+			name:  "unterminated literal",
+			query: "SELECT 'secret",
+			want:  "SELECT ?",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeSQL(tt.query, WithSystem(tt.system)); got != tt.want {
+				t.Errorf("SanitizeSQL(%q) with system %q = %q, want %q", tt.query, tt.system, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Package logfiresql instruments database/sql with Logfire spans.
+//
+// Statements are recorded with their literals removed, see SanitizeSQL, and slow
+// statements can be escalated to warning logs.
+package logfiresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	redacted = "[REDACTED]"
+	// maxArgLength is the longest argument value attached to a slow query log.
+	maxArgLength = 256
+)
+
+// sensitiveArg matches the names of arguments whose value should never be recorded.
+var sensitiveArg = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[_-]?key|auth|credential|private[_-]?key|ssn|card)`)
+
+// config is the config used by DB.
+type config struct {
+	// System is the database system, e.g. "postgresql".
+	System string
+	// SlowQueryThreshold is how long a statement can take before it is logged as slow.
+	SlowQueryThreshold time.Duration
+}
+
+// Option is a function type that modifies the DB config.
+type Option func(*config)
+
+// WithSystem sets the database system recorded on spans, e.g. "postgresql" or "mysql".
+func WithSystem(system string) Option {
+	return func(c *config) {
+		c.System = system
+	}
+}
+
+// WithSlowQueryThreshold logs a Warn log, with the statement and its scrubbed arguments,
+// for every statement that takes at least d.
+func WithSlowQueryThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.SlowQueryThreshold = d
+	}
+}
+
+// DB is a *sql.DB that records every statement in a span.  Only the context aware
+// methods are instrumented.
+type DB struct {
+	*sql.DB
+	config *config
+}
+
+// Wrap wraps db in a DB.
+func Wrap(db *sql.DB, opts ...Option) *DB {
+	config := &config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &DB{DB: db, config: config}
+}
+
+// Open opens a database like sql.Open, and wraps it in a DB.
+func Open(driverName, dataSourceName string, opts ...Option) (*DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(db, opts...), nil
+}
+
+// ExecContext executes a statement in a span.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, s := db.config.start(ctx, query)
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	s.end(err, args)
+	return result, err
+}
+
+// QueryContext executes a query in a span.  The span ends once the query returns, before
+// any rows are read, so its duration doesn't include fetching the rows.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, s := db.config.start(ctx, query)
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	s.end(err, args)
+	return rows, err
+}
+
+// QueryRowContext executes a query that returns at most one row in a span.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, s := db.config.start(ctx, query)
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	s.end(row.Err(), args)
+	return row
+}
+
+// statementSpan is the span of a single statement.
+type statementSpan struct {
+	ctx    context.Context
+	config *config
+	span   oteltrace.Span
+	query  string
+	start  time.Time
+}
+
+func (c *config) start(ctx context.Context, query string) (context.Context, *statementSpan) {
+	sanitized := sanitizeSQL(query, c.System)
+	operation := operation(sanitized)
+
+	attrs := []attribute.KeyValue{
+		attribute.String("db.statement", sanitized),
+	}
+	if c.System != "" {
+		attrs = append(attrs, attribute.String("db.system", c.System))
+	}
+	name := "db"
+	if operation != "" {
+		name = operation
+		attrs = append(attrs, attribute.String("db.operation", operation))
+	}
+
+	ctx, span := logfire.Tracer().Start(ctx, name,
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+		oteltrace.WithAttributes(attrs...),
+	)
+	return ctx, &statementSpan{
+		ctx:    ctx,
+		config: c,
+		span:   span,
+		query:  query,
+		start:  time.Now(),
+	}
+}
+
+func (s *statementSpan) end(err error, args []any) {
+	duration := time.Since(s.start)
+	if err != nil && err != sql.ErrNoRows {
+		s.span.RecordError(err)
+		s.span.SetStatus(otelcodes.Error, err.Error())
+	}
+
+	if s.config.SlowQueryThreshold > 0 && duration >= s.config.SlowQueryThreshold {
+		attrs := []attribute.KeyValue{
+			attribute.String("db.statement", sanitizeSQL(s.query, s.config.System)),
+			attribute.Float64("db.duration_ms", float64(duration)/float64(time.Millisecond)),
+		}
+		attrs = append(attrs, argAttributes(args)...)
+		logfire.FromContext(s.ctx).Warn(fmt.Sprintf("slow query took %v", duration.Round(time.Millisecond)),
+			logfire.WithAttributes(attrs...))
+	}
+	s.span.End()
+}
+
+// operation returns the first keyword of the statement, e.g. "SELECT".
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// argAttributes returns the arguments of a statement as attributes, with the values of
+// sensitive named arguments redacted and long values truncated.
+func argAttributes(args []any) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(args))
+	for i, arg := range args {
+		key := fmt.Sprintf("db.args.%d", i+1)
+		value := arg
+		if named, ok := arg.(sql.NamedArg); ok {
+			key = "db.args." + named.Name
+			value = named.Value
+			if sensitiveArg.MatchString(named.Name) {
+				attrs = append(attrs, attribute.String(key, redacted))
+				continue
+			}
+		}
+		s := fmt.Sprint(value)
+		if b, ok := value.([]byte); ok {
+			s = fmt.Sprintf("<%d bytes>", len(b))
+		}
+		if len(s) > maxArgLength {
+			s = s[:maxArgLength] + "..."
+		}
+		attrs = append(attrs, attribute.String(key, s))
+	}
+	return attrs
+}
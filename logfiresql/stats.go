@@ -0,0 +1,108 @@
+package logfiresql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// RecordStats records the connection pool stats of db every interval, until the
+// returned function is called, so pool exhaustion can be diagnosed:
+//
+//   - db.client.connections.usage is the number of open connections, by state.
+//   - db.client.connections.max is the maximum number of open connections.
+//   - db.client.connections.waits counts the connections that were waited for.
+//   - db.client.connections.wait_time is the total time spent waiting.
+//   - db.client.connections.closed counts the connections closed, by reason.
+//
+// Only WithSystem applies, and is recorded as the db.system attribute.
+func RecordStats(db *sql.DB, interval time.Duration, opts ...Option) func() {
+	config := &config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	var attrs []attribute.KeyValue
+	if config.System != "" {
+		attrs = append(attrs, semconv.DBSystemKey.String(config.System))
+	}
+
+	meter := logfire.Meter()
+	usage, err := meter.Int64Gauge("db.client.connections.usage",
+		metric.WithDescription("Number of open connections, by state."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create db.client.connections.usage metric: " + err.Error())
+		return func() {}
+	}
+	maxOpen, err := meter.Int64Gauge("db.client.connections.max",
+		metric.WithDescription("Maximum number of open connections, or 0 for no limit."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create db.client.connections.max metric: " + err.Error())
+		return func() {}
+	}
+	waits, err := meter.Int64Counter("db.client.connections.waits",
+		metric.WithDescription("Number of connections that were waited for."),
+		metric.WithUnit("{wait}"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create db.client.connections.waits metric: " + err.Error())
+		return func() {}
+	}
+	waitTime, err := meter.Float64Counter("db.client.connections.wait_time",
+		metric.WithDescription("Total time spent waiting for a connection."),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create db.client.connections.wait_time metric: " + err.Error())
+		return func() {}
+	}
+	closed, err := meter.Int64Counter("db.client.connections.closed",
+		metric.WithDescription("Number of connections closed, by reason."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		logfire.Warn("failed to create db.client.connections.closed metric: " + err.Error())
+		return func() {}
+	}
+
+	with := func(extra ...attribute.KeyValue) metric.MeasurementOption {
+		return metric.WithAttributes(append(extra, attrs...)...)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// DBStats counters are totals since db was opened, the metrics are the change
+		// since the last interval.
+		var previous sql.DBStats
+		for {
+			stats := db.Stats()
+			usage.Record(ctx, int64(stats.Idle), with(attribute.String("state", "idle")))
+			usage.Record(ctx, int64(stats.InUse), with(attribute.String("state", "used")))
+			maxOpen.Record(ctx, int64(stats.MaxOpenConnections), with())
+			waits.Add(ctx, stats.WaitCount-previous.WaitCount, with())
+			waitTime.Add(ctx, float64(stats.WaitDuration-previous.WaitDuration)/float64(time.Millisecond), with())
+			closed.Add(ctx, stats.MaxIdleClosed-previous.MaxIdleClosed, with(attribute.String("reason", "max_idle")))
+			closed.Add(ctx, stats.MaxIdleTimeClosed-previous.MaxIdleTimeClosed, with(attribute.String("reason", "max_idle_time")))
+			closed.Add(ctx, stats.MaxLifetimeClosed-previous.MaxLifetimeClosed, with(attribute.String("reason", "max_lifetime")))
+			previous = stats
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
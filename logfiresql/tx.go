@@ -0,0 +1,132 @@
+package logfiresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Tx is a *sql.Tx whose lifetime, from begin to commit or rollback, is recorded in a
+// span.  Statements executed in the transaction are nested under that span, so
+// transactions holding locks are visible as a unit.
+type Tx struct {
+	*sql.Tx
+	config *config
+	logger *logfire.SpanLogger
+}
+
+// BeginTx starts a transaction in a new span, which ends when the transaction is
+// committed or rolled back.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	var attrs []attribute.KeyValue
+	if db.config.System != "" {
+		attrs = append(attrs, attribute.String("db.system", db.config.System))
+	}
+	if opts != nil {
+		attrs = append(attrs,
+			attribute.String("db.transaction.isolation", opts.Isolation.String()),
+			attribute.Bool("db.transaction.read_only", opts.ReadOnly),
+		)
+	}
+	logger := logfire.NewSpanLogger(ctx, "transaction", logfire.WithAttributes(attrs...))
+	addEvent(logger, "begin")
+
+	tx, err := db.DB.BeginTx(logger.Context(), opts)
+	if err != nil {
+		logger.CloseWithOptions(logfire.WithError(err))
+		return nil, err
+	}
+	return &Tx{Tx: tx, config: db.config, logger: logger}, nil
+}
+
+// RunInTx runs fn in a transaction, committing it if fn succeeds and rolling it back
+// if fn returns an error or panics.
+func (db *DB) RunInTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context, tx *Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.rollback(fmt.Errorf("panic: %v", r))
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx.logger.Context(), tx); err != nil {
+		tx.rollback(err)
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExecContext executes a statement in a span nested under the transaction.
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, s := tx.config.start(tx.statementContext(ctx), query)
+	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	s.end(err, args)
+	return result, err
+}
+
+// QueryContext executes a query in a span nested under the transaction.  As with
+// DB.QueryContext, the span doesn't include reading the rows.
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, s := tx.config.start(tx.statementContext(ctx), query)
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	s.end(err, args)
+	return rows, err
+}
+
+// QueryRowContext executes a query that returns at most one row in a span nested
+// under the transaction.
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, s := tx.config.start(tx.statementContext(ctx), query)
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	s.end(row.Err(), args)
+	return row
+}
+
+// Commit commits the transaction and ends its span.
+func (tx *Tx) Commit() error {
+	err := tx.Tx.Commit()
+	addEvent(tx.logger, "commit")
+	tx.logger.SetAttributes(attribute.String("db.transaction.outcome", "commit"))
+	tx.logger.CloseWithOptions(logfire.WithError(err))
+	return err
+}
+
+// Rollback rolls back the transaction and ends its span.
+func (tx *Tx) Rollback() error {
+	return tx.rollback(nil)
+}
+
+// rollback rolls back the transaction because of cause, which is recorded on the span
+// unless the rollback itself fails.
+func (tx *Tx) rollback(cause error) error {
+	err := tx.Tx.Rollback()
+	if err == sql.ErrTxDone {
+		// Already committed or rolled back, and the span has ended.
+		return err
+	}
+	addEvent(tx.logger, "rollback")
+	tx.logger.SetAttributes(attribute.String("db.transaction.outcome", "rollback"))
+	outcome := err
+	if outcome == nil {
+		outcome = cause
+	}
+	tx.logger.CloseWithOptions(logfire.WithError(outcome))
+	return err
+}
+
+// statementContext returns ctx with the transaction span as the parent of statements.
+func (tx *Tx) statementContext(ctx context.Context) context.Context {
+	return oteltrace.ContextWithSpan(ctx, oteltrace.SpanFromContext(tx.logger.Context()))
+}
+
+func addEvent(logger *logfire.SpanLogger, name string) {
+	oteltrace.SpanFromContext(logger.Context()).AddEvent(name)
+}
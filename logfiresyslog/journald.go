@@ -0,0 +1,128 @@
+package logfiresyslog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxJournalEntrySize is the largest journal entry, as JSON, that is forwarded.  Larger
+// entries are skipped.
+const maxJournalEntrySize = 1024 * 1024
+
+// journalEntry is the subset of journald fields that are forwarded.
+type journalEntry struct {
+	Message           any    `json:"MESSAGE"`
+	Priority          string `json:"PRIORITY"`
+	Facility          string `json:"SYSLOG_FACILITY"`
+	Identifier        string `json:"SYSLOG_IDENTIFIER"`
+	PID               string `json:"_PID"`
+	Hostname          string `json:"_HOSTNAME"`
+	RealtimeTimestamp string `json:"__REALTIME_TIMESTAMP"`
+}
+
+// FollowJournal follows journald using journalctl, and forwards every new entry to
+// Logfire until ctx is done.  Extra arguments are passed to journalctl, e.g.
+// "--unit=nginx.service" to only forward a single unit.
+func FollowJournal(ctx context.Context, args ...string) error {
+	args = append([]string{"--output=json", "--follow", "--lines=0"}, args...)
+	cmd := exec.CommandContext(ctx, "journalctl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(stdout, maxJournalEntrySize)
+	for {
+		line, err := readJournalLine(reader)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			// Stop journalctl, rather than leave it blocked on a full pipe.
+			cmd.Process.Kill()
+			cmd.Wait()
+			return err
+		}
+		if line == nil {
+			logfire.Warn("skipped a journal entry over the maximum size", logfire.WithAttributes(
+				attribute.Int("journal.max_entry_size", maxJournalEntrySize),
+			))
+			continue
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		entry.record().Send(ctx)
+	}
+
+	if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// readJournalLine reads the next line of journalctl output.  Lines longer than
+// maxJournalEntrySize are skipped, returning a nil line.
+func readJournalLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		if err == io.EOF && len(line) > 0 {
+			return line, nil
+		}
+		return line, err
+	}
+	// Discard the rest of the oversized line.
+	for err == bufio.ErrBufferFull {
+		_, err = r.ReadSlice('\n')
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (e journalEntry) record() Record {
+	record := Record{
+		Facility: 1, // user
+		Severity: 6, // info
+		Hostname: e.Hostname,
+		AppName:  e.Identifier,
+		ProcID:   e.PID,
+	}
+	if severity, err := strconv.Atoi(e.Priority); err == nil {
+		record.Severity = severity
+	}
+	if facility, err := strconv.Atoi(e.Facility); err == nil {
+		record.Facility = facility
+	}
+	if usec, err := strconv.ParseInt(e.RealtimeTimestamp, 10, 64); err == nil {
+		record.Timestamp = time.UnixMicro(usec)
+	}
+
+	switch msg := e.Message.(type) {
+	case string:
+		record.Message = msg
+	case []any:
+		// Messages that are not valid UTF-8 are encoded as an array of bytes.
+		b := make([]byte, 0, len(msg))
+		for _, v := range msg {
+			if n, ok := v.(float64); ok {
+				b = append(b, byte(n))
+			}
+		}
+		record.Message = string(b)
+	}
+	return record
+}
@@ -0,0 +1,59 @@
+package logfiresyslog
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadJournalLine(t *testing.T) {
+	input := "first\n" + strings.Repeat("x", 100) + "\nlast"
+	r := bufio.NewReaderSize(strings.NewReader(input), 16)
+
+	want := []string{"first\n", "", "last"}
+	for i, w := range want {
+		line, err := readJournalLine(r)
+		if err != nil {
+			t.Fatalf("line %d: readJournalLine failed: %v", i, err)
+		}
+		if w == "" && line != nil {
+			t.Errorf("line %d = %q, want the oversized line to be skipped", i, line)
+		} else if string(line) != w {
+			t.Errorf("line %d = %q, want %q", i, line, w)
+		}
+	}
+	if _, err := readJournalLine(r); err != io.EOF {
+		t.Errorf("readJournalLine at the end = %v, want io.EOF", err)
+	}
+}
+
+func TestJournalEntryRecord(t *testing.T) {
+	entry := journalEntry{
+		Message:           []any{float64('h'), float64('i'), float64(0xff)},
+		Priority:          "3",
+		Facility:          "4",
+		Identifier:        "sshd",
+		PID:               "42",
+		Hostname:          "host",
+		RealtimeTimestamp: "1726826400000000",
+	}
+	want := Record{
+		Facility:  4,
+		Severity:  3,
+		Timestamp: time.UnixMicro(1726826400000000),
+		Hostname:  "host",
+		AppName:   "sshd",
+		ProcID:    "42",
+		Message:   "hi\xff",
+	}
+	if got := entry.record(); got != want {
+		t.Errorf("record() = %+v, want %+v", got, want)
+	}
+
+	defaults := journalEntry{Message: "hello"}.record()
+	if defaults.Facility != 1 || defaults.Severity != 6 || defaults.Message != "hello" {
+		t.Errorf("record() without fields = %+v, want facility 1 and severity 6", defaults)
+	}
+}
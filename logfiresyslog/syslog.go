@@ -0,0 +1,233 @@
+// Package logfiresyslog forwards system logs to Logfire, either by listening on a
+// syslog socket or by following journald.
+//
+// The syslog priority of each record is mapped to a Logfire level, and the facility,
+// hostname and application name are attached as attributes.
+package logfiresyslog
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxMessageSize is the largest syslog datagram that is accepted.
+const maxMessageSize = 64 * 1024
+
+var facilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// Record is a parsed syslog record.
+type Record struct {
+	// Facility is the syslog facility, e.g. 3 for daemon.
+	Facility int
+	// Severity is the syslog severity, from 0 (emergency) to 7 (debug).
+	Severity int
+	// Timestamp is when the record was created, or the zero time if unknown.
+	Timestamp time.Time
+	// Hostname is the host the record came from.
+	Hostname string
+	// AppName is the application, or tag, that wrote the record.
+	AppName string
+	// ProcID is the process ID of the application, if known.
+	ProcID string
+	// Message is the log message.
+	Message string
+}
+
+// Level maps the syslog severity to a Logfire level.
+func (r Record) Level() logfire.Level {
+	switch {
+	case r.Severity <= 2:
+		// Emergency, alert and critical.
+		return logfire.LevelFatal
+	case r.Severity == 3:
+		return logfire.LevelError
+	case r.Severity == 4:
+		return logfire.LevelWarn
+	case r.Severity <= 6:
+		// Notice and informational.
+		return logfire.LevelInfo
+	default:
+		return logfire.LevelDebug
+	}
+}
+
+// Send sends the record to Logfire as a log.
+func (r Record) Send(ctx context.Context) {
+	attrs := []attribute.KeyValue{
+		attribute.Int("syslog.severity", r.Severity),
+		attribute.Int("syslog.facility", r.Facility),
+	}
+	if r.Facility >= 0 && r.Facility < len(facilities) {
+		attrs = append(attrs, attribute.String("syslog.facility_name", facilities[r.Facility]))
+	}
+	if r.Hostname != "" {
+		attrs = append(attrs, attribute.String("host.name", r.Hostname))
+	}
+	if r.AppName != "" {
+		attrs = append(attrs, attribute.String("syslog.app_name", r.AppName))
+	}
+	if r.ProcID != "" {
+		attrs = append(attrs, attribute.String("syslog.proc_id", r.ProcID))
+	}
+
+	opts := []logfire.SpanOption{logfire.WithAttributes(attrs...)}
+	if !r.Timestamp.IsZero() {
+		opts = append(opts, logfire.WithTimestamp(r.Timestamp))
+	}
+	logfire.FromContext(ctx).Log(r.Level(), r.Message, opts...)
+}
+
+// ListenAndServe listens for syslog datagrams on the given address, e.g. "udp" and
+// ":514", or "unixgram" and "/dev/log", and forwards them to Logfire until ctx is done.
+func ListenAndServe(ctx context.Context, network, address string) error {
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, conn)
+}
+
+// Serve reads syslog datagrams from conn and forwards them to Logfire until ctx is
+// done.  conn is closed when Serve returns.
+func Serve(ctx context.Context, conn net.PacketConn) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		record, err := Parse(string(buf[:n]))
+		if err != nil {
+			// Don't stop listening because one sender is misbehaving.
+			continue
+		}
+		record.Send(ctx)
+	}
+}
+
+// Parse parses an RFC 5424 or RFC 3164 syslog message.
+func Parse(msg string) (Record, error) {
+	msg = strings.TrimRight(msg, "\r\n\x00")
+	if !strings.HasPrefix(msg, "<") {
+		return Record{}, errors.New("syslog: missing priority")
+	}
+	end := strings.IndexByte(msg, '>')
+	if end < 2 || end > 4 {
+		return Record{}, errors.New("syslog: invalid priority")
+	}
+	priority, err := strconv.Atoi(msg[1:end])
+	if err != nil || priority > 191 {
+		return Record{}, errors.New("syslog: invalid priority")
+	}
+
+	record := Record{
+		Facility: priority / 8,
+		Severity: priority % 8,
+	}
+	rest := msg[end+1:]
+	if strings.HasPrefix(rest, "1 ") {
+		parse5424(&record, rest[2:])
+	} else {
+		parse3164(&record, rest)
+	}
+	return record, nil
+}
+
+// parse5424 parses "TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG".
+func parse5424(record *Record, rest string) {
+	fields := strings.SplitN(rest, " ", 6)
+	if len(fields) < 6 {
+		record.Message = rest
+		return
+	}
+	if t, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+		record.Timestamp = t
+	}
+	record.Hostname = nilValue(fields[1])
+	record.AppName = nilValue(fields[2])
+	record.ProcID = nilValue(fields[3])
+
+	msg := fields[5]
+	if strings.HasPrefix(msg, "-") {
+		msg = strings.TrimPrefix(msg[1:], " ")
+	} else if strings.HasPrefix(msg, "[") {
+		// Skip the structured data elements.
+		for strings.HasPrefix(msg, "[") {
+			end := strings.Index(msg, "]")
+			if end < 0 {
+				break
+			}
+			msg = msg[end+1:]
+		}
+		msg = strings.TrimPrefix(msg, " ")
+	}
+	record.Message = strings.TrimPrefix(msg, "\ufeff")
+}
+
+// parse3164 parses "Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG".  Messages that don't
+// follow the format are kept whole.
+func parse3164(record *Record, rest string) {
+	record.Message = rest
+	if len(rest) < 16 {
+		return
+	}
+	t, err := time.ParseInLocation(time.Stamp, rest[:15], time.Local)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	// RFC 3164 timestamps don't have a year.
+	record.Timestamp = t.AddDate(now.Year(), 0, 0)
+	if record.Timestamp.After(now.Add(24 * time.Hour)) {
+		record.Timestamp = record.Timestamp.AddDate(-1, 0, 0)
+	}
+
+	rest = strings.TrimPrefix(rest[15:], " ")
+	hostname, rest, ok := strings.Cut(rest, " ")
+	if !ok {
+		record.Message = hostname
+		return
+	}
+	record.Hostname = hostname
+
+	tag, msg, ok := strings.Cut(rest, ": ")
+	if !ok || strings.Contains(tag, " ") {
+		record.Message = rest
+		return
+	}
+	if open := strings.IndexByte(tag, '['); open >= 0 && strings.HasSuffix(tag, "]") {
+		record.ProcID = tag[open+1 : len(tag)-1]
+		tag = tag[:open]
+	}
+	record.AppName = tag
+	record.Message = msg
+}
+
+// nilValue returns "" for the RFC 5424 nil value "-".
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
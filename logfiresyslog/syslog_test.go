@@ -0,0 +1,108 @@
+package logfiresyslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want Record
+	}{
+		{
+			name: "rfc 5424",
+			msg:  "<165>1 2024-09-20T10:00:00.5Z host app 1234 ID47 - hello world\n",
+			want: Record{
+				Facility:  20,
+				Severity:  5,
+				Timestamp: time.Date(2024, 9, 20, 10, 0, 0, 500000000, time.UTC),
+				Hostname:  "host",
+				AppName:   "app",
+				ProcID:    "1234",
+				Message:   "hello world",
+			},
+		},
+		{
+			name: "rfc 5424 with structured data",
+			msg:  `<14>1 2024-09-20T10:00:00Z host app - - [id@1 a="b"][id@2 c="d"] hello`,
+			want: Record{
+				Facility:  1,
+				Severity:  6,
+				Timestamp: time.Date(2024, 9, 20, 10, 0, 0, 0, time.UTC),
+				Hostname:  "host",
+				AppName:   "app",
+				Message:   "hello",
+			},
+		},
+		{
+			name: "rfc 5424 with nil values",
+			msg:  "<11>1 - - - - - \ufeffboom",
+			want: Record{Facility: 1, Severity: 3, Message: "boom"},
+		},
+		{
+			name: "rfc 3164",
+			msg:  "<34>Oct 11 22:14:15 mymachine su[99]: 'su root' failed",
+			want: Record{
+				Facility: 4,
+				Severity: 2,
+				Hostname: "mymachine",
+				AppName:  "su",
+				ProcID:   "99",
+				Message:  "'su root' failed",
+			},
+		},
+		{
+			name: "rfc 3164 without a tag",
+			msg:  "<13>Oct 11 22:14:15 mymachine just some text",
+			want: Record{Facility: 1, Severity: 5, Hostname: "mymachine", Message: "just some text"},
+		},
+		{
+			name: "free text",
+			msg:  "<13>hello",
+			want: Record{Facility: 1, Severity: 5, Message: "hello"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.msg)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.msg, err)
+			}
+			if tt.want.Timestamp.IsZero() {
+				// RFC 3164 timestamps depend on the current year and time zone.
+				got.Timestamp = time.Time{}
+			}
+			if !got.Timestamp.Equal(tt.want.Timestamp) {
+				t.Errorf("Parse(%q).Timestamp = %v, want %v", tt.msg, got.Timestamp, tt.want.Timestamp)
+			}
+			got.Timestamp, tt.want.Timestamp = time.Time{}, time.Time{}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, msg := range []string{"", "hello", "<>hello", "<1234>hello", "<192>hello", "<x>hello"} {
+		if _, err := Parse(msg); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", msg)
+		}
+	}
+}
+
+func TestRecordLevel(t *testing.T) {
+	want := []logfire.Level{
+		logfire.LevelFatal, logfire.LevelFatal, logfire.LevelFatal, logfire.LevelError,
+		logfire.LevelWarn, logfire.LevelInfo, logfire.LevelInfo, logfire.LevelDebug,
+	}
+	for severity, level := range want {
+		if got := (Record{Severity: severity}).Level(); got != level {
+			t.Errorf("Record{Severity: %d}.Level() = %v, want %v", severity, got, level)
+		}
+	}
+}
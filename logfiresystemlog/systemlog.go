@@ -0,0 +1,41 @@
+// Package logfiresystemlog mirrors Error and Fatal logs to the system log of the
+// platform, as well as Logfire, so services running as Windows services or launchd
+// daemons have their errors where operators of the host look first:
+//
+//	sink, err := logfiresystemlog.New("my-service")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer sink.Close()
+//	closer, err := logfire.Initialize(ctx, logfire.WithLogSink(sink))
+//
+// On Windows, logs are written to the Application Event Log, under the source, which
+// should be registered when the service is installed, e.g. with
+// eventlog.InstallAsEventCreate.  On macOS, logs are written to the unified log through
+// syslog, as on other Unix systems.
+package logfiresystemlog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var _ logfire.LogSink = (*Sink)(nil)
+
+// format formats a log as a single line, with its attributes and trace ID, so it can be
+// correlated with Logfire.
+func format(msg string, sc oteltrace.SpanContext, attrs []attribute.KeyValue) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, kv := range attrs {
+		fmt.Fprintf(&b, " %s=%q", kv.Key, kv.Value.Emit())
+	}
+	if sc.IsValid() {
+		fmt.Fprintf(&b, " trace_id=%s span_id=%s", sc.TraceID(), sc.SpanID())
+	}
+	return b.String()
+}
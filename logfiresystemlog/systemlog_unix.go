@@ -0,0 +1,42 @@
+//go:build !windows
+
+package logfiresystemlog
+
+import (
+	"log/syslog"
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Sink is a logfire.LogSink that writes logs to syslog, which is the unified log on
+// macOS.
+type Sink struct {
+	writer *syslog.Writer
+}
+
+// New creates a Sink that writes logs to the local syslog, tagged with source.
+func New(source string) (*Sink, error) {
+	writer, err := syslog.New(syslog.LOG_ERR|syslog.LOG_DAEMON, source)
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{writer: writer}, nil
+}
+
+// WriteLog writes Error logs with the err priority, and Fatal logs with the crit
+// priority.
+func (s *Sink) WriteLog(t time.Time, level logfire.Level, msg string, sc oteltrace.SpanContext, attrs []attribute.KeyValue) error {
+	line := format(msg, sc, attrs)
+	if level >= logfire.LevelFatal {
+		return s.writer.Crit(line)
+	}
+	return s.writer.Err(line)
+}
+
+// Close closes the connection to syslog.
+func (s *Sink) Close() error {
+	return s.writer.Close()
+}
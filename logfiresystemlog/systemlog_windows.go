@@ -0,0 +1,37 @@
+package logfiresystemlog
+
+import (
+	"time"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventID is the ID of the events written to the Event Log.
+const eventID = 1
+
+// Sink is a logfire.LogSink that writes logs to the Windows Event Log.
+type Sink struct {
+	log *eventlog.Log
+}
+
+// New creates a Sink that writes logs to the Application Event Log under source.
+func New(source string) (*Sink, error) {
+	log, err := eventlog.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{log: log}, nil
+}
+
+// WriteLog writes the log as an error event.
+func (s *Sink) WriteLog(t time.Time, level logfire.Level, msg string, sc oteltrace.SpanContext, attrs []attribute.KeyValue) error {
+	return s.log.Error(eventID, format(msg, sc, attrs))
+}
+
+// Close closes the Event Log.
+func (s *Sink) Close() error {
+	return s.log.Close()
+}
@@ -0,0 +1,46 @@
+// Package golden compares recorded spans against golden files, so instrumentation
+// regressions show up as diffs.
+//
+// Golden files are created, or updated after an intended change, by running the tests
+// with the LOGFIRE_UPDATE_GOLDEN environment variable set to true.
+package golden
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/jerechua/logfire-go/logfiretest"
+)
+
+// Assert fails the test if the spans recorded by recorder, in canonical JSON form, are
+// different from the golden file at path, e.g. "testdata/trace.json".
+func Assert(t testing.TB, recorder *logfiretest.Recorder, path string) {
+	t.Helper()
+
+	got, err := recorder.JSON()
+	if err != nil {
+		t.Fatalf("golden: failed to encode spans: %v", err)
+	}
+	got = append(got, '\n')
+
+	if update, _ := strconv.ParseBool(os.Getenv("LOGFIRE_UPDATE_GOLDEN")); update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden: failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("golden: failed to write %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden: failed to read %s, run with LOGFIRE_UPDATE_GOLDEN=true to create it: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("golden: spans differ from %s, run with LOGFIRE_UPDATE_GOLDEN=true to update it\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,162 @@
+// Package logfiretest records the spans and logs sent with logfire, so instrumentation
+// can be tested.
+//
+// Spans are serialized to a canonical JSON form, with IDs and timestamps removed, so
+// they can be compared against golden files, see the golden package.
+package logfiretest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/jerechua/logfire-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Recorder records every span and log sent with logfire.
+type Recorder struct {
+	exporter *tracetest.InMemoryExporter
+}
+
+// NewRecorder initializes logfire to record spans in memory, instead of sending them to
+// Logfire, until the test ends.  opts are applied after the recording exporter is set.
+//
+// Logfire is configured globally, so tests using a Recorder must not run in parallel.
+func NewRecorder(t testing.TB, opts ...logfire.Option) *Recorder {
+	t.Helper()
+
+	r := &Recorder{exporter: tracetest.NewInMemoryExporter()}
+	closer, err := logfire.Initialize(context.Background(),
+		append([]logfire.Option{
+			logfire.WithServiceName("logfiretest"),
+			logfire.WithSpanExporter(r.exporter),
+		}, opts...)...,
+	)
+	if err != nil {
+		t.Fatalf("logfiretest: failed to initialize logfire: %v", err)
+	}
+	t.Cleanup(closer)
+
+	// Only record the spans of the test, not the service.start span.
+	if err := logfire.Flush(context.Background()); err != nil {
+		t.Fatalf("logfiretest: failed to flush spans: %v", err)
+	}
+	r.exporter.Reset()
+	return r
+}
+
+// Spans flushes and returns every span that has ended, in canonical form.
+func (r *Recorder) Spans() []Span {
+	if err := logfire.Flush(context.Background()); err != nil {
+		panic(fmt.Sprintf("logfiretest: failed to flush spans: %v", err))
+	}
+	return canonicalize(r.exporter.GetSpans())
+}
+
+// Reset forgets every span recorded so far.
+func (r *Recorder) Reset() {
+	r.exporter.Reset()
+}
+
+// JSON returns the spans that have ended, in canonical JSON form.
+func (r *Recorder) JSON() ([]byte, error) {
+	return json.MarshalIndent(r.Spans(), "", "  ")
+}
+
+// Span is a span in canonical form.  Trace and span IDs are replaced by their order of
+// appearance, and timestamps are removed, so the same instrumentation always produces
+// the same spans.
+type Span struct {
+	// Trace is the order of the trace, starting from 1.
+	Trace int `json:"trace"`
+	// ID is the order of the span, starting from 1.
+	ID int `json:"id"`
+	// Parent is the ID of the parent span, or 0 for root spans.
+	Parent int `json:"parent,omitempty"`
+	// Name is the name of the span.
+	Name string `json:"name"`
+	// Kind is the kind of the span, e.g. "internal" or "client".
+	Kind string `json:"kind"`
+	// Scope is the instrumentation scope name of the span.
+	Scope string `json:"scope"`
+	// Status is the status code of the span, and its description.
+	Status string `json:"status,omitempty"`
+	// Attributes are the attributes of the span, by key.
+	Attributes map[string]any `json:"attributes,omitempty"`
+	// Events are the events of the span.
+	Events []Event `json:"events,omitempty"`
+}
+
+// Event is a span event in canonical form.
+type Event struct {
+	// Name is the name of the event.
+	Name string `json:"name"`
+	// Attributes are the attributes of the event, by key.
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// canonicalize converts spans to canonical form, ordered by start time.
+func canonicalize(stubs tracetest.SpanStubs) []Span {
+	sort.SliceStable(stubs, func(i, j int) bool {
+		return stubs[i].StartTime.Before(stubs[j].StartTime)
+	})
+
+	traces := make(map[string]int)
+	ids := make(map[string]int)
+	for _, s := range stubs {
+		ids[s.SpanContext.SpanID().String()] = len(ids) + 1
+	}
+
+	spans := make([]Span, len(stubs))
+	for i, s := range stubs {
+		traceID := s.SpanContext.TraceID().String()
+		if _, ok := traces[traceID]; !ok {
+			traces[traceID] = len(traces) + 1
+		}
+		span := Span{
+			Trace:      traces[traceID],
+			ID:         ids[s.SpanContext.SpanID().String()],
+			Name:       s.Name,
+			Kind:       s.SpanKind.String(),
+			Scope:      s.InstrumentationScope.Name,
+			Attributes: attributeMap(s.Attributes),
+		}
+		if s.Parent.IsValid() {
+			// Remote parents, and parents that haven't ended, are -1.
+			span.Parent = -1
+			if id, ok := ids[s.Parent.SpanID().String()]; ok {
+				span.Parent = id
+			}
+		}
+		if s.Status.Code != 0 {
+			span.Status = s.Status.Code.String()
+			if s.Status.Description != "" {
+				span.Status += ": " + s.Status.Description
+			}
+		}
+		for _, e := range s.Events {
+			span.Events = append(span.Events, Event{
+				Name:       e.Name,
+				Attributes: attributeMap(e.Attributes),
+			})
+		}
+		spans[i] = span
+	}
+	return spans
+}
+
+// attributeMap converts attributes to a map, which JSON encodes with sorted keys.
+func attributeMap(attrs []attribute.KeyValue) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(attrs))
+	for _, kv := range attrs {
+		m[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return m
+}
@@ -0,0 +1,115 @@
+package logfire
+
+import (
+	"context"
+	"runtime/metrics"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultMemoryWatcherInterval = 10 * time.Second
+	gcPausesMetric               = "/sched/pauses/total/gc:seconds"
+)
+
+// MemoryWatcher configures the thresholds of WithMemoryWatcher.  A zero threshold is
+// not checked.
+type MemoryWatcher struct {
+	// Interval is how often memory usage is sampled.  Defaults to 10 seconds.
+	Interval time.Duration
+	// WarnBytes is the memory used by the Go runtime above which a Warn is logged.
+	WarnBytes uint64
+	// ErrorBytes is the memory used by the Go runtime above which an Error is logged.
+	ErrorBytes uint64
+	// MaxGCPause is the p99 stop-the-world GC pause, over an interval, above which a
+	// Warn is logged.
+	MaxGCPause time.Duration
+}
+
+// memoryStats are the runtime/metrics reported with every memory log, as memory.*
+// attributes.  The first is the memory usage compared against the thresholds.
+var memoryStats = []struct {
+	metric string
+	key    string
+}{
+	{"/memory/classes/total:bytes", "memory.total_bytes"},
+	{"/memory/classes/heap/objects:bytes", "memory.heap_objects_bytes"},
+	{"/gc/heap/goal:bytes", "memory.heap_goal_bytes"},
+	{"/gc/heap/objects:objects", "memory.heap_objects"},
+	{"/gc/gomemlimit:bytes", "memory.limit_bytes"},
+	{"/gc/cycles/total:gc-cycles", "memory.gc_cycles"},
+}
+
+// startMemoryWatcher samples memory usage and GC pauses every interval, until the
+// returned function is called.  A log is emitted once when a threshold is exceeded, and
+// again only after it has recovered.
+func startMemoryWatcher(w MemoryWatcher) func() {
+	if w.Interval <= 0 {
+		w.Interval = defaultMemoryWatcherInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		samples := []metrics.Sample{{Name: gcPausesMetric}}
+		for _, stat := range memoryStats {
+			samples = append(samples, metrics.Sample{Name: stat.metric})
+		}
+		metrics.Read(samples)
+		previous := copyHistogram(samples[0].Value)
+
+		var memoryLevel Level
+		var slowGC bool
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			metrics.Read(samples)
+			stats := memoryStatsAttributes(samples[1:])
+			used := samples[1].Value.Uint64()
+
+			var level Level
+			switch {
+			case w.ErrorBytes > 0 && used > w.ErrorBytes:
+				level = LevelError
+			case w.WarnBytes > 0 && used > w.WarnBytes:
+				level = LevelWarn
+			}
+			if level > memoryLevel {
+				Log(level, "memory usage is high", WithAttributes(stats...))
+			}
+			memoryLevel = level
+
+			current := copyHistogram(samples[0].Value)
+			p99 := histogramQuantile(previous, current, 0.99)
+			previous = current
+			exceeded := w.MaxGCPause > 0 && p99 > w.MaxGCPause
+			if exceeded && !slowGC {
+				attrs := append(stats,
+					Attr("memory.gc_pause_p99_ms", p99),
+					Attr("memory.max_gc_pause_ms", w.MaxGCPause),
+				)
+				Warn("GC pauses are long", WithAttributes(attrs...))
+			}
+			slowGC = exceeded
+		}
+	}()
+	return cancel
+}
+
+// memoryStatsAttributes converts samples of memoryStats to attributes.
+func memoryStatsAttributes(samples []metrics.Sample) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(samples))
+	for i, s := range samples {
+		if s.Value.Kind() == metrics.KindUint64 {
+			attrs = append(attrs, attribute.Int64(memoryStats[i].key, int64(s.Value.Uint64())))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,40 @@
+package logfire
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// newMeterProvider creates a MeterProvider that periodically exports metrics to
+// Logfire, or to a generic OTLP endpoint.
+func newMeterProvider(ctx context.Context, config *config, resources *resource.Resource) (*sdkmetric.MeterProvider, error) {
+	opts := []sdkmetric.Option{
+		sdkmetric.WithResource(resources),
+	}
+	// Metrics aren't exported when the Logfire exporter is replaced.
+	if config.SpanExporter == nil {
+		exporter, err := otlpmetrichttp.New(ctx, metricExporterOptions(config)...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)))
+	}
+	for _, reader := range config.MetricReaders {
+		opts = append(opts, sdkmetric.WithReader(reader))
+	}
+	return sdkmetric.NewMeterProvider(opts...), nil
+}
+
+// Meter returns an OpenTelemetry Meter that can be used to record metrics, or to hook
+// into other OpenTelemetry integrations.  Metrics recorded with this meter are sent
+// directly to Logfire.
+func Meter() metric.Meter {
+	if globalMeter == nil {
+		panic("did you forget to call Initialize()?")
+	}
+	return globalMeter
+}
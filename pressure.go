@@ -0,0 +1,17 @@
+package logfire
+
+// Pressure returns how saturated the telemetry pipeline is, as the utilization of the
+// fullest export queue from 0 to 1.  Applications can use it to shed their own load, or
+// to log less, before spans start being dropped:
+//
+//	if logfire.Pressure() > 0.8 {
+//		// Skip debug logs until the exporter catches up.
+//	}
+//
+// Pressure returns 0 before Initialize is called.
+func Pressure() float64 {
+	if globalExportQueue == nil {
+		return 0
+	}
+	return globalExportQueue.utilization()
+}
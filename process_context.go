@@ -0,0 +1,76 @@
+package logfire
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// TraceParentEnv is the environment variable TraceEnv passes the W3C traceparent of
+	// the current span in.
+	TraceParentEnv = "LOGFIRE_TRACEPARENT"
+	// TraceStateEnv is the environment variable TraceEnv passes the W3C tracestate of the
+	// current span in.
+	TraceStateEnv = "LOGFIRE_TRACESTATE"
+)
+
+// envCarriers maps W3C trace context headers to the environment variables they are
+// passed in.
+var envCarriers = map[string]string{
+	"traceparent": TraceParentEnv,
+	"tracestate":  TraceStateEnv,
+}
+
+// TraceEnv returns the trace context of ctx as environment variables, to pass to a child
+// process so the spans it sends are part of the same trace:
+//
+//	cmd := exec.CommandContext(ctx, "worker")
+//	cmd.Env = append(os.Environ(), logfire.TraceEnv(ctx)...)
+//
+// The child process continues the trace with InitializeFromEnv or ContextFromEnv.
+func TraceEnv(ctx context.Context) []string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	var env []string
+	for header, name := range envCarriers {
+		if value := carrier.Get(header); value != "" {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// ContextFromEnv returns ctx with the trace context passed to the process by its parent
+// with TraceEnv, if any, so spans started from it continue the parent's trace.
+func ContextFromEnv(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{}
+	for header, name := range envCarriers {
+		if value := os.Getenv(name); value != "" {
+			carrier.Set(header, value)
+		}
+	}
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// InitializeFromEnv initializes Logfire like Initialize, and returns a context with the
+// trace context passed to the process by its parent with TraceEnv, so that multi-process
+// pipelines produce connected traces:
+//
+//	ctx, closer, err := logfire.InitializeFromEnv(context.Background())
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer closer()
+//	logger := logfire.NewSpanLogger(ctx, "worker")
+func InitializeFromEnv(ctx context.Context, opts ...Option) (context.Context, func(), error) {
+	closer, err := Initialize(ctx, opts...)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return ContextFromEnv(ctx), closer, nil
+}
@@ -0,0 +1,329 @@
+package logfire
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	defaultMaxQueueSize  = 2048
+	defaultMaxBatchSize  = 512
+	defaultBatchTimeout  = 1 * time.Second
+	defaultExportTimeout = 30 * time.Second
+)
+
+type overflowKind int
+
+const (
+	overflowDropNewest overflowKind = iota
+	overflowDropOldest
+	overflowBlock
+)
+
+// OverflowPolicy decides what happens to a finished span when the export queue is full.
+type OverflowPolicy struct {
+	kind    overflowKind
+	timeout time.Duration
+}
+
+var (
+	// DropNewest discards the span that could not be queued.  This never blocks the
+	// caller and is the default.
+	DropNewest = OverflowPolicy{kind: overflowDropNewest}
+	// DropOldest discards the oldest queued span to make room for the new one.  This
+	// never blocks the caller, and favours recent telemetry over old telemetry.
+	DropOldest = OverflowPolicy{kind: overflowDropOldest}
+)
+
+// BlockWithTimeout blocks the caller ending the span until there is room in the queue,
+// or until the timeout elapses, in which case the span is dropped.  Use this for
+// pipelines that must not lose spans, and can afford to slow down instead.
+func BlockWithTimeout(timeout time.Duration) OverflowPolicy {
+	return OverflowPolicy{kind: overflowBlock, timeout: timeout}
+}
+
+// batchProcessor is a SpanProcessor that queues finished spans and exports them in
+// batches.  Unlike the SDK batcher, what happens when the queue is full is decided by
+// an OverflowPolicy.
+type batchProcessor struct {
+	exporter     sdktrace.SpanExporter
+	policy       OverflowPolicy
+	clock        Clock
+	maxQueueSize int
+	maxBatchSize int
+	batchTimeout time.Duration
+
+	mu    sync.Mutex
+	queue []sdktrace.ReadOnlySpan
+	// space is closed, and replaced, whenever spans are removed from the queue.
+	space   chan struct{}
+	dropped uint64
+
+	// exportMu serializes calls to the exporter.
+	exportMu sync.Mutex
+	// lastExport and lastErr are the time of the last successful export, and the error
+	// of the last export attempt.  They are guarded by mu.
+	lastExport time.Time
+	lastErr    error
+
+	ready    chan struct{}
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+var _ sdktrace.SpanProcessor = (*batchProcessor)(nil)
+
+func newBatchProcessor(exporter sdktrace.SpanExporter, policy OverflowPolicy) *batchProcessor {
+	p := &batchProcessor{
+		exporter:     exporter,
+		policy:       policy,
+		clock:        globalClock,
+		maxQueueSize: defaultMaxQueueSize,
+		maxBatchSize: defaultMaxBatchSize,
+		batchTimeout: defaultBatchTimeout,
+		space:        make(chan struct{}),
+		ready:        make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// OnStart does nothing, spans are only queued once they end.
+func (p *batchProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {}
+
+// OnEnd queues the span for export, applying the overflow policy if the queue is full.
+func (p *batchProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	select {
+	case <-p.stopCh:
+		return
+	default:
+	}
+
+	var deadline <-chan time.Time
+	for {
+		p.mu.Lock()
+		if len(p.queue) < p.maxQueueSize {
+			p.enqueueLocked(s)
+			p.mu.Unlock()
+			return
+		}
+
+		switch p.policy.kind {
+		case overflowDropOldest:
+			p.queue[0] = nil
+			p.queue = p.queue[1:]
+			p.dropped++
+			p.enqueueLocked(s)
+			p.mu.Unlock()
+			return
+		case overflowBlock:
+			space := p.space
+			p.mu.Unlock()
+			if deadline == nil {
+				timer := time.NewTimer(p.policy.timeout)
+				defer timer.Stop()
+				deadline = timer.C
+			}
+			select {
+			case <-space:
+				continue
+			case <-deadline:
+			case <-p.stopCh:
+			}
+			p.mu.Lock()
+			p.dropped++
+			p.mu.Unlock()
+			return
+		default:
+			p.dropped++
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+// enqueueLocked appends the span to the queue, and wakes the export loop once a full
+// batch is available.  p.mu must be held.
+func (p *batchProcessor) enqueueLocked(s sdktrace.ReadOnlySpan) {
+	p.queue = append(p.queue, s)
+	if len(p.queue) >= p.maxBatchSize {
+		select {
+		case p.ready <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// utilization returns how full the queue is, from 0 to 1.
+func (p *batchProcessor) utilization() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return float64(len(p.queue)) / float64(p.maxQueueSize)
+}
+
+// exportStats describes the health of a batchProcessor.
+type exportStats struct {
+	Exporter         string     `json:"exporter"`
+	Connected        bool       `json:"connected"`
+	LastExport       *time.Time `json:"last_export,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+	QueueUtilization float64    `json:"queue_utilization"`
+	Dropped          uint64     `json:"dropped"`
+}
+
+// stats returns the health of the processor.  The exporter is considered connected
+// until an export fails.
+func (p *batchProcessor) stats() exportStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := exportStats{
+		Exporter:         exporterName(p.exporter),
+		Connected:        p.lastErr == nil,
+		QueueUtilization: float64(len(p.queue)) / float64(p.maxQueueSize),
+		Dropped:          p.dropped,
+	}
+	if !p.lastExport.IsZero() {
+		lastExport := p.lastExport
+		stats.LastExport = &lastExport
+	}
+	if p.lastErr != nil {
+		stats.LastError = p.lastErr.Error()
+	}
+	return stats
+}
+
+// dequeue removes up to maxBatchSize spans from the front of the queue.
+func (p *batchProcessor) dequeue() []sdktrace.ReadOnlySpan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := min(len(p.queue), p.maxBatchSize)
+	if n == 0 {
+		return nil
+	}
+	batch := make([]sdktrace.ReadOnlySpan, n)
+	copy(batch, p.queue)
+	clear(p.queue[:n])
+	p.queue = p.queue[n:]
+
+	close(p.space)
+	p.space = make(chan struct{})
+	return batch
+}
+
+// exportAll exports every queued span, one batch at a time, until ctx is done or stop
+// is closed.
+func (p *batchProcessor) exportAll(ctx context.Context, stop <-chan struct{}) error {
+	p.exportMu.Lock()
+	defer p.exportMu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return nil
+		default:
+		}
+		batch := p.dequeue()
+		if len(batch) == 0 {
+			return nil
+		}
+		exportCtx, cancel := context.WithTimeout(ctx, defaultExportTimeout)
+		err := p.exporter.ExportSpans(exportCtx, batch)
+		cancel()
+
+		p.mu.Lock()
+		p.lastErr = err
+		if err == nil {
+			p.lastExport = p.clock.Now()
+		} else {
+			// The batch isn't retried, whether the exporter failed or the export was cut
+			// short, e.g. by the shutdown timeout.
+			p.dropped += uint64(len(batch))
+		}
+		p.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *batchProcessor) run() {
+	defer close(p.done)
+
+	ticker := p.clock.NewTicker(p.batchTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.Chan():
+		case <-p.ready:
+		}
+		// Stop between batches on shutdown, which exports the rest within its deadline.
+		if err := p.exportAll(context.Background(), p.stopCh); err != nil {
+			otel.Handle(err)
+		}
+	}
+}
+
+// Shutdown stops the export loop, exports any remaining spans and shuts down the
+// exporter.
+func (p *batchProcessor) Shutdown(ctx context.Context) error {
+	var err error
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+		<-p.done
+		if exportErr := p.exportAll(ctx, nil); exportErr != nil {
+			err = exportErr
+		}
+		p.dropQueued()
+		if shutdownErr := p.exporter.Shutdown(ctx); shutdownErr != nil && err == nil {
+			err = shutdownErr
+		}
+	})
+	return err
+}
+
+// queued returns the number of spans waiting to be exported.
+func (p *batchProcessor) queued() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.queue)
+}
+
+// dropQueued counts the spans still queued, e.g. when shutdown times out, as dropped.
+func (p *batchProcessor) dropQueued() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dropped += uint64(len(p.queue))
+	clear(p.queue)
+	p.queue = nil
+}
+
+// ForceFlush exports all queued spans.
+func (p *batchProcessor) ForceFlush(ctx context.Context) error {
+	return p.exportAll(ctx, nil)
+}
+
+// exporterName returns the type of the exporter, looking through the usage exporter.
+func exporterName(exporter sdktrace.SpanExporter) string {
+	if usage, ok := exporter.(*usageExporter); ok {
+		exporter = usage.SpanExporter
+	}
+	return fmt.Sprintf("%T", exporter)
+}
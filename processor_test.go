@@ -0,0 +1,186 @@
+package logfire
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// testSpan returns an ended, sampled span with the given IDs.
+func testSpan(name string, id, parent byte) sdktrace.ReadOnlySpan {
+	stub := tracetest.SpanStub{
+		Name: name,
+		SpanContext: oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+			TraceID:    oteltrace.TraceID{1},
+			SpanID:     oteltrace.SpanID{id},
+			TraceFlags: oteltrace.FlagsSampled,
+		}),
+		StartTime: time.Unix(0, 0),
+		EndTime:   time.Unix(0, 0).Add(time.Duration(id) * time.Millisecond),
+	}
+	if parent != 0 {
+		stub.Parent = oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+			TraceID:    oteltrace.TraceID{1},
+			SpanID:     oteltrace.SpanID{parent},
+			TraceFlags: oteltrace.FlagsSampled,
+		})
+	}
+	return stub.Snapshot()
+}
+
+// newTestBatchProcessor returns a batchProcessor with a small queue, without the export
+// loop, so the queue only changes when the test changes it.
+func newTestBatchProcessor(policy OverflowPolicy, size int) (*batchProcessor, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return &batchProcessor{
+		exporter:     exporter,
+		policy:       policy,
+		clock:        systemClock{},
+		maxQueueSize: size,
+		maxBatchSize: size,
+		batchTimeout: defaultBatchTimeout,
+		space:        make(chan struct{}),
+		ready:        make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+		done:         make(chan struct{}),
+	}, exporter
+}
+
+func queuedNames(p *batchProcessor) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var names []string
+	for _, s := range p.queue {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+func TestOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+		want   []string
+	}{
+		{"drop newest", DropNewest, []string{"a", "b"}},
+		{"drop oldest", DropOldest, []string{"c", "d"}},
+		{"block with timeout", BlockWithTimeout(time.Millisecond), []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestBatchProcessor(tt.policy, 2)
+			for i, name := range []string{"a", "b", "c", "d"} {
+				p.OnEnd(testSpan(name, byte(i+1), 0))
+			}
+			if got := queuedNames(p); !slices.Equal(got, tt.want) {
+				t.Errorf("queued %v, want %v", got, tt.want)
+			}
+			if got := p.stats().Dropped; got != 2 {
+				t.Errorf("dropped %d spans, want 2", got)
+			}
+		})
+	}
+}
+
+func TestBlockWithTimeoutWaitsForSpace(t *testing.T) {
+	p, _ := newTestBatchProcessor(BlockWithTimeout(time.Minute), 1)
+	p.OnEnd(testSpan("a", 1, 0))
+
+	queued := make(chan struct{})
+	go func() {
+		p.OnEnd(testSpan("b", 2, 0))
+		close(queued)
+	}()
+	select {
+	case <-queued:
+		t.Fatal("OnEnd returned while the queue was full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if batch := p.dequeue(); len(batch) != 1 {
+		t.Fatalf("dequeued %d spans, want 1", len(batch))
+	}
+	<-queued
+	if got := queuedNames(p); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("queued %v, want [b]", got)
+	}
+	if got := p.stats().Dropped; got != 0 {
+		t.Errorf("dropped %d spans, want 0", got)
+	}
+}
+
+func TestBlockWithTimeoutStopsOnShutdown(t *testing.T) {
+	p, _ := newTestBatchProcessor(BlockWithTimeout(time.Minute), 1)
+	p.OnEnd(testSpan("a", 1, 0))
+
+	queued := make(chan struct{})
+	go func() {
+		p.OnEnd(testSpan("b", 2, 0))
+		close(queued)
+	}()
+	close(p.stopCh)
+	select {
+	case <-queued:
+	case <-time.After(time.Second):
+		t.Fatal("OnEnd still blocked after the processor stopped")
+	}
+}
+
+func TestBatchProcessorSkipsUnsampledSpans(t *testing.T) {
+	p, _ := newTestBatchProcessor(DropNewest, 1)
+	p.OnEnd(tracetest.SpanStub{Name: "unsampled"}.Snapshot())
+	if got := queuedNames(p); len(got) != 0 {
+		t.Errorf("queued %v, want nothing", got)
+	}
+}
+
+func TestBatchProcessorExportsInBatches(t *testing.T) {
+	p, exporter := newTestBatchProcessor(DropNewest, 5)
+	p.maxBatchSize = 2
+	for i := range 5 {
+		p.OnEnd(testSpan("span", byte(i+1), 0))
+	}
+	if err := p.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("ForceFlush failed: %v", err)
+	}
+	if got := len(exporter.GetSpans()); got != 5 {
+		t.Errorf("exported %d spans, want 5", got)
+	}
+	if stats := p.stats(); !stats.Connected || stats.LastExport == nil || stats.QueueUtilization != 0 {
+		t.Errorf("stats() = %+v, want connected with an empty queue", stats)
+	}
+}
+
+// failingExporter fails every export.
+type failingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (failingExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	return errors.New("export failed")
+}
+
+func TestBatchProcessorCountsFailedExports(t *testing.T) {
+	p, _ := newTestBatchProcessor(DropNewest, 5)
+	p.exporter = failingExporter{tracetest.NewInMemoryExporter()}
+	p.maxBatchSize = 2
+	for i := range 3 {
+		p.OnEnd(testSpan("span", byte(i+1), 0))
+	}
+	if err := p.ForceFlush(context.Background()); err == nil {
+		t.Fatal("ForceFlush succeeded, want the export error")
+	}
+	stats := p.stats()
+	if stats.Dropped != 2 {
+		t.Errorf("dropped %d spans, want the 2 spans of the failed batch", stats.Dropped)
+	}
+	if stats.Connected || stats.LastError != "export failed" {
+		t.Errorf("stats() = %+v, want disconnected with the export error", stats)
+	}
+}
@@ -0,0 +1,165 @@
+package logfire
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/autoprop"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// extractors are propagators that only extract trace context, so that traces started
+// by other ecosystems continue in Logfire.  They are selected by name, along with the
+// propagators of autoprop.
+var extractors = map[string]propagation.TextMapPropagator{
+	"datadog": datadogExtractor{},
+	"gcp":     cloudTraceExtractor{},
+}
+
+// newPropagator creates the propagator with the given names.  Without names, the
+// OTEL_PROPAGATORS environment variable is used, which defaults to W3C trace context
+// and baggage.
+func newPropagator(names []string) (propagation.TextMapPropagator, error) {
+	if len(names) == 0 {
+		return autoprop.NewTextMapPropagator(), nil
+	}
+
+	// Extractors go first, so that the standard formats take precedence when a request
+	// carries several of them.
+	var propagators []propagation.TextMapPropagator
+	var standard []string
+	for _, name := range names {
+		if extractor, ok := extractors[name]; ok {
+			propagators = append(propagators, extractor)
+		} else {
+			standard = append(standard, name)
+		}
+	}
+	if len(standard) > 0 {
+		p, err := autoprop.TextMapPropagator(standard...)
+		if err != nil {
+			return nil, err
+		}
+		propagators = append(propagators, p)
+	}
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
+
+const (
+	datadogTraceIDHeader  = "x-datadog-trace-id"
+	datadogParentIDHeader = "x-datadog-parent-id"
+	datadogPriorityHeader = "x-datadog-sampling-priority"
+	datadogTagsHeader     = "x-datadog-tags"
+	// datadogTraceIDHighTag holds the upper 64 bits of a 128-bit trace ID, in hex.
+	datadogTraceIDHighTag = "_dd.p.tid"
+)
+
+// datadogExtractor extracts trace context from Datadog headers.  Datadog IDs are
+// decimal 64-bit integers, with the upper 64 bits of 128-bit trace IDs in a tag.
+type datadogExtractor struct{}
+
+var _ propagation.TextMapPropagator = datadogExtractor{}
+
+// Inject does nothing, trace context is only extracted.
+func (datadogExtractor) Inject(context.Context, propagation.TextMapCarrier) {}
+
+// Extract returns ctx with the remote span context of the Datadog headers, if any.
+func (datadogExtractor) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	low, err := strconv.ParseUint(carrier.Get(datadogTraceIDHeader), 10, 64)
+	if err != nil {
+		return ctx
+	}
+	parent, err := strconv.ParseUint(carrier.Get(datadogParentIDHeader), 10, 64)
+	if err != nil {
+		return ctx
+	}
+	var high uint64
+	for _, tag := range strings.Split(carrier.Get(datadogTagsHeader), ",") {
+		if value, ok := strings.CutPrefix(tag, datadogTraceIDHighTag+"="); ok {
+			high, _ = strconv.ParseUint(value, 16, 64)
+		}
+	}
+
+	var traceID oteltrace.TraceID
+	binary.BigEndian.PutUint64(traceID[:8], high)
+	binary.BigEndian.PutUint64(traceID[8:], low)
+	var spanID oteltrace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], parent)
+
+	// Requests are sampled unless the sampling priority says otherwise.
+	flags := oteltrace.FlagsSampled
+	if priority, err := strconv.Atoi(carrier.Get(datadogPriorityHeader)); err == nil && priority <= 0 {
+		flags = 0
+	}
+	return withRemoteSpanContext(ctx, traceID, spanID, flags)
+}
+
+// Fields returns the headers that are read.
+func (datadogExtractor) Fields() []string {
+	return []string{datadogTraceIDHeader, datadogParentIDHeader, datadogPriorityHeader, datadogTagsHeader}
+}
+
+const cloudTraceHeader = "x-cloud-trace-context"
+
+// cloudTraceExtractor extracts trace context from the Google Cloud
+// X-Cloud-Trace-Context header, formatted as TRACE_ID/SPAN_ID;o=OPTIONS, where the
+// trace ID is hex, and the span ID is a decimal 64-bit integer.
+type cloudTraceExtractor struct{}
+
+var _ propagation.TextMapPropagator = cloudTraceExtractor{}
+
+// Inject does nothing, trace context is only extracted.
+func (cloudTraceExtractor) Inject(context.Context, propagation.TextMapCarrier) {}
+
+// Extract returns ctx with the remote span context of the X-Cloud-Trace-Context
+// header, if any.
+func (cloudTraceExtractor) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	traceHex, rest, ok := strings.Cut(carrier.Get(cloudTraceHeader), "/")
+	if !ok {
+		return ctx
+	}
+	spanDecimal, options, _ := strings.Cut(rest, ";")
+
+	var traceID oteltrace.TraceID
+	b, err := hex.DecodeString(traceHex)
+	if err != nil || len(b) != len(traceID) {
+		return ctx
+	}
+	copy(traceID[:], b)
+	parent, err := strconv.ParseUint(spanDecimal, 10, 64)
+	if err != nil {
+		return ctx
+	}
+	var spanID oteltrace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], parent)
+
+	// Requests are sampled unless the header says otherwise.
+	flags := oteltrace.FlagsSampled
+	if options == "o=0" {
+		flags = 0
+	}
+	return withRemoteSpanContext(ctx, traceID, spanID, flags)
+}
+
+// Fields returns the headers that are read.
+func (cloudTraceExtractor) Fields() []string {
+	return []string{cloudTraceHeader}
+}
+
+// withRemoteSpanContext returns ctx with a remote span context, if it is valid.
+func withRemoteSpanContext(ctx context.Context, traceID oteltrace.TraceID, spanID oteltrace.SpanID, flags oteltrace.TraceFlags) context.Context {
+	sc := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+	if !sc.IsValid() {
+		return ctx
+	}
+	return oteltrace.ContextWithRemoteSpanContext(ctx, sc)
+}
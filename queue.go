@@ -0,0 +1,38 @@
+package logfire
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	queueDepthOnce  sync.Once
+	queueDepthGauge metric.Int64Gauge
+)
+
+// RecordQueueDepth records the number of messages waiting in the named queue, as the
+// messaging.queue.depth metric with the queue in the messaging.destination.name
+// attribute.  Call it whenever the depth is known, e.g. after polling a broker, so
+// asynchronous pipelines report their backlog alongside their traces.
+func RecordQueueDepth(name string, n int64) {
+	queueDepthOnce.Do(func() {
+		var err error
+		queueDepthGauge, err = Meter().Int64Gauge("messaging.queue.depth",
+			metric.WithDescription("Number of messages waiting in a queue."),
+			metric.WithUnit("{message}"),
+		)
+		if err != nil {
+			otel.Handle(err)
+		}
+	})
+	if queueDepthGauge == nil {
+		return
+	}
+	queueDepthGauge.Record(context.Background(), n,
+		metric.WithAttributes(attribute.String("messaging.destination.name", name)),
+	)
+}
@@ -0,0 +1,145 @@
+package logfire
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxRelayFrameSize is the largest frame sent to, or accepted by, a relay.
+const MaxRelayFrameSize = 16 * 1024 * 1024
+
+// relayDialTimeout is how long connecting to the relay may take, if the context of the
+// export has no deadline.
+const relayDialTimeout = 10 * time.Second
+
+// WithRelay exports spans to a relay, such as cmd/logfire-relay, at addr, instead of to
+// Logfire.  The relay holds the token and sends the spans on to Logfire, so the
+// application needs neither the token nor access to the internet.  No API token is
+// required, and metrics are only sent to readers registered with WithMetricReader.
+//
+// secret is the secret shared with the relay, which only relays spans from connections
+// that send it.  It can be empty if the relay has no secret, e.g. when it only listens
+// on the loopback interface.
+//
+//	logfire.Initialize(ctx, logfire.WithRelay("logfire-relay.observability:7070", os.Getenv("LOGFIRE_RELAY_SECRET")))
+//
+// Spans are sent over TCP as frames of a big endian uint32 length followed by a payload.
+// The first frame of a connection holds the secret, and every later frame a protobuf
+// OTLP ExportTraceServiceRequest.  The relay answers each frame with a big endian uint16
+// HTTP status code: 200 or 401 for the secret, closing the connection after a 401, and
+// that of Logfire's response for spans.
+func WithRelay(addr, secret string) Option {
+	return func(c *config) {
+		c.SpanExporter = otlptrace.NewUnstarted(&relayClient{addr: addr, secret: secret})
+	}
+}
+
+// relayClient is an otlptrace.Client that sends spans to a relay over one connection,
+// reconnecting after errors.
+type relayClient struct {
+	addr   string
+	secret string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+var _ otlptrace.Client = (*relayClient)(nil)
+
+// Start does nothing, the relay is connected to on the first export.
+func (c *relayClient) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop closes the connection to the relay.
+func (c *relayClient) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// UploadTraces sends the spans to the relay, and waits for the relay to send them on.
+func (c *relayClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	payload, err := proto.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+	if len(payload) > MaxRelayFrameSize {
+		return fmt.Errorf("logfire relay: %d bytes of spans exceeds the frame size", len(payload))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		dialer := net.Dialer{Timeout: relayDialTimeout}
+		c.conn, err = dialer.DialContext(ctx, "tcp", c.addr)
+		if err != nil {
+			c.conn = nil
+			return fmt.Errorf("logfire relay: %w", err)
+		}
+		if err := c.authenticate(ctx); err != nil {
+			c.conn.Close()
+			c.conn = nil
+			return fmt.Errorf("logfire relay: %w", err)
+		}
+	}
+
+	status, err := c.send(ctx, payload)
+	if err != nil {
+		// The connection may be out of step with the relay, so start over.
+		c.conn.Close()
+		c.conn = nil
+		return fmt.Errorf("logfire relay: %w", err)
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("logfire relay: Logfire responded with status %d", status)
+	}
+	return nil
+}
+
+// authenticate sends the secret on a new connection.
+func (c *relayClient) authenticate(ctx context.Context) error {
+	status, err := c.send(ctx, []byte(c.secret))
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("relay rejected the secret with status %d", status)
+	}
+	return nil
+}
+
+// send writes payload as a frame, and reads the status the relay answers with.
+func (c *relayClient) send(ctx context.Context, payload []byte) (int, error) {
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return 0, err
+	}
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+	if _, err := c.conn.Write(frame); err != nil {
+		return 0, err
+	}
+	var status [2]byte
+	if _, err := io.ReadFull(c.conn, status[:]); err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint16(status[:])), nil
+}
@@ -0,0 +1,142 @@
+package logfire
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeRelay accepts one connection at a time, checks the secret, and answers every
+// frame of spans with status, sending the requests it received on requests.
+type fakeRelay struct {
+	addr     string
+	requests chan *coltracepb.ExportTraceServiceRequest
+}
+
+func newFakeRelay(t *testing.T, secret string, status uint16) *fakeRelay {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	r := &fakeRelay{addr: l.Addr().String(), requests: make(chan *coltracepb.ExportTraceServiceRequest, 10)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r.serve(conn, secret, status)
+		}
+	}()
+	return r
+}
+
+func (r *fakeRelay) serve(conn net.Conn, secret string, status uint16) {
+	defer conn.Close()
+	frame, err := readTestFrame(conn)
+	if err != nil {
+		return
+	}
+	if string(frame) != secret {
+		writeTestStatus(conn, http.StatusUnauthorized)
+		return
+	}
+	writeTestStatus(conn, http.StatusOK)
+	for {
+		frame, err := readTestFrame(conn)
+		if err != nil {
+			return
+		}
+		req := &coltracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(frame, req); err != nil {
+			return
+		}
+		r.requests <- req
+		writeTestStatus(conn, status)
+	}
+}
+
+func readTestFrame(conn net.Conn) ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	frame := make([]byte, binary.BigEndian.Uint32(length[:]))
+	_, err := io.ReadFull(conn, frame)
+	return frame, err
+}
+
+func writeTestStatus(conn net.Conn, status uint16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], status)
+	conn.Write(b[:])
+}
+
+func testResourceSpans(name string) []*tracepb.ResourceSpans {
+	return []*tracepb.ResourceSpans{{
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Spans: []*tracepb.Span{{Name: name}},
+		}},
+	}}
+}
+
+func TestRelayClientUploadsSpans(t *testing.T) {
+	relay := newFakeRelay(t, "s3cret", http.StatusOK)
+	c := &relayClient{addr: relay.addr, secret: "s3cret"}
+	defer c.Stop(context.Background())
+
+	for _, name := range []string{"first", "second"} {
+		if err := c.UploadTraces(context.Background(), testResourceSpans(name)); err != nil {
+			t.Fatalf("UploadTraces failed: %v", err)
+		}
+		req := <-relay.requests
+		if got := req.ResourceSpans[0].ScopeSpans[0].Spans[0].Name; got != name {
+			t.Errorf("relay received span %q, want %q", got, name)
+		}
+	}
+}
+
+func TestRelayClientWrongSecret(t *testing.T) {
+	relay := newFakeRelay(t, "s3cret", http.StatusOK)
+	c := &relayClient{addr: relay.addr, secret: "guess"}
+	defer c.Stop(context.Background())
+
+	err := c.UploadTraces(context.Background(), testResourceSpans("span"))
+	if err == nil || !strings.Contains(err.Error(), "rejected the secret") {
+		t.Errorf("UploadTraces = %v, want the secret rejected", err)
+	}
+	if c.conn != nil {
+		t.Errorf("the rejected connection wasn't closed")
+	}
+}
+
+func TestRelayClientReportsLogfireStatus(t *testing.T) {
+	relay := newFakeRelay(t, "", http.StatusTooManyRequests)
+	c := &relayClient{addr: relay.addr}
+	defer c.Stop(context.Background())
+
+	err := c.UploadTraces(context.Background(), testResourceSpans("span"))
+	if err == nil || !strings.Contains(err.Error(), "status 429") {
+		t.Errorf("UploadTraces = %v, want Logfire's status", err)
+	}
+}
+
+func TestTracesURL(t *testing.T) {
+	if got := TracesURL(DefaultEndpoint, false); got != "https://logfire-api.pydantic.dev/v1/traces" {
+		t.Errorf("TracesURL(DefaultEndpoint, false) = %q", got)
+	}
+	if got := TracesURL("http://collector:4318", true); got != "http://collector:4318/v1/traces" {
+		t.Errorf("TracesURL(collector, true) = %q", got)
+	}
+}
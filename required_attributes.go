@@ -0,0 +1,100 @@
+package logfire
+
+import (
+	"log"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// maxWarnedSpans is the most span names and log templates warned about, so names with
+// high cardinality can't grow the set warned about without bound.
+const maxWarnedSpans = 1000
+
+// MissingAttributesHook is called with every span that is missing required attributes,
+// and the keys that are missing.  The span is dropped if it returns false.
+type MissingAttributesHook func(span sdktrace.ReadOnlySpan, missing []string) bool
+
+// requiredAttributes checks that spans have every required attribute.
+type requiredAttributes struct {
+	keys []attribute.Key
+	hook MissingAttributesHook
+
+	mu sync.Mutex
+	// warned is the span names, and the templates of logs, that have already been warned
+	// about.
+	warned map[string]struct{}
+}
+
+func newRequiredAttributes(keys []string, hook MissingAttributesHook) *requiredAttributes {
+	r := &requiredAttributes{hook: hook, warned: make(map[string]struct{})}
+	for _, key := range keys {
+		r.keys = append(r.keys, attribute.Key(key))
+	}
+	if r.hook == nil {
+		r.hook = r.warn
+	}
+	return r
+}
+
+// check reports whether the span should be exported.  The lifecycle spans of the
+// service are never checked.
+func (r *requiredAttributes) check(s sdktrace.ReadOnlySpan) bool {
+	if r == nil || s.Name() == "service.start" || s.Name() == "service.stop" {
+		return true
+	}
+	var missing []string
+	for _, key := range r.keys {
+		if !hasAttribute(s.Attributes(), key) {
+			missing = append(missing, string(key))
+		}
+	}
+	if len(missing) == 0 {
+		return true
+	}
+	return r.hook(s, missing)
+}
+
+// warn warns about the first span with each name, or log with each template, that is
+// missing attributes, and exports it anyway.  Logs are named after their message, so
+// they're told apart by template instead.
+func (r *requiredAttributes) warn(s sdktrace.ReadOnlySpan, missing []string) bool {
+	key := s.Name()
+	if isLogSpan(s.Attributes()) {
+		key = "log " + attributeString(s.Attributes(), "logfire.msg_template")
+	}
+
+	r.mu.Lock()
+	_, warned := r.warned[key]
+	if !warned && len(r.warned) < maxWarnedSpans {
+		r.warned[key] = struct{}{}
+	} else {
+		warned = true
+	}
+	r.mu.Unlock()
+
+	if !warned {
+		log.Printf("logfire: span %q is missing required attributes %v", s.Name(), missing)
+	}
+	return true
+}
+
+// attributeString returns the string value of the attribute with the key, or "".
+func attributeString(attrs []attribute.KeyValue, key attribute.Key) string {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value.AsString()
+		}
+	}
+	return ""
+}
+
+func hasAttribute(attrs []attribute.KeyValue, key attribute.Key) bool {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return true
+		}
+	}
+	return false
+}
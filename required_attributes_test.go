@@ -0,0 +1,65 @@
+package logfire
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// captureLog returns the standard logger's output until the test ends.
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestRequiredAttributesWarnsOncePerName(t *testing.T) {
+	buf := captureLog(t)
+	r := newRequiredAttributes([]string{"tenant.id"}, nil)
+
+	for range 3 {
+		if !r.check(tracetest.SpanStub{Name: "checkout"}.Snapshot()) {
+			t.Fatalf("check dropped a span, want the default hook to export it")
+		}
+	}
+	r.check(tracetest.SpanStub{Name: "refund", Attributes: []attribute.KeyValue{attribute.String("tenant.id", "acme")}}.Snapshot())
+	if got := strings.Count(buf.String(), "missing required attributes"); got != 1 {
+		t.Errorf("warned %d times, want once for checkout:\n%s", got, buf)
+	}
+}
+
+func TestRequiredAttributesWarnsOncePerLogTemplate(t *testing.T) {
+	buf := captureLog(t)
+	r := newRequiredAttributes([]string{"tenant.id"}, nil)
+
+	for i := range 100 {
+		r.check(tracetest.SpanStub{
+			Name: fmt.Sprintf("user %d signed in", i),
+			Attributes: []attribute.KeyValue{
+				attribute.String("logfire.span_type", "log"),
+				attribute.String("logfire.msg_template", "user {id} signed in"),
+			},
+		}.Snapshot())
+	}
+	if got := strings.Count(buf.String(), "missing required attributes"); got != 1 {
+		t.Errorf("warned %d times, want once for the template", got)
+	}
+}
+
+func TestRequiredAttributesCapsWarnings(t *testing.T) {
+	captureLog(t)
+	r := newRequiredAttributes([]string{"tenant.id"}, nil)
+	for i := range maxWarnedSpans + 10 {
+		r.check(tracetest.SpanStub{Name: fmt.Sprintf("span %d", i)}.Snapshot())
+	}
+	if got := len(r.warned); got != maxWarnedSpans {
+		t.Errorf("remembered %d warnings, want at most %d", got, maxWarnedSpans)
+	}
+}
@@ -0,0 +1,121 @@
+package logfire
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// maxBeaconBytes is the largest body accepted by RUMJoinHandler.
+const maxBeaconBytes = 64 * 1024
+
+// rumBeacon is a timing or log sent by frontend code to RUMJoinHandler.
+type rumBeacon struct {
+	// TraceParent is the W3C traceparent of the backend span the beacon belongs to.
+	TraceParent string `json:"traceparent"`
+	// Name is the name of the span, or the message of the log.
+	Name string `json:"name"`
+	// Start is when the timing started, or the time of the log, in milliseconds since
+	// the Unix epoch, e.g. performance.timeOrigin + entry.startTime.
+	Start float64 `json:"start"`
+	// Duration is how long the timing took, in milliseconds.
+	Duration float64 `json:"duration"`
+	// Level is the level of a log, e.g. "error".  Beacons without a level are spans.
+	Level string `json:"level"`
+	// Attributes are added to the span or log.
+	Attributes map[string]any `json:"attributes"`
+}
+
+// RUMJoinHandler returns an http.Handler that accepts beacons from frontend code, and
+// records them as spans or logs in the backend traces they name, so simple frontend
+// timings can be seen alongside the requests they caused.  Serve the traceparent of the
+// request's span to the page, e.g. in a <meta> tag, and POST beacons, one JSON object
+// or an array of them, e.g. with navigator.sendBeacon:
+//
+//	{
+//	  "traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+//	  "name": "page load",
+//	  "start": 1726826400000,
+//	  "duration": 1234.5,
+//	  "attributes": {"page": "/checkout"}
+//	}
+//
+// Beacons with a level, such as "error", are recorded as logs at start instead.  The
+// handler responds with 204, or 400 if the body isn't valid.  It doesn't handle CORS, so
+// wrap it in CORS middleware if the frontend is served from another origin.
+func RUMJoinHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		beacons, err := readBeacons(http.MaxBytesReader(w, r.Body, maxBeaconBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, beacon := range beacons {
+			recordBeacon(r, beacon)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// readBeacons decodes a beacon, or an array of beacons.
+func readBeacons(body io.Reader) ([]rumBeacon, error) {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	var beacons []rumBeacon
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		err = json.Unmarshal(b, &beacons)
+	} else {
+		beacons = make([]rumBeacon, 1)
+		err = json.Unmarshal(b, &beacons[0])
+	}
+	return beacons, err
+}
+
+// recordBeacon records a beacon as a span or log in the trace of its traceparent.
+func recordBeacon(r *http.Request, beacon rumBeacon) {
+	ctx := propagation.TraceContext{}.Extract(r.Context(), propagation.MapCarrier{
+		"traceparent": beacon.TraceParent,
+	})
+	start := time.UnixMicro(int64(beacon.Start * 1000))
+	if beacon.Start <= 0 {
+		start = globalClock.Now()
+	}
+	var attrs []attribute.KeyValue
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, attribute.String("user_agent.original", ua))
+	}
+	for key, value := range beacon.Attributes {
+		attrs = append(attrs, Flatten(key, value)...)
+	}
+	name := beacon.Name
+	if name == "" {
+		name = "rum beacon"
+	}
+
+	if beacon.Level != "" {
+		level, ok := ParseLevel(beacon.Level)
+		if !ok {
+			level = LevelInfo
+		}
+		FromContext(ctx).Log(level, name, WithAttributes(attrs...), WithTimestamp(start))
+		return
+	}
+	_, span := Tracer().Start(ctx, name,
+		oteltrace.WithTimestamp(start),
+		oteltrace.WithAttributes(attrs...),
+	)
+	span.End(oteltrace.WithTimestamp(start.Add(time.Duration(beacon.Duration * float64(time.Millisecond)))))
+}
@@ -0,0 +1,111 @@
+package logfire
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans initializes Logfire to export to an in-memory exporter until the test ends.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	closer, err := Initialize(context.Background(), WithServiceName("test"), WithSpanExporter(exporter))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(closer)
+	if err := Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	exporter.Reset()
+	return exporter
+}
+
+func postBeacons(t *testing.T, method, body string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	RUMJoinHandler().ServeHTTP(w, httptest.NewRequest(method, "/rum", strings.NewReader(body)))
+	if err := Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	return w.Code
+}
+
+const rumTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestRUMJoinHandlerRecordsSpans(t *testing.T) {
+	exporter := recordSpans(t)
+	body := `{"traceparent": "` + rumTraceParent + `", "name": "page load", "start": 1726826400000, "duration": 1234.5, "attributes": {"page": "/checkout"}}`
+	if code := postBeacons(t, http.MethodPost, body); code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", code)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want the beacon", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "page load" {
+		t.Errorf("span name = %q, want page load", span.Name)
+	}
+	if span.Parent.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("span parent = %v, want the span of the traceparent", span.Parent)
+	}
+	if want := time.UnixMilli(1726826400000); !span.StartTime.Equal(want) {
+		t.Errorf("span start = %v, want %v", span.StartTime, want)
+	}
+	if got := span.EndTime.Sub(span.StartTime); got != 1234500*time.Microsecond {
+		t.Errorf("span duration = %v, want 1.2345s", got)
+	}
+	if got := attributeString(span.Attributes, "page"); got != "/checkout" {
+		t.Errorf("page attribute = %q, want /checkout", got)
+	}
+}
+
+func TestRUMJoinHandlerRecordsLogs(t *testing.T) {
+	exporter := recordSpans(t)
+	body := `[
+		{"traceparent": "` + rumTraceParent + `", "name": "checkout failed", "level": "error"},
+		{"traceparent": "` + rumTraceParent + `", "name": "clicked", "level": "shouting"}
+	]`
+	if code := postBeacons(t, http.MethodPost, body); code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", code)
+	}
+
+	levels := map[string]int64{}
+	for _, span := range exporter.GetSpans() {
+		if span.Parent.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("log %q is in trace %v, want the trace of the traceparent", span.Name, span.Parent.TraceID())
+		}
+		for _, kv := range span.Attributes {
+			if kv.Key == "logfire.level_num" {
+				levels[span.Name] = kv.Value.AsInt64()
+			}
+		}
+	}
+	if levels["checkout failed"] != int64(LevelError) || levels["clicked"] != int64(LevelInfo) {
+		t.Errorf("log levels = %v, want error, and info for an unknown level", levels)
+	}
+}
+
+func TestRUMJoinHandlerRejectsInvalidRequests(t *testing.T) {
+	exporter := recordSpans(t)
+	if code := postBeacons(t, http.MethodGet, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("status of a GET = %d, want 405", code)
+	}
+	if code := postBeacons(t, http.MethodPost, `{"name": `); code != http.StatusBadRequest {
+		t.Errorf("status of invalid JSON = %d, want 400", code)
+	}
+	if code := postBeacons(t, http.MethodPost, `{"name": "`+strings.Repeat("x", maxBeaconBytes)+`"}`); code != http.StatusBadRequest {
+		t.Errorf("status of an oversized body = %d, want 400", code)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("recorded %d spans for invalid requests, want none", len(spans))
+	}
+}
@@ -0,0 +1,131 @@
+package logfire
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"runtime/metrics"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// skippedExpvars are published by the standard library, and are better covered by
+// runtime/metrics.
+var skippedExpvars = map[string]bool{
+	"cmdline":  true,
+	"memstats": true,
+}
+
+// registerRuntimeMetrics reports expvar values and runtime/metrics counters whenever
+// metrics are collected.
+func registerRuntimeMetrics(meter metric.Meter) error {
+	if err := registerGoRuntimeMetrics(meter); err != nil {
+		return err
+	}
+	return registerExpvarMetrics(meter)
+}
+
+// registerGoRuntimeMetrics reports every scalar runtime/metrics value, e.g.
+// /sched/goroutines:goroutines as go.sched.goroutines.  Histograms are skipped.
+func registerGoRuntimeMetrics(meter metric.Meter) error {
+	var (
+		samples     []metrics.Sample
+		instruments []metric.Float64Observable
+		observables []metric.Observable
+	)
+	for _, desc := range metrics.All() {
+		if desc.Kind != metrics.KindUint64 && desc.Kind != metrics.KindFloat64 {
+			continue
+		}
+		name, unit := runtimeMetricName(desc.Name)
+		var (
+			instrument metric.Float64Observable
+			err        error
+		)
+		if desc.Cumulative {
+			instrument, err = meter.Float64ObservableCounter(name,
+				metric.WithDescription(desc.Description),
+				metric.WithUnit(unit),
+			)
+		} else {
+			instrument, err = meter.Float64ObservableGauge(name,
+				metric.WithDescription(desc.Description),
+				metric.WithUnit(unit),
+			)
+		}
+		if err != nil {
+			return err
+		}
+		samples = append(samples, metrics.Sample{Name: desc.Name})
+		instruments = append(instruments, instrument)
+		observables = append(observables, instrument)
+	}
+
+	_, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		metrics.Read(samples)
+		for i, sample := range samples {
+			switch sample.Value.Kind() {
+			case metrics.KindUint64:
+				o.ObserveFloat64(instruments[i], float64(sample.Value.Uint64()))
+			case metrics.KindFloat64:
+				o.ObserveFloat64(instruments[i], sample.Value.Float64())
+			}
+		}
+		return nil
+	}, observables...)
+	return err
+}
+
+// runtimeMetricName converts a runtime/metrics name, such as /gc/heap/allocs:bytes,
+// to a metric name and unit, such as go.gc.heap.allocs and By.
+func runtimeMetricName(name string) (string, string) {
+	path, unit, _ := strings.Cut(name, ":")
+	path = "go" + strings.ReplaceAll(path, "/", ".")
+	switch unit {
+	case "bytes":
+		unit = "By"
+	case "seconds":
+		unit = "s"
+	default:
+		unit = "{" + unit + "}"
+	}
+	return path, unit
+}
+
+// registerExpvarMetrics reports every numeric value published with expvar as the
+// expvar metric, with the dotted path of the value in the expvar.name attribute.
+// Maps and JSON objects are flattened, and booleans are reported as 0 or 1.
+func registerExpvarMetrics(meter metric.Meter) error {
+	_, err := meter.Float64ObservableGauge("expvar",
+		metric.WithDescription("Numeric values published with expvar."),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			expvar.Do(func(kv expvar.KeyValue) {
+				if skippedExpvars[kv.Key] {
+					return
+				}
+				var value any
+				if err := json.Unmarshal([]byte(kv.Value.String()), &value); err != nil {
+					return
+				}
+				for _, attr := range Flatten(kv.Key, value) {
+					var v float64
+					switch attr.Value.Type() {
+					case attribute.FLOAT64:
+						v = attr.Value.AsFloat64()
+					case attribute.BOOL:
+						if attr.Value.AsBool() {
+							v = 1
+						}
+					default:
+						continue
+					}
+					o.Observe(v, metric.WithAttributes(attribute.String("expvar.name", string(attr.Key))))
+				}
+			})
+			return nil
+		}),
+	)
+	return err
+}
@@ -0,0 +1,302 @@
+package logfire
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// routeSampler samples root spans at a rate keyed by the span name or HTTP route.
+// Spans that don't match a route are delegated to the fallback sampler.
+type routeSampler struct {
+	routes   map[string]sdktrace.Sampler
+	fallback sdktrace.Sampler
+}
+
+var _ sdktrace.Sampler = (*routeSampler)(nil)
+
+func newRouteSampler(rates map[string]float64, fallback sdktrace.Sampler) *routeSampler {
+	routes := make(map[string]sdktrace.Sampler, len(rates))
+	for route, rate := range rates {
+		routes[route] = sdktrace.TraceIDRatioBased(rate)
+	}
+	return &routeSampler{
+		routes:   routes,
+		fallback: fallback,
+	}
+}
+
+// ShouldSample delegates to the ratio sampler of the matching route, preferring the
+// http.route attribute over the span name.
+func (s *routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, kv := range p.Attributes {
+		if kv.Key == semconv.HTTPRouteKey && kv.Value.Type() == attribute.STRING {
+			if sampler, ok := s.routes[kv.Value.AsString()]; ok {
+				return sampler.ShouldSample(p)
+			}
+		}
+	}
+	if sampler, ok := s.routes[p.Name]; ok {
+		return sampler.ShouldSample(p)
+	}
+	// Integrations commonly name spans "METHOD /route".
+	if _, route, ok := strings.Cut(p.Name, " "); ok {
+		if sampler, ok := s.routes[route]; ok {
+			return sampler.ShouldSample(p)
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+// Description returns the description of the sampler.
+func (s *routeSampler) Description() string {
+	return fmt.Sprintf("RouteSampler{routes:%d,fallback:%s}", len(s.routes), s.fallback.Description())
+}
+
+// scheduleSampling is the config of a scheduleSampler.
+type scheduleSampling struct {
+	// Window is the daily window, e.g. "02:00-03:00".
+	Window string
+	// Rate is the ratio of traces sampled during the window.
+	Rate float64
+	// DefaultRate is the ratio of traces sampled outside the window.
+	DefaultRate float64
+}
+
+// scheduleSampler samples root spans at one rate during a daily window, and at another
+// rate outside it.
+type scheduleSampler struct {
+	// start and end are the time of day the window starts and ends.
+	start, end time.Duration
+	in, out    sdktrace.Sampler
+	clock      Clock
+}
+
+var _ sdktrace.Sampler = (*scheduleSampler)(nil)
+
+func newScheduleSampler(config scheduleSampling, clock Clock) (*scheduleSampler, error) {
+	from, to, ok := strings.Cut(config.Window, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid sampling window %q, expected e.g. \"02:00-03:00\"", config.Window)
+	}
+	start, err := parseTimeOfDay(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sampling window %q: %w", config.Window, err)
+	}
+	end, err := parseTimeOfDay(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sampling window %q: %w", config.Window, err)
+	}
+	return &scheduleSampler{
+		start: start,
+		end:   end,
+		in:    sdktrace.TraceIDRatioBased(config.Rate),
+		out:   sdktrace.TraceIDRatioBased(config.DefaultRate),
+		clock: clock,
+	}, nil
+}
+
+// parseTimeOfDay parses a time of day, e.g. "02:00", as the time since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// inWindow reports whether t is in the window.  Windows that end before they start,
+// e.g. "23:00-01:00", wrap around midnight.
+func (s *scheduleSampler) inWindow(t time.Time) bool {
+	hour, minute, second := t.Clock()
+	now := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+	if s.start <= s.end {
+		return now >= s.start && now < s.end
+	}
+	return now >= s.start || now < s.end
+}
+
+// ShouldSample delegates to the sampler of the window, if the current time is in it,
+// or the default sampler otherwise.
+func (s *scheduleSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if s.inWindow(s.clock.Now()) {
+		return s.in.ShouldSample(p)
+	}
+	return s.out.ShouldSample(p)
+}
+
+// Description returns the description of the sampler.
+func (s *scheduleSampler) Description() string {
+	return fmt.Sprintf("ScheduleSampler{window:%v-%v,in:%s,out:%s}", s.start, s.end, s.in.Description(), s.out.Description())
+}
+
+// maxWarmupNames is the most span names a warmupSampler counts, so span names with
+// high cardinality can't grow it without bound.
+const maxWarmupNames = 10000
+
+// warmupSampling is the config of a warmupSampler.
+type warmupSampling struct {
+	// N is how many traces of each span name are always sampled.
+	N int
+	// Rate is the ratio of traces sampled after the first N.
+	Rate float64
+}
+
+// warmupSampler samples the first n root spans of each span name, and delegates every
+// later span.  Logs are always delegated, because they are named after their message,
+// which would fill the names counted with messages rather than operations.
+type warmupSampler struct {
+	n    int
+	next sdktrace.Sampler
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+var _ sdktrace.Sampler = (*warmupSampler)(nil)
+
+func newWarmupSampler(n int, next sdktrace.Sampler) *warmupSampler {
+	return &warmupSampler{
+		n:      n,
+		next:   next,
+		counts: make(map[string]int),
+	}
+}
+
+// warm reports whether the first n spans with the name have already been sampled, and
+// counts the span if not.
+func (s *warmupSampler) warm(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, ok := s.counts[name]
+	if !ok && len(s.counts) >= maxWarmupNames {
+		return true
+	}
+	if count >= s.n {
+		return true
+	}
+	s.counts[name] = count + 1
+	return false
+}
+
+// ShouldSample samples the span if fewer than n spans with its name have been
+// sampled, and delegates it otherwise.
+func (s *warmupSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if isLogSpan(p.Attributes) || s.warm(p.Name) {
+		return s.next.ShouldSample(p)
+	}
+	return sdktrace.AlwaysSample().ShouldSample(p)
+}
+
+// Description returns the description of the sampler.
+func (s *warmupSampler) Description() string {
+	return fmt.Sprintf("WarmupSampler{n:%d,next:%s}", s.n, s.next.Description())
+}
+
+// isLogSpan reports whether the attributes a span started with are those of a log.
+func isLogSpan(attrs []attribute.KeyValue) bool {
+	for _, kv := range attrs {
+		if kv.Key == "logfire.span_type" {
+			return kv.Value.AsString() == "log"
+		}
+	}
+	return false
+}
+
+// SamplingPriority is how important it is that a span is exported.
+type SamplingPriority int
+
+const (
+	// SamplingPriorityNormal spans are sampled by the configured samplers.
+	SamplingPriorityNormal SamplingPriority = iota
+	// SamplingPriorityHigh spans are always sampled.
+	SamplingPriorityHigh
+)
+
+// samplingPriorityKey is the attribute that sets the SamplingPriority of a span.
+const samplingPriorityKey = attribute.Key("logfire.sampling_priority")
+
+// WithSamplingPriority sets the sampling priority of a span.  Spans with
+// SamplingPriorityHigh, e.g. for payments or authentication, are always exported, along
+// with the spans nested in them, regardless of sampling.
+func WithSamplingPriority(priority SamplingPriority) SpanOption {
+	return WithAttributes(samplingPriorityKey.Int(int(priority)))
+}
+
+type forceSampleKey struct{}
+
+// ForceSample returns a context in which every span started is sampled, as if it had
+// SamplingPriorityHigh.  A span forced in a trace that wasn't sampled is exported
+// without the spans above it.
+func ForceSample(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceSampleKey{}, true)
+}
+
+// prioritySampler always samples spans with a high sampling priority, and delegates
+// every other span.
+type prioritySampler struct {
+	next sdktrace.Sampler
+}
+
+var _ sdktrace.Sampler = prioritySampler{}
+
+// ShouldSample samples spans forced by ForceSample or WithSamplingPriority.
+func (s prioritySampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	forced, _ := p.ParentContext.Value(forceSampleKey{}).(bool)
+	for _, kv := range p.Attributes {
+		if kv.Key == samplingPriorityKey && kv.Value.AsInt64() >= int64(SamplingPriorityHigh) {
+			forced = true
+		}
+	}
+	if !forced {
+		return s.next.ShouldSample(p)
+	}
+	return sdktrace.SamplingResult{
+		Decision:   sdktrace.RecordAndSample,
+		Tracestate: oteltrace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+// Description returns the description of the sampler.
+func (s prioritySampler) Description() string {
+	return fmt.Sprintf("PrioritySampler{%s}", s.next.Description())
+}
+
+// newSampler builds the sampler for the given config.  Root spans go through each
+// configured sampler in turn, until one of them decides:
+//
+//  1. The route sampler, for spans matching a route of WithRouteSampling.
+//  2. The warmup sampler, for the first spans of each name.
+//  3. The schedule sampler, at the rate of the window or the default rate.
+//  4. The warmup rate, if there is no schedule, or else every span is sampled.
+//
+// Child spans always follow the sampling decision of their parent, unless they have a
+// high sampling priority.
+func newSampler(config *config) (sdktrace.Sampler, error) {
+	var root sdktrace.Sampler = sdktrace.AlwaysSample()
+	if config.WarmupSampling != nil {
+		root = sdktrace.TraceIDRatioBased(config.WarmupSampling.Rate)
+	}
+	if config.ScheduleSampling != nil {
+		schedule, err := newScheduleSampler(*config.ScheduleSampling, config.Clock)
+		if err != nil {
+			return nil, err
+		}
+		root = schedule
+	}
+	if config.WarmupSampling != nil {
+		root = newWarmupSampler(config.WarmupSampling.N, root)
+	}
+	if len(config.RouteSampling) > 0 {
+		root = newRouteSampler(config.RouteSampling, root)
+	}
+	return prioritySampler{next: sdktrace.ParentBased(root)}, nil
+}
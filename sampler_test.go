@@ -0,0 +1,165 @@
+package logfire
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// fixedClock is a Clock that is always at now.
+type fixedClock struct {
+	systemClock
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+func sampled(s sdktrace.Sampler, name string, attrs ...attribute.KeyValue) bool {
+	result := s.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: context.Background(),
+		TraceID:       oteltrace.TraceID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		Name:          name,
+		Attributes:    attrs,
+	})
+	return result.Decision == sdktrace.RecordAndSample
+}
+
+func TestRouteSampler(t *testing.T) {
+	s := newRouteSampler(map[string]float64{"/health": 0, "/orders": 1}, sdktrace.NeverSample())
+	tests := []struct {
+		name  string
+		attrs []attribute.KeyValue
+		want  bool
+	}{
+		{"/orders", nil, true},
+		{"/health", nil, false},
+		{"GET /health", nil, false},
+		{"GET /orders", nil, true},
+		{"handler", []attribute.KeyValue{semconv.HTTPRouteKey.String("/health")}, false},
+		{"/health", []attribute.KeyValue{semconv.HTTPRouteKey.String("/orders")}, true},
+		{"/unknown", nil, false},
+	}
+	for _, tt := range tests {
+		if got := sampled(s, tt.name, tt.attrs...); got != tt.want {
+			t.Errorf("sampling %q with %v = %v, want %v", tt.name, tt.attrs, got, tt.want)
+		}
+	}
+}
+
+func TestRouteSamplerFallback(t *testing.T) {
+	s := newRouteSampler(map[string]float64{"/health": 0}, newWarmupSampler(1, sdktrace.NeverSample()))
+	if !sampled(s, "/orders") {
+		t.Errorf("sampling the first unlisted span = false, want the fallback's warmup sample")
+	}
+	if sampled(s, "/orders") {
+		t.Errorf("sampling the second unlisted span = true, want the fallback's rate of 0")
+	}
+}
+
+func TestPrioritySampler(t *testing.T) {
+	s := prioritySampler{next: sdktrace.NeverSample()}
+	if sampled(s, "span") {
+		t.Errorf("sampling a normal span = true, want false")
+	}
+	if !sampled(s, "span", samplingPriorityKey.Int(int(SamplingPriorityHigh))) {
+		t.Errorf("sampling a high priority span = false, want true")
+	}
+
+	result := s.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: ForceSample(context.Background()),
+		Name:          "span",
+	})
+	if result.Decision != sdktrace.RecordAndSample {
+		t.Errorf("sampling a span in a ForceSample context = %v, want RecordAndSample", result.Decision)
+	}
+}
+
+func TestScheduleSampler(t *testing.T) {
+	tests := []struct {
+		window string
+		at     string
+		want   bool
+	}{
+		{"02:00-03:00", "02:00", true},
+		{"02:00-03:00", "02:59", true},
+		{"02:00-03:00", "03:00", false},
+		{"02:00-03:00", "01:59", false},
+		{"23:00-01:00", "23:30", true},
+		{"23:00-01:00", "00:30", true},
+		{"23:00-01:00", "01:30", false},
+		{"23:00-01:00", "12:00", false},
+	}
+	for _, tt := range tests {
+		at, err := time.Parse("15:04", tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clock := fixedClock{now: time.Date(2024, 9, 20, at.Hour(), at.Minute(), 0, 0, time.UTC)}
+		s, err := newScheduleSampler(scheduleSampling{Window: tt.window, Rate: 1, DefaultRate: 0}, clock)
+		if err != nil {
+			t.Fatalf("newScheduleSampler(%q) failed: %v", tt.window, err)
+		}
+		if got := sampled(s, "span"); got != tt.want {
+			t.Errorf("sampling at %s in window %s = %v, want %v", tt.at, tt.window, got, tt.want)
+		}
+	}
+
+	for _, window := range []string{"", "02:00", "2am-3am", "02:00-25:00"} {
+		if _, err := newScheduleSampler(scheduleSampling{Window: window}, systemClock{}); err == nil {
+			t.Errorf("newScheduleSampler(%q) succeeded, want an error", window)
+		}
+	}
+}
+func TestWarmupSampler(t *testing.T) {
+	s := newWarmupSampler(2, sdktrace.NeverSample())
+	for i, want := range []bool{true, true, false, false} {
+		if got := sampled(s, "checkout"); got != want {
+			t.Errorf("sampling checkout span %d = %v, want %v", i, got, want)
+		}
+	}
+	if !sampled(s, "refund") {
+		t.Errorf("sampling the first refund span = false, want true")
+	}
+}
+
+func TestWarmupSamplerSkipsLogs(t *testing.T) {
+	s := newWarmupSampler(1, sdktrace.NeverSample())
+	logType := attribute.String("logfire.span_type", "log")
+	for i := range maxWarmupNames + 1 {
+		if sampled(s, fmt.Sprintf("user %d signed in", i), logType) {
+			t.Fatalf("sampling log %d = true, want it delegated", i)
+		}
+	}
+	if !sampled(s, "checkout") {
+		t.Errorf("sampling the first checkout span after many logs = false, want true")
+	}
+}
+
+func TestNewSampler(t *testing.T) {
+	c := &config{
+		Clock:          systemClock{},
+		RouteSampling:  map[string]float64{"/health": 0},
+		WarmupSampling: &warmupSampling{N: 1, Rate: 0},
+	}
+	s, err := newSampler(c)
+	if err != nil {
+		t.Fatalf("newSampler failed: %v", err)
+	}
+	if sampled(s, "/health") {
+		t.Errorf("sampling /health = true, want the route rate of 0")
+	}
+	if !sampled(s, "/orders") || sampled(s, "/orders") {
+		t.Errorf("want only the first /orders span sampled by warmup sampling")
+	}
+
+	c.ScheduleSampling = &scheduleSampling{Window: "nonsense"}
+	if _, err := newSampler(c); err == nil {
+		t.Errorf("newSampler with an invalid window succeeded, want an error")
+	}
+}
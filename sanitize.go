@@ -0,0 +1,130 @@
+package logfire
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// maxAttributeLength is the longest string attribute value, in bytes.  Longer
+	// values are truncated.
+	maxAttributeLength = 1 << 20
+	// maxAttributeSliceLength is the most elements of a slice attribute value.
+	maxAttributeSliceLength = 1024
+	truncatedSuffix         = "...truncated"
+)
+
+// SanitizeAttribute returns kv in a form every exporter can encode:
+//
+//   - Invalid UTF-8 in keys and strings is replaced with U+FFFD.
+//   - NaN and infinite floats are converted to the strings "NaN", "+Inf" and "-Inf".
+//   - Strings longer than 1 MiB are truncated, and end with "...truncated".
+//   - Slices are truncated to 1024 elements.
+//
+// Every span is sanitized before it is exported, SanitizeAttribute is exposed for
+// integrations that encode attributes themselves.
+func SanitizeAttribute(kv attribute.KeyValue) attribute.KeyValue {
+	key := attribute.Key(sanitizeString(string(kv.Key)))
+	switch kv.Value.Type() {
+	case attribute.STRING:
+		return key.String(sanitizeString(kv.Value.AsString()))
+	case attribute.FLOAT64:
+		f := kv.Value.AsFloat64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return key.String(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+		return key.Float64(f)
+	case attribute.STRINGSLICE:
+		values := truncateSlice(kv.Value.AsStringSlice())
+		for i, v := range values {
+			values[i] = sanitizeString(v)
+		}
+		return key.StringSlice(values)
+	case attribute.FLOAT64SLICE:
+		values := truncateSlice(kv.Value.AsFloat64Slice())
+		for _, f := range values {
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				strs := make([]string, len(values))
+				for i, f := range values {
+					strs[i] = strconv.FormatFloat(f, 'g', -1, 64)
+				}
+				return key.StringSlice(strs)
+			}
+		}
+		return key.Float64Slice(values)
+	case attribute.BOOLSLICE:
+		return key.BoolSlice(truncateSlice(kv.Value.AsBoolSlice()))
+	case attribute.INT64SLICE:
+		return key.Int64Slice(truncateSlice(kv.Value.AsInt64Slice()))
+	}
+	return attribute.KeyValue{Key: key, Value: kv.Value}
+}
+
+// SanitizeAttributes applies SanitizeAttribute to every attribute.
+func SanitizeAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	sanitized := make([]attribute.KeyValue, len(attrs))
+	for i, kv := range attrs {
+		sanitized[i] = SanitizeAttribute(kv)
+	}
+	return sanitized
+}
+
+// sanitizeString replaces invalid UTF-8, and truncates s to maxAttributeLength.
+func sanitizeString(s string) string {
+	s = strings.ToValidUTF8(s, "�")
+	if len(s) <= maxAttributeLength {
+		return s
+	}
+	end := maxAttributeLength - len(truncatedSuffix)
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + truncatedSuffix
+}
+
+func truncateSlice[T any](values []T) []T {
+	if len(values) > maxAttributeSliceLength {
+		return values[:maxAttributeSliceLength]
+	}
+	return values
+}
+
+// sanitizedSpan is a span with sanitized attributes and events.
+type sanitizedSpan struct {
+	sdktrace.ReadOnlySpan
+	name   string
+	attrs  []attribute.KeyValue
+	events []sdktrace.Event
+}
+
+// sanitizeSpan returns s with its name, attributes and events sanitized, and only the
+// attributes allowed by policy.
+func sanitizeSpan(s sdktrace.ReadOnlySpan, policy *attributePolicy) sdktrace.ReadOnlySpan {
+	events := s.Events()
+	sanitized := make([]sdktrace.Event, len(events))
+	for i, e := range events {
+		e.Name = sanitizeString(e.Name)
+		e.Attributes = SanitizeAttributes(policy.filter(e.Attributes))
+		sanitized[i] = e
+	}
+	return &sanitizedSpan{
+		ReadOnlySpan: s,
+		name:         sanitizeString(s.Name()),
+		attrs:        SanitizeAttributes(policy.filter(s.Attributes())),
+		events:       sanitized,
+	}
+}
+
+// Name returns the sanitized name of the span.
+func (s *sanitizedSpan) Name() string { return s.name }
+
+// Attributes returns the sanitized attributes of the span.
+func (s *sanitizedSpan) Attributes() []attribute.KeyValue { return s.attrs }
+
+// Events returns the sanitized events of the span.
+func (s *sanitizedSpan) Events() []sdktrace.Event { return s.events }
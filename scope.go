@@ -0,0 +1,52 @@
+package logfire
+
+import (
+	"go.opentelemetry.io/otel"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// scopeConfig is the instrumentation scope of a tracer.
+type scopeConfig struct {
+	// Name identifies the library or component that creates the spans.
+	Name string
+	// Version is the version of the library or component.
+	Version string
+	// SchemaURL is the semantic conventions schema that the spans follow.
+	SchemaURL string
+}
+
+// ScopeOption is a function type that modifies the instrumentation scope of a tracer.
+type ScopeOption func(*scopeConfig)
+
+// WithScope sets the instrumentation scope name and version of a tracer, so spans from
+// different libraries of an application can be told apart.
+func WithScope(name, version string) ScopeOption {
+	return func(c *scopeConfig) {
+		c.Name = name
+		c.Version = version
+	}
+}
+
+// WithSchemaURL sets the semantic conventions schema that the spans of a tracer follow.
+func WithSchemaURL(url string) ScopeOption {
+	return func(c *scopeConfig) {
+		c.SchemaURL = url
+	}
+}
+
+// newTracer creates a tracer with the instrumentation scope name, modified by opts.
+func newTracer(name string, opts []ScopeOption) oteltrace.Tracer {
+	config := &scopeConfig{Name: name}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	var tracerOpts []oteltrace.TracerOption
+	if config.Version != "" {
+		tracerOpts = append(tracerOpts, oteltrace.WithInstrumentationVersion(config.Version))
+	}
+	if config.SchemaURL != "" {
+		tracerOpts = append(tracerOpts, oteltrace.WithSchemaURL(config.SchemaURL))
+	}
+	return otel.Tracer(config.Name, tracerOpts...)
+}
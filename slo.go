@@ -0,0 +1,204 @@
+package logfire
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const (
+	// sloBucketSize is the resolution the outcomes of an SLO are counted at.
+	sloBucketSize = time.Minute
+	// defaultSLOWindow is the window the burn rate of an SLO is measured over.
+	defaultSLOWindow = time.Hour
+	// minSLOEvents is the fewest outcomes in the window for the burn rate to be
+	// measured, so a single failure after a quiet period doesn't raise an alert.
+	minSLOEvents = 20
+)
+
+// SLOOption is a function type that modifies an SLOTracker.
+type SLOOption func(*SLOTracker)
+
+// WithSLOWindow sets the window the burn rate is measured over.  Defaults to an hour.
+func WithSLOWindow(window time.Duration) SLOOption {
+	return func(t *SLOTracker) {
+		t.window = window
+	}
+}
+
+// WithBurnRateThresholds sets the burn rates at which a Warn and an Error log are
+// emitted.  Defaults to 2 and 14.4, the rate at which an hour uses 2% of a 30 day
+// error budget.
+func WithBurnRateThresholds(warn, err float64) SLOOption {
+	return func(t *SLOTracker) {
+		t.warnBurnRate = warn
+		t.errorBurnRate = err
+	}
+}
+
+// sloBucket counts the outcomes of an SLO in one sloBucketSize.
+type sloBucket struct {
+	start         time.Time
+	total, failed int
+}
+
+// SLOTracker tracks the error budget of a service level objective, and warns when it
+// is being used up too fast.
+type SLOTracker struct {
+	name          string
+	target        float64
+	window        time.Duration
+	warnBurnRate  float64
+	errorBurnRate float64
+
+	mu      sync.Mutex
+	buckets []sloBucket
+	// level is the level of the last alert, or 0 if the burn rate is fine.
+	level Level
+}
+
+// sloRegistry holds the SLOs fed from span outcomes, keyed by span name or route.
+var sloRegistry = struct {
+	sync.RWMutex
+	byName map[string][]*SLOTracker
+}{byName: make(map[string][]*SLOTracker)}
+
+// SLO tracks the objective that a target ratio, e.g. 0.999, of the spans named name, or
+// with name as their http.route, succeed.  The burn rate is how many times faster than
+// sustainable the error budget, 1 - target, is being used.  When it crosses the warn
+// or error threshold a Warn or Error log is emitted, as an early warning before
+// dashboards catch up, and an Info log once it recovers.
+//
+//	logfire.SLO("/checkout", 0.999)
+//
+// Only spans that are recorded count, so sampling makes the SLO less accurate.
+// Outcomes of other operations can be recorded with Record.
+func SLO(name string, target float64, opts ...SLOOption) *SLOTracker {
+	t := &SLOTracker{
+		name:          name,
+		target:        target,
+		window:        defaultSLOWindow,
+		warnBurnRate:  2,
+		errorBurnRate: 14.4,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.buckets = make([]sloBucket, max(int(t.window/sloBucketSize), 1))
+
+	sloRegistry.Lock()
+	sloRegistry.byName[name] = append(sloRegistry.byName[name], t)
+	sloRegistry.Unlock()
+	return t
+}
+
+// Record records the outcome of an operation.
+func (t *SLOTracker) Record(ok bool) {
+	now := globalClock.Now()
+	start := now.Truncate(sloBucketSize)
+
+	t.mu.Lock()
+	b := &t.buckets[int(now.UnixNano()/int64(sloBucketSize))%len(t.buckets)]
+	if !b.start.Equal(start) {
+		*b = sloBucket{start: start}
+	}
+	b.total++
+	if !ok {
+		b.failed++
+	}
+	burnRate, errorRate := t.burnRate(now)
+	var level Level
+	switch {
+	case burnRate >= t.errorBurnRate:
+		level = LevelError
+	case burnRate >= t.warnBurnRate:
+		level = LevelWarn
+	}
+	changed := level != t.level
+	t.level = level
+	t.mu.Unlock()
+
+	if changed && globalLogger != nil {
+		t.alert(level, burnRate, errorRate)
+	}
+}
+
+// BurnRate returns how many times faster than sustainable the error budget is being
+// used over the window, or 0 if there are too few outcomes to tell.
+func (t *SLOTracker) BurnRate() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	burnRate, _ := t.burnRate(globalClock.Now())
+	return burnRate
+}
+
+// burnRate returns the burn rate and error rate over the window ending at now.  It
+// must be called with mu held.
+func (t *SLOTracker) burnRate(now time.Time) (burnRate, errorRate float64) {
+	var total, failed int
+	for _, b := range t.buckets {
+		if now.Sub(b.start) < t.window {
+			total += b.total
+			failed += b.failed
+		}
+	}
+	if total < minSLOEvents {
+		return 0, 0
+	}
+	errorRate = float64(failed) / float64(total)
+	budget := 1 - t.target
+	if budget <= 0 {
+		if failed > 0 {
+			return math.Inf(1), errorRate
+		}
+		return 0, errorRate
+	}
+	return errorRate / budget, errorRate
+}
+
+// alert logs a change of the burn rate.
+func (t *SLOTracker) alert(level Level, burnRate, errorRate float64) {
+	attrs := WithAttributes(
+		attribute.String("slo.name", t.name),
+		attribute.Float64("slo.target", t.target),
+		attribute.Float64("slo.burn_rate", burnRate),
+		attribute.Float64("slo.error_rate", errorRate),
+		Attr("slo.window_ms", t.window),
+	)
+	if level == 0 {
+		Info(fmt.Sprintf("SLO %s burn rate recovered", t.name), attrs)
+		return
+	}
+	Log(level, fmt.Sprintf("SLO %s is using its error budget %.1fx too fast", t.name, burnRate), attrs)
+}
+
+// observeSLOs records the outcome of a span in the SLOs tracking its name or route.
+func observeSLOs(s sdktrace.ReadOnlySpan) {
+	sloRegistry.RLock()
+	if len(sloRegistry.byName) == 0 {
+		sloRegistry.RUnlock()
+		return
+	}
+	trackers := sloRegistry.byName[s.Name()]
+	for _, kv := range s.Attributes() {
+		if kv.Key == "logfire.span_type" && kv.Value.AsString() == "log" {
+			// Logs have no outcome.
+			sloRegistry.RUnlock()
+			return
+		}
+		if kv.Key == semconv.HTTPRouteKey && kv.Value.AsString() != s.Name() {
+			trackers = append(trackers[:len(trackers):len(trackers)], sloRegistry.byName[kv.Value.AsString()]...)
+		}
+	}
+	// Recording may log, which ends a span and observes it, so the lock isn't held.
+	sloRegistry.RUnlock()
+
+	for _, t := range trackers {
+		t.Record(!isError(s))
+	}
+}
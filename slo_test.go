@@ -0,0 +1,78 @@
+package logfire
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// setClock sets the global clock for the duration of the test.
+func setClock(t *testing.T, clock Clock) {
+	previous := globalClock
+	globalClock = clock
+	t.Cleanup(func() { globalClock = previous })
+}
+
+// closeTo reports whether a and b are equal, allowing for rounding.
+func closeTo(a, b float64) bool {
+	return a == b || math.Abs(a-b) < 1e-9
+}
+
+func TestSLOBurnRate(t *testing.T) {
+	now := time.Date(2024, 9, 20, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		target    float64
+		total     int
+		failed    int
+		wantRate  float64
+		wantLevel Level
+	}{
+		{"too few outcomes", 0.99, minSLOEvents - 1, 5, 0, 0},
+		{"within budget", 0.99, 100, 1, 1, 0},
+		{"warn", 0.99, 100, 5, 5, LevelWarn},
+		{"error", 0.99, 100, 20, 20, LevelError},
+		{"no budget", 1, 100, 1, math.Inf(1), LevelError},
+		{"no budget without failures", 1, 100, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setClock(t, fixedClock{now: now})
+			slo := SLO("test "+tt.name, tt.target)
+			for i := range tt.total {
+				slo.Record(i >= tt.failed)
+			}
+			if got := slo.BurnRate(); !closeTo(got, tt.wantRate) {
+				t.Errorf("BurnRate() = %v, want %v", got, tt.wantRate)
+			}
+			if slo.level != tt.wantLevel {
+				t.Errorf("alert level = %v, want %v", slo.level, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestSLOWindow(t *testing.T) {
+	now := time.Date(2024, 9, 20, 10, 0, 0, 0, time.UTC)
+	setClock(t, fixedClock{now: now})
+
+	slo := SLO("test window", 0.9, WithSLOWindow(10*time.Minute))
+	for range 50 {
+		slo.Record(false)
+	}
+	if got := slo.BurnRate(); !closeTo(got, 10) {
+		t.Fatalf("BurnRate() = %v, want 10", got)
+	}
+
+	// Once the failures are outside the window, only new outcomes count.
+	setClock(t, fixedClock{now: now.Add(15 * time.Minute)})
+	if got := slo.BurnRate(); got != 0 {
+		t.Errorf("BurnRate() after the window = %v, want 0", got)
+	}
+	for range minSLOEvents {
+		slo.Record(true)
+	}
+	if got := slo.BurnRate(); got != 0 || slo.level != 0 {
+		t.Errorf("BurnRate() after recovering = %v at level %v, want 0", got, slo.level)
+	}
+}
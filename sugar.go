@@ -0,0 +1,93 @@
+package logfire
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SugaredLogger logs with loosely typed key value pairs instead of attributes, for
+// call sites that prefer brevity over type safety:
+//
+//	logfire.Sugar().Infow("order placed", "order_id", id, "total", total)
+type SugaredLogger struct {
+	logger *SpanLogger
+	attrs  []attribute.KeyValue
+}
+
+// Sugar returns a SugaredLogger that logs with the global logger.
+func Sugar() *SugaredLogger {
+	return globalLogger.Sugar()
+}
+
+// Sugar returns a SugaredLogger that logs in the current span context.
+func (s *SpanLogger) Sugar() *SugaredLogger {
+	return &SugaredLogger{logger: s}
+}
+
+// With returns a SugaredLogger that adds keysAndValues to every log.
+func (s *SugaredLogger) With(keysAndValues ...any) *SugaredLogger {
+	return &SugaredLogger{
+		logger: s.logger,
+		attrs:  append(s.attrs[:len(s.attrs):len(s.attrs)], keyValueAttributes(keysAndValues)...),
+	}
+}
+
+// Logw sends a log with the given level, and keysAndValues as attributes.
+func (s *SugaredLogger) Logw(level Level, msg string, keysAndValues ...any) {
+	attrs := append(s.attrs[:len(s.attrs):len(s.attrs)], keyValueAttributes(keysAndValues)...)
+	s.logger.Log(level, msg, WithAttributes(attrs...))
+}
+
+// Tracew sends a trace log, with keysAndValues as attributes.
+func (s *SugaredLogger) Tracew(msg string, keysAndValues ...any) {
+	s.Logw(LevelTrace, msg, keysAndValues...)
+}
+
+// Debugw sends a debug log, with keysAndValues as attributes.
+func (s *SugaredLogger) Debugw(msg string, keysAndValues ...any) {
+	s.Logw(LevelDebug, msg, keysAndValues...)
+}
+
+// Infow sends an info log, with keysAndValues as attributes.
+func (s *SugaredLogger) Infow(msg string, keysAndValues ...any) {
+	s.Logw(LevelInfo, msg, keysAndValues...)
+}
+
+// Warnw sends a warn log, with keysAndValues as attributes.
+func (s *SugaredLogger) Warnw(msg string, keysAndValues ...any) {
+	s.Logw(LevelWarn, msg, keysAndValues...)
+}
+
+// Errorw sends an error log, with keysAndValues as attributes.
+func (s *SugaredLogger) Errorw(msg string, keysAndValues ...any) {
+	s.Logw(LevelError, msg, keysAndValues...)
+}
+
+// Fatalw sends a fatal log, with keysAndValues as attributes.
+func (s *SugaredLogger) Fatalw(msg string, keysAndValues ...any) {
+	s.Logw(LevelFatal, msg, keysAndValues...)
+}
+
+// keyValueAttributes converts alternating keys and values to attributes.  Values are
+// flattened like Flatten, and a key without a value is recorded with the value
+// "<missing>".
+func keyValueAttributes(keysAndValues []any) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		if i+1 >= len(keysAndValues) {
+			attrs = append(attrs, attribute.String(key, "<missing>"))
+			break
+		}
+		switch value := keysAndValues[i+1].(type) {
+		case attribute.Value:
+			attrs = append(attrs, attribute.KeyValue{Key: attribute.Key(key), Value: value})
+		case error:
+			attrs = append(attrs, attribute.String(key, safeString("Error", value.Error)))
+		default:
+			attrs = append(attrs, Flatten(key, value)...)
+		}
+	}
+	return attrs
+}
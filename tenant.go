@@ -0,0 +1,145 @@
+package logfire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// tenantKey is the attribute recording the tenant a span is routed to.
+const tenantKey = attribute.Key("logfire.tenant")
+
+// TenantRouter returns the tenant of the request in ctx, or "" if there is none.
+type TenantRouter func(ctx context.Context) string
+
+// tenantProcessor is a SpanProcessor that records the tenant of every span when it
+// starts, so the tenantExporter can route it.
+type tenantProcessor struct {
+	route TenantRouter
+}
+
+var _ sdktrace.SpanProcessor = (*tenantProcessor)(nil)
+
+// OnStart records the tenant of the span.  Spans without a tenant of their own inherit
+// the tenant of their parent, so a trace isn't split between projects.
+func (p *tenantProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	tenant := p.route(parent)
+	if tenant == "" {
+		tenant = parentTenant(parent)
+	}
+	if tenant != "" {
+		s.SetAttributes(tenantKey.String(tenant))
+	}
+}
+
+// OnEnd does nothing.
+func (p *tenantProcessor) OnEnd(s sdktrace.ReadOnlySpan) {}
+
+// Shutdown does nothing.
+func (p *tenantProcessor) Shutdown(ctx context.Context) error { return nil }
+
+// ForceFlush does nothing.
+func (p *tenantProcessor) ForceFlush(ctx context.Context) error { return nil }
+
+// parentTenant returns the tenant of the span in ctx, if it was started in this process.
+func parentTenant(ctx context.Context) string {
+	parent, ok := oteltrace.SpanFromContext(ctx).(sdktrace.ReadOnlySpan)
+	if !ok {
+		return ""
+	}
+	return spanTenant(parent)
+}
+
+// spanTenant returns the tenant recorded on s.
+func spanTenant(s sdktrace.ReadOnlySpan) string {
+	for _, kv := range s.Attributes() {
+		if kv.Key == tenantKey {
+			return kv.Value.AsString()
+		}
+	}
+	return ""
+}
+
+// tenantExporter is a SpanExporter that sends the spans of each tenant to the project
+// of its token, and the other spans with the default exporter.
+type tenantExporter struct {
+	sdktrace.SpanExporter
+	config *config
+	tokens map[string]string
+
+	mu        sync.Mutex
+	exporters map[string]sdktrace.SpanExporter
+}
+
+func newTenantExporter(exporter sdktrace.SpanExporter, config *config) *tenantExporter {
+	return &tenantExporter{
+		SpanExporter: exporter,
+		config:       config,
+		tokens:       config.TenantTokens,
+		exporters:    make(map[string]sdktrace.SpanExporter),
+	}
+}
+
+// ExportSpans groups the spans by tenant, and exports each group to its project.
+func (e *tenantExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	var defaults []sdktrace.ReadOnlySpan
+	byTenant := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, s := range spans {
+		tenant := spanTenant(s)
+		if _, ok := e.tokens[tenant]; !ok {
+			defaults = append(defaults, s)
+			continue
+		}
+		byTenant[tenant] = append(byTenant[tenant], s)
+	}
+
+	var errs []error
+	if len(defaults) > 0 {
+		errs = append(errs, e.SpanExporter.ExportSpans(ctx, defaults))
+	}
+	for tenant, spans := range byTenant {
+		exporter, err := e.exporter(tenant)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := exporter.ExportSpans(ctx, spans); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %q: %w", tenant, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// exporter returns the exporter of tenant, creating it on first use.
+func (e *tenantExporter) exporter(tenant string) (sdktrace.SpanExporter, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if exporter, ok := e.exporters[tenant]; ok {
+		return exporter, nil
+	}
+	tenantConfig := *e.config
+	tenantConfig.APIToken = e.tokens[tenant]
+	exporter, err := otlptracehttp.New(context.Background(), traceExporterOptions(&tenantConfig)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exporter for tenant %q: %w", tenant, err)
+	}
+	e.exporters[tenant] = exporter
+	return exporter, nil
+}
+
+// Shutdown shuts down the default exporter, and the exporter of every tenant.
+func (e *tenantExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	errs := []error{e.SpanExporter.Shutdown(ctx)}
+	for _, exporter := range e.exporters {
+		errs = append(errs, exporter.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
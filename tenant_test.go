@@ -0,0 +1,96 @@
+package logfire
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+type tenantCtxKey struct{}
+
+func routeTenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantCtxKey{}).(string)
+	return tenant
+}
+
+func TestTenantProcessorInheritsParentTenant(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&tenantProcessor{route: routeTenant}),
+		sdktrace.WithSyncer(exporter),
+	)
+	defer tp.Shutdown(context.Background())
+	tracer := tp.Tracer("test")
+
+	_, request := tracer.Start(context.WithValue(context.Background(), tenantCtxKey{}, "acme"), "request")
+	// The query runs in a context holding only the span, without the tenant, and still
+	// belongs to acme.
+	_, query := tracer.Start(oteltrace.ContextWithSpan(context.Background(), request), "query")
+	query.End()
+	request.End()
+	_, other := tracer.Start(context.Background(), "background job")
+	other.End()
+
+	tenants := map[string]string{}
+	for _, s := range exporter.GetSpans().Snapshots() {
+		tenants[s.Name()] = spanTenant(s)
+	}
+	if tenants["request"] != "acme" || tenants["query"] != "acme" || tenants["background job"] != "" {
+		t.Errorf("tenants = %v, want acme for the request and its child only", tenants)
+	}
+}
+
+func TestTenantExporterRoutesByToken(t *testing.T) {
+	var mu sync.Mutex
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	defaults := tracetest.NewInMemoryExporter()
+	e := newTenantExporter(defaults, &config{
+		Endpoint:     server.URL,
+		APIToken:     "default-token",
+		TenantTokens: map[string]string{"acme": "acme-token", "globex": "globex-token"},
+	})
+	defer e.Shutdown(context.Background())
+
+	span := func(name string, id byte, tenant string) sdktrace.ReadOnlySpan {
+		stub := tracetest.SpanStubFromReadOnlySpan(testSpan(name, id, 0))
+		if tenant != "" {
+			stub.Attributes = append(stub.Attributes, tenantKey.String(tenant))
+		}
+		return stub.Snapshot()
+	}
+	err := e.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{
+		span("acme", 1, "acme"),
+		span("globex", 2, "globex"),
+		span("unknown tenant", 3, "initech"),
+		span("no tenant", 4, ""),
+	})
+	if err != nil {
+		t.Fatalf("ExportSpans failed: %v", err)
+	}
+
+	var names []string
+	for _, s := range defaults.GetSpans() {
+		names = append(names, s.Name)
+	}
+	if len(names) != 2 || names[0] != "unknown tenant" || names[1] != "no tenant" {
+		t.Errorf("the default exporter got %v, want the spans without a known tenant", names)
+	}
+	sort.Strings(tokens)
+	if len(tokens) != 2 || tokens[0] != "Bearer acme-token" || tokens[1] != "Bearer globex-token" {
+		t.Errorf("tenant projects got tokens %v, want one export with each tenant's token", tokens)
+	}
+}
@@ -0,0 +1,148 @@
+package logfire
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// SetTraceAttributes sets attributes on every span of the trace in ctx.  Spans of the
+// trace that are still open get the attributes immediately, and spans started later
+// get them when they start.
+//
+// Attributes are kept for as long as the trace has open spans in this process.
+func SetTraceAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
+	if globalTraceAttributes == nil {
+		oteltrace.SpanFromContext(ctx).SetAttributes(attrs...)
+		return
+	}
+	globalTraceAttributes.set(ctx, attrs)
+}
+
+// traceState is the trace attributes and the open spans of a single trace.
+type traceState struct {
+	attrs []attribute.KeyValue
+	open  map[oteltrace.SpanID]sdktrace.ReadWriteSpan
+}
+
+// traceAttributesProcessor is a SpanProcessor that tracks the open spans of each trace,
+// so that attributes set with SetTraceAttributes can be applied to all of them.
+type traceAttributesProcessor struct {
+	mu     sync.Mutex
+	traces map[oteltrace.TraceID]*traceState
+}
+
+var _ sdktrace.SpanProcessor = (*traceAttributesProcessor)(nil)
+
+func newTraceAttributesProcessor() *traceAttributesProcessor {
+	return &traceAttributesProcessor{
+		traces: make(map[oteltrace.TraceID]*traceState),
+	}
+}
+
+// OnStart applies the trace attributes to the span, and tracks it until it ends.
+func (p *traceAttributesProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	sc := s.SpanContext()
+
+	p.mu.Lock()
+	state, ok := p.traces[sc.TraceID()]
+	if !ok {
+		state = &traceState{open: make(map[oteltrace.SpanID]sdktrace.ReadWriteSpan)}
+		p.traces[sc.TraceID()] = state
+	}
+	state.open[sc.SpanID()] = s
+	attrs := state.attrs
+	p.mu.Unlock()
+
+	if len(attrs) > 0 {
+		s.SetAttributes(attrs...)
+	}
+}
+
+// OnEnd stops tracking the span, and forgets the trace once it has no open spans.
+func (p *traceAttributesProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	sc := s.SpanContext()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.traces[sc.TraceID()]
+	if !ok {
+		return
+	}
+	delete(state.open, sc.SpanID())
+	if len(state.open) == 0 {
+		delete(p.traces, sc.TraceID())
+	}
+}
+
+// Shutdown does nothing.
+func (p *traceAttributesProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush does nothing.
+func (p *traceAttributesProcessor) ForceFlush(context.Context) error { return nil }
+
+func (p *traceAttributesProcessor) set(ctx context.Context, attrs []attribute.KeyValue) {
+	span := oteltrace.SpanFromContext(ctx)
+	traceID := span.SpanContext().TraceID()
+
+	p.mu.Lock()
+	state, ok := p.traces[traceID]
+	if !ok {
+		p.mu.Unlock()
+		// The trace has no open spans in this process, there is nothing to attach to.
+		span.SetAttributes(attrs...)
+		return
+	}
+	state.attrs = mergeAttributes(state.attrs, attrs)
+	open := make([]sdktrace.ReadWriteSpan, 0, len(state.open))
+	for _, s := range state.open {
+		open = append(open, s)
+	}
+	p.mu.Unlock()
+
+	for _, s := range open {
+		s.SetAttributes(attrs...)
+	}
+}
+
+// openSpans returns up to limit spans that are currently open.
+func (p *traceAttributesProcessor) openSpans(limit int) []sdktrace.ReadWriteSpan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var open []sdktrace.ReadWriteSpan
+	for _, state := range p.traces {
+		for _, s := range state.open {
+			if len(open) >= limit {
+				return open
+			}
+			open = append(open, s)
+		}
+	}
+	return open
+}
+
+// mergeAttributes returns attrs with updates applied, replacing attributes that share
+// a key.
+func mergeAttributes(attrs, updates []attribute.KeyValue) []attribute.KeyValue {
+	merged := make([]attribute.KeyValue, 0, len(attrs)+len(updates))
+	merged = append(merged, attrs...)
+	for _, update := range updates {
+		replaced := false
+		for i := range merged {
+			if merged[i].Key == update.Key {
+				merged[i] = update
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, update)
+		}
+	}
+	return merged
+}
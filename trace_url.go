@@ -0,0 +1,27 @@
+package logfire
+
+import (
+	"context"
+	"net/url"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// TraceURL returns the link to the trace in ctx in the Logfire UI, so error responses,
+// alerts and CLI output can link to it.  It returns "" if there is no trace in ctx, or
+// no project is configured with WithProject or WithProjectURL.
+//
+//	http.Error(w, "internal error, see "+logfire.TraceURL(r.Context()), http.StatusInternalServerError)
+func TraceURL(ctx context.Context) string {
+	sc := oteltrace.SpanContextFromContext(ctx)
+	if globalProjectURL == "" || !sc.IsValid() {
+		return ""
+	}
+	return traceURL(globalProjectURL, sc.TraceID())
+}
+
+// traceURL returns the URL of the trace in the Logfire project.
+func traceURL(projectURL string, traceID oteltrace.TraceID) string {
+	query := url.Values{"q": {"trace_id='" + traceID.String() + "'"}}
+	return projectURL + "?" + query.Encode()
+}
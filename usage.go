@@ -0,0 +1,180 @@
+package logfire
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Stats describes the telemetry exported to Logfire since Initialize.
+type Stats struct {
+	// ExportedSpans is the number of spans and logs exported.
+	ExportedSpans uint64
+	// ExportedBytes is the approximate encoded size of the exported spans.  Diff it
+	// between calls to get the bytes exported per interval.
+	ExportedBytes uint64
+	// BytesToday is the approximate encoded size of the spans exported today, in UTC.
+	BytesToday uint64
+	// DroppedSpans is the number of spans dropped because an export queue was full.
+	DroppedSpans uint64
+	// QueueUtilization is the utilization of the fullest export queue, from 0 to 1.
+	QueueUtilization float64
+	// BudgetExceeded is true when the daily byte budget is exceeded, and only errors
+	// are exported.
+	BudgetExceeded bool
+}
+
+// GetStats returns the stats of the telemetry exported to Logfire.  It returns zero
+// stats before Initialize is called.
+func GetStats() Stats {
+	if globalUsage == nil || globalExportQueue == nil {
+		return Stats{}
+	}
+	stats := globalUsage.stats()
+	for _, s := range globalExportQueue.stats() {
+		stats.DroppedSpans += s.Dropped
+	}
+	stats.QueueUtilization = globalExportQueue.utilization()
+	return stats
+}
+
+// usageExporter counts the spans and bytes exported by the Logfire exporter, and
+// enforces the daily byte budget.  It only wraps the Logfire exporter, so the budget
+// doesn't limit additional exporters.
+type usageExporter struct {
+	sdktrace.SpanExporter
+	budget uint64
+	clock  Clock
+
+	mu         sync.Mutex
+	spans      uint64
+	bytes      uint64
+	day        time.Time
+	bytesToday uint64
+}
+
+func newUsageExporter(exporter sdktrace.SpanExporter, budget uint64, clock Clock) *usageExporter {
+	return &usageExporter{
+		SpanExporter: exporter,
+		budget:       budget,
+		clock:        clock,
+	}
+}
+
+// ExportSpans exports the spans, only the errors once the budget is exceeded, and counts
+// them if the export succeeds.
+func (e *usageExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if e.exceeded() {
+		spans = slices.DeleteFunc(slices.Clone(spans), func(s sdktrace.ReadOnlySpan) bool {
+			return !isError(s)
+		})
+		if len(spans) == 0 {
+			return nil
+		}
+	}
+	if err := e.SpanExporter.ExportSpans(ctx, spans); err != nil {
+		return err
+	}
+	var size uint64
+	for _, s := range spans {
+		size += uint64(estimateSpanSize(s))
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rollOverLocked()
+	e.spans += uint64(len(spans))
+	e.bytes += size
+	e.bytesToday += size
+	return nil
+}
+
+// rollOverLocked resets the bytes of today when the day changes.  e.mu must be held.
+func (e *usageExporter) rollOverLocked() {
+	today := e.clock.Now().UTC().Truncate(24 * time.Hour)
+	if !today.Equal(e.day) {
+		e.day = today
+		e.bytesToday = 0
+	}
+}
+
+// exceeded reports whether the daily byte budget is exceeded.
+func (e *usageExporter) exceeded() bool {
+	if e == nil || e.budget == 0 {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rollOverLocked()
+	return e.bytesToday >= e.budget
+}
+
+func (e *usageExporter) stats() Stats {
+	exceeded := e.exceeded()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return Stats{
+		ExportedSpans:  e.spans,
+		ExportedBytes:  e.bytes,
+		BytesToday:     e.bytesToday,
+		BudgetExceeded: exceeded,
+	}
+}
+
+// isError reports whether the span failed, or is a log of at least error severity.
+func isError(s sdktrace.ReadOnlySpan) bool {
+	if s.Status().Code == codes.Error {
+		return true
+	}
+	for _, kv := range s.Attributes() {
+		if kv.Key == "logfire.level_num" {
+			return kv.Value.AsInt64() >= int64(LevelError)
+		}
+	}
+	return false
+}
+
+// estimateSpanSize approximates the size of the span once encoded as OTLP.
+func estimateSpanSize(s sdktrace.ReadOnlySpan) int {
+	// Trace, span and parent IDs, timestamps, kind, status and field tags.
+	size := 16 + 8 + 8 + 8 + 8 + 16 + len(s.Name()) + len(s.Status().Description)
+	size += estimateAttributesSize(s.Attributes())
+	for _, e := range s.Events() {
+		size += 16 + len(e.Name) + estimateAttributesSize(e.Attributes)
+	}
+	for _, l := range s.Links() {
+		size += 16 + 8 + estimateAttributesSize(l.Attributes)
+	}
+	return size
+}
+
+func estimateAttributesSize(attrs []attribute.KeyValue) int {
+	var size int
+	for _, kv := range attrs {
+		// Tags and lengths of the key and value.
+		size += 4 + len(kv.Key)
+		switch kv.Value.Type() {
+		case attribute.STRING:
+			size += len(kv.Value.AsString())
+		case attribute.STRINGSLICE:
+			for _, v := range kv.Value.AsStringSlice() {
+				size += 2 + len(v)
+			}
+		case attribute.BOOLSLICE:
+			size += len(kv.Value.AsBoolSlice())
+		case attribute.INT64SLICE:
+			size += 8 * len(kv.Value.AsInt64Slice())
+		case attribute.FLOAT64SLICE:
+			size += 8 * len(kv.Value.AsFloat64Slice())
+		default:
+			size += 8
+		}
+	}
+	return size
+}
@@ -0,0 +1,58 @@
+package logfire
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestUsageExporterBudget(t *testing.T) {
+	logfire := tracetest.NewInMemoryExporter()
+	e := newUsageExporter(logfire, 1, fixedClock{now: time.Unix(0, 0)})
+
+	if err := e.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{testSpan("first", 1, 0)}); err != nil {
+		t.Fatalf("ExportSpans failed: %v", err)
+	}
+	if !e.exceeded() {
+		t.Fatalf("budget of 1 byte not exceeded after exporting a span")
+	}
+
+	failed := tracetest.SpanStubFromReadOnlySpan(testSpan("failed", 3, 0))
+	failed.Status = sdktrace.Status{Code: codes.Error}
+	if err := e.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{testSpan("second", 2, 0), failed.Snapshot()}); err != nil {
+		t.Fatalf("ExportSpans failed: %v", err)
+	}
+	var names []string
+	for _, s := range logfire.GetSpans() {
+		names = append(names, s.Name)
+	}
+	if len(names) != 2 || names[1] != "failed" {
+		t.Errorf("exported %v, want only the failed span after the budget was exceeded", names)
+	}
+	if got := e.stats().ExportedSpans; got != 2 {
+		t.Errorf("ExportedSpans = %d, want 2", got)
+	}
+}
+
+func TestBudgetOnlyLimitsLogfire(t *testing.T) {
+	logfire, additional := tracetest.NewInMemoryExporter(), tracetest.NewInMemoryExporter()
+	usage := newUsageExporter(logfire, 1, fixedClock{now: time.Unix(0, 0)})
+	f := newFanoutProcessor(DropNewest, usage, additional)
+	defer f.Shutdown(context.Background())
+	for i := range 3 {
+		f.OnEnd(testSpan("span", byte(i+1), 0))
+		if err := f.ForceFlush(context.Background()); err != nil {
+			t.Fatalf("ForceFlush failed: %v", err)
+		}
+	}
+	if got := len(logfire.GetSpans()); got != 1 {
+		t.Errorf("Logfire got %d spans, want 1 before the budget was exceeded", got)
+	}
+	if got := len(additional.GetSpans()); got != 3 {
+		t.Errorf("the additional exporter got %d spans, want all 3", got)
+	}
+}
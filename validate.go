@@ -0,0 +1,190 @@
+package logfire
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// validate checks the config, returning every problem found so they can all be fixed at
+// once, rather than one at a time or by deciphering errors from the exporter.
+func (c *config) validate() []error {
+	var errs []error
+	if c.APIToken == "" && !c.GenericOTLP && c.SpanExporter == nil {
+		errs = append(errs, errors.New("config.APIToken is required"))
+	}
+	if err := validateToken(c.APIToken); err != nil {
+		errs = append(errs, fmt.Errorf("invalid API token: %w", err))
+	}
+	for tenant, token := range c.TenantTokens {
+		if err := validateToken(token); err != nil {
+			errs = append(errs, fmt.Errorf("invalid token for tenant %q: %w", tenant, err))
+		}
+	}
+	if err := validateServiceName(c.ServiceName); err != nil {
+		errs = append(errs, fmt.Errorf("invalid service name %q: %w", c.ServiceName, err))
+	}
+	if err := validateURL(c.Endpoint); err != nil {
+		errs = append(errs, fmt.Errorf("invalid endpoint %q: %w", c.Endpoint, err))
+	} else if err := validateEndpointHost(c.Endpoint); err != nil {
+		errs = append(errs, fmt.Errorf("invalid endpoint %q: %w", c.Endpoint, err))
+	}
+	if c.ProjectURL != "" {
+		if err := validateURL(c.ProjectURL); err != nil {
+			errs = append(errs, fmt.Errorf("invalid project URL %q: %w", c.ProjectURL, err))
+		}
+	}
+	if c.FatalNotifierURL != "" {
+		if err := validateURL(c.FatalNotifierURL); err != nil {
+			errs = append(errs, fmt.Errorf("invalid fatal notifier URL %q: %w", c.FatalNotifierURL, err))
+		}
+	}
+	for route, rate := range c.RouteSampling {
+		if err := validateRate(rate); err != nil {
+			errs = append(errs, fmt.Errorf("invalid sampling rate for %q: %w", route, err))
+		}
+	}
+	if s := c.ScheduleSampling; s != nil {
+		if err := validateRate(s.Rate); err != nil {
+			errs = append(errs, fmt.Errorf("invalid schedule sampling rate: %w", err))
+		}
+		if err := validateRate(s.DefaultRate); err != nil {
+			errs = append(errs, fmt.Errorf("invalid schedule sampling default rate: %w", err))
+		}
+	}
+	if w := c.WarmupSampling; w != nil {
+		if err := validateRate(w.Rate); err != nil {
+			errs = append(errs, fmt.Errorf("invalid warmup sampling rate: %w", err))
+		}
+	}
+	if c.TenantTokens != nil && c.TenantRouter == nil {
+		errs = append(errs, errors.New("tenant tokens are set without a tenant router"))
+	}
+	return errs
+}
+
+// validateToken checks that token can be sent in the Authorization header.
+func validateToken(token string) error {
+	if strings.TrimSpace(token) != token {
+		return errors.New("has leading or trailing whitespace")
+	}
+	for _, r := range token {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) || r == ' ' {
+			return fmt.Errorf("contains %q, tokens can only contain printable ASCII", r)
+		}
+	}
+	return nil
+}
+
+// validateServiceName checks that name is set and printable, so it displays correctly
+// in Logfire.
+func validateServiceName(name string) error {
+	if name == "" {
+		return errors.New("is required, set it with WithServiceName")
+	}
+	if !utf8.ValidString(name) {
+		return errors.New("is not valid UTF-8")
+	}
+	if strings.TrimSpace(name) == "" {
+		return errors.New("is only whitespace")
+	}
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return fmt.Errorf("contains %q, service names can only contain printable characters", r)
+		}
+	}
+	return nil
+}
+
+// validateURL checks that s is an absolute HTTP or HTTPS URL.
+func validateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("scheme must be http or https")
+	}
+	if u.Host == "" {
+		return errors.New("has no host")
+	}
+	return nil
+}
+
+// validateEndpointHost catches typos in the host of Logfire endpoints, such as
+// "logfire-api.pydantic.de", which would otherwise only fail when spans are exported.
+func validateEndpointHost(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	want, _ := url.Parse(DefaultEndpoint)
+	if host := u.Hostname(); host != want.Hostname() && editDistance(host, want.Hostname()) <= 2 {
+		return fmt.Errorf("host %q looks like a typo, did you mean %q?", host, want.Hostname())
+	}
+	return nil
+}
+
+// knownEnvVars are the LOGFIRE_ environment variables read by this module.
+var knownEnvVars = []string{
+	"LOGFIRE_TOKEN",
+	"LOGFIRE_CREDENTIALS",
+	"LOGFIRE_GENERIC_OTLP",
+	"LOGFIRE_RELAY_SECRET",
+	"LOGFIRE_TRACEPARENT",
+	"LOGFIRE_TRACESTATE",
+	"LOGFIRE_UPDATE_GOLDEN",
+}
+
+// envTypos returns an error for every LOGFIRE_ variable in environ, in the format of
+// os.Environ, that isn't known but is close to one that is, e.g. LOGFIRE_TOKN.  These are
+// only warnings, because the variable may be read by something other than this module.
+func envTypos(environ []string) []error {
+	var errs []error
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, "LOGFIRE_") || slices.Contains(knownEnvVars, name) {
+			continue
+		}
+		for _, known := range knownEnvVars {
+			if editDistance(name, known) <= 2 {
+				errs = append(errs, fmt.Errorf("unknown environment variable %s, did you mean %s?", name, known))
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// editDistance returns the Levenshtein distance between a and b, in bytes.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// validateRate checks that rate is a sampling ratio, between 0 and 1.
+func validateRate(rate float64) error {
+	if !(rate >= 0 && rate <= 1) {
+		return fmt.Errorf("%v is not between 0 and 1", rate)
+	}
+	return nil
+}
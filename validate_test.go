@@ -0,0 +1,177 @@
+package logfire
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestValidateServiceName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"checkout", true},
+		{"café api", true},
+		{"", false},
+		{"   ", false},
+		{"bad\nname", false},
+		{"bad\xffname", false},
+	}
+	for _, tt := range tests {
+		if err := validateServiceName(tt.name); (err == nil) != tt.valid {
+			t.Errorf("validateServiceName(%q) = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	tests := []struct {
+		token string
+		valid bool
+	}{
+		{"pylf_v1_us_abc123", true},
+		{"", true},
+		{" pylf_v1_us_abc123", false},
+		{"pylf_v1_us_abc123\n", false},
+		{"pylf v1", false},
+		{"pylf_v1_üs", false},
+	}
+	for _, tt := range tests {
+		if err := validateToken(tt.token); (err == nil) != tt.valid {
+			t.Errorf("validateToken(%q) = %v, want valid %v", tt.token, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{DefaultEndpoint, true},
+		{"https://logfire-eu.pydantic.dev/v1", true},
+		{"http://localhost:4318", true},
+		{"logfire-api.pydantic.dev", false},
+		{"ftp://logfire-api.pydantic.dev", false},
+		{"https://logfire-api.pydantic.de/v1", false},
+		{"https://logfire-apii.pydantic.dev/v1", false},
+	}
+	for _, tt := range tests {
+		err := validateURL(tt.endpoint)
+		if err == nil {
+			err = validateEndpointHost(tt.endpoint)
+		}
+		if (err == nil) != tt.valid {
+			t.Errorf("validating endpoint %q = %v, want valid %v", tt.endpoint, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateRate(t *testing.T) {
+	for _, rate := range []float64{0, 0.5, 1} {
+		if err := validateRate(rate); err != nil {
+			t.Errorf("validateRate(%v) = %v, want nil", rate, err)
+		}
+	}
+	for _, rate := range []float64{-0.1, 1.1} {
+		if err := validateRate(rate); err == nil {
+			t.Errorf("validateRate(%v) = nil, want an error", rate)
+		}
+	}
+}
+
+func TestEnvTypos(t *testing.T) {
+	errs := envTypos([]string{
+		"LOGFIRE_TOKN=abc",
+		"LOGFIRE_TOKEN=abc",
+		"LOGFIRE_CREDENTIAL=/tmp/creds",
+		"LOGFIRE_DEPLOYMENT_COLOR=blue",
+		"LOGFIRE_TOKENS",
+		"PATH=/bin",
+	})
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"unknown environment variable LOGFIRE_TOKN, did you mean LOGFIRE_TOKEN?",
+		"unknown environment variable LOGFIRE_CREDENTIAL, did you mean LOGFIRE_CREDENTIALS?",
+		"unknown environment variable LOGFIRE_TOKENS, did you mean LOGFIRE_TOKEN?",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("envTypos() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"token", "token", 0},
+		{"tokn", "token", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	c := &config{
+		APIToken:       "pylf token",
+		ServiceName:    "",
+		Endpoint:       "localhost",
+		RouteSampling:  map[string]float64{"/health": 2},
+		WarmupSampling: &warmupSampling{N: 10, Rate: -1},
+		TenantTokens:   map[string]string{"acme": "t"},
+	}
+	errs := c.validate()
+	for _, want := range []string{
+		"invalid API token",
+		"invalid service name",
+		"invalid endpoint",
+		`invalid sampling rate for "/health"`,
+		"invalid warmup sampling rate",
+		"tenant tokens are set without a tenant router",
+	} {
+		found := false
+		for _, err := range errs {
+			found = found || strings.Contains(err.Error(), want)
+		}
+		if !found {
+			t.Errorf("validate() = %v, want an error containing %q", errs, want)
+		}
+	}
+
+	// Misspelled environment variables are only logged.
+	t.Setenv("LOGFIRE_TOKN", "abc")
+	valid := &config{APIToken: "pylf_v1_us_abc", ServiceName: "checkout", Endpoint: DefaultEndpoint}
+	if errs := valid.validate(); len(errs) != 0 {
+		t.Errorf("validate() of a valid config = %v, want no errors", errs)
+	}
+}
+
+func TestInitializeDebugSocketErrorKeepsState(t *testing.T) {
+	before := globalServiceName
+	_, err := Initialize(context.Background(),
+		WithServiceName("debug-socket-test"),
+		WithSpanExporter(tracetest.NewInMemoryExporter()),
+		WithDebugSocket(filepath.Join(t.TempDir(), "missing", "debug.sock")),
+	)
+	if err == nil {
+		t.Fatal("Initialize with an unusable debug socket succeeded, want an error")
+	}
+	if globalServiceName != before {
+		t.Errorf("globalServiceName = %q after a failed Initialize, want %q", globalServiceName, before)
+	}
+}
@@ -0,0 +1,152 @@
+package logfire
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultWatchdogInterval = 10 * time.Second
+	schedLatenciesMetric    = "/sched/latencies:seconds"
+	// maxWatchdogOpenSpans is the maximum number of open spans listed in a warning.
+	maxWatchdogOpenSpans = 50
+)
+
+// Watchdog configures the thresholds of WithWatchdog.  A zero threshold is not checked.
+type Watchdog struct {
+	// Interval is how often goroutines and scheduler latency are sampled.  Defaults to
+	// 10 seconds.
+	Interval time.Duration
+	// MaxGoroutines is the number of goroutines above which a warning is logged.
+	MaxGoroutines int
+	// MaxSchedulerLatency is the p99 time goroutines wait to be scheduled, over an
+	// interval, above which a warning is logged.
+	MaxSchedulerLatency time.Duration
+}
+
+// startWatchdog samples goroutines and scheduler latency every interval, until the
+// returned function is called.  A warning is logged once when a threshold is exceeded,
+// and again only after it has recovered.
+func startWatchdog(w Watchdog) func() {
+	if w.Interval <= 0 {
+		w.Interval = defaultWatchdogInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		latencies := []metrics.Sample{{Name: schedLatenciesMetric}}
+		metrics.Read(latencies)
+		previous := copyHistogram(latencies[0].Value)
+
+		var tooManyGoroutines, tooSlow bool
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			goroutines := runtime.NumGoroutine()
+			exceeded := w.MaxGoroutines > 0 && goroutines > w.MaxGoroutines
+			if exceeded && !tooManyGoroutines {
+				warnWithDump("too many goroutines",
+					attribute.Int("watchdog.max_goroutines", w.MaxGoroutines),
+				)
+			}
+			tooManyGoroutines = exceeded
+
+			metrics.Read(latencies)
+			current := copyHistogram(latencies[0].Value)
+			p99 := histogramQuantile(previous, current, 0.99)
+			previous = current
+			exceeded = w.MaxSchedulerLatency > 0 && p99 > w.MaxSchedulerLatency
+			if exceeded && !tooSlow {
+				warnWithDump("scheduler latency is high",
+					Attr("watchdog.scheduler_latency_p99_ms", p99),
+					Attr("watchdog.max_scheduler_latency_ms", w.MaxSchedulerLatency),
+				)
+			}
+			tooSlow = exceeded
+		}
+	}()
+	return cancel
+}
+
+// warnWithDump logs a Warn with a goroutine dump and the spans that are currently open,
+// so the warning can be correlated with the work in progress.
+func warnWithDump(msg string, attrs ...attribute.KeyValue) {
+	attrs = append(attrs, goroutineDumpAttributes()...)
+	attrs = append(attrs, openSpanAttributes()...)
+	Warn(msg, WithAttributes(attrs...))
+}
+
+// openSpanAttributes lists the names and trace IDs of the spans that are currently
+// open, as watchdog.open_spans and watchdog.open_trace_ids.
+func openSpanAttributes() []attribute.KeyValue {
+	if globalTraceAttributes == nil {
+		return nil
+	}
+	var names, traceIDs []string
+	seen := make(map[string]bool)
+	for _, s := range globalTraceAttributes.openSpans(maxWatchdogOpenSpans) {
+		names = append(names, s.Name())
+		traceID := s.SpanContext().TraceID().String()
+		if !seen[traceID] {
+			seen[traceID] = true
+			traceIDs = append(traceIDs, traceID)
+		}
+	}
+	return []attribute.KeyValue{
+		attribute.StringSlice("watchdog.open_spans", names),
+		attribute.StringSlice("watchdog.open_trace_ids", traceIDs),
+	}
+}
+
+// copyHistogram copies a runtime/metrics histogram, whose buckets are reused between
+// reads.  A value that isn't a histogram returns nil.
+func copyHistogram(v metrics.Value) *metrics.Float64Histogram {
+	if v.Kind() != metrics.KindFloat64Histogram {
+		return nil
+	}
+	h := v.Float64Histogram()
+	return &metrics.Float64Histogram{
+		Counts:  append([]uint64(nil), h.Counts...),
+		Buckets: h.Buckets,
+	}
+}
+
+// histogramQuantile estimates the quantile q of the values recorded between previous
+// and current, using the upper bound of the bucket it falls in.
+func histogramQuantile(previous, current *metrics.Float64Histogram, q float64) time.Duration {
+	if previous == nil || current == nil || len(previous.Counts) != len(current.Counts) {
+		return 0
+	}
+	var total uint64
+	for i := range current.Counts {
+		total += current.Counts[i] - previous.Counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	threshold := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i := range current.Counts {
+		seen += current.Counts[i] - previous.Counts[i]
+		if seen >= threshold {
+			upper := current.Buckets[i+1]
+			if math.IsInf(upper, 1) {
+				upper = current.Buckets[i]
+			}
+			return time.Duration(upper * float64(time.Second))
+		}
+	}
+	return 0
+}
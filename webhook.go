@@ -0,0 +1,29 @@
+package logfire
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TraceURLHeader is the header SignAndInject sets to the Logfire URL of the trace.
+const TraceURLHeader = "logfire-trace-url"
+
+// SignAndInject marks an outgoing webhook request with the trace that sent it, so the
+// receiver can link back to it.  The trace context is injected in the configured
+// propagation formats, e.g. traceparent, and if a project URL is configured with
+// WithProjectURL, the logfire-trace-url header links to the trace in Logfire.
+//
+// The trace is taken from the context of req:
+//
+//	req, _ := http.NewRequestWithContext(logger.Context(), "POST", hook.URL, body)
+//	logfire.SignAndInject(req)
+func SignAndInject(req *http.Request) {
+	ctx := req.Context()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	if u := TraceURL(ctx); u != "" {
+		req.Header.Set(TraceURLHeader, u)
+	}
+}
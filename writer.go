@@ -0,0 +1,122 @@
+package logfire
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// maxWriterLineSize is the longest line a LineWriter buffers before sending it as a
+// log anyway.
+const maxWriterLineSize = 64 * 1024
+
+// LineWriter is an io.Writer that sends every line written to it as a log.
+type LineWriter struct {
+	ctx     context.Context
+	level   Level
+	parsers []LineParser
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// WriterOption is a function type that modifies a LineWriter.
+type WriterOption func(*LineWriter)
+
+// WithLineParsers parses lines with the first parser that accepts them, so structured
+// output, e.g. JSON or logfmt, is logged with its own level, time and attributes.
+// Lines that no parser accepts are logged as they are.
+//
+//	cmd.Stderr = logfire.Writer(logfire.LevelInfo, logfire.WithLineParsers(logfire.ParseJSONLine, logfire.ParseKlogLine))
+func WithLineParsers(parsers ...LineParser) WriterOption {
+	return func(w *LineWriter) {
+		w.parsers = append(w.parsers, parsers...)
+	}
+}
+
+// Writer returns a LineWriter that logs every line written to it with the given level,
+// e.g. to capture the output of a subprocess:
+//
+//	cmd.Stdout = logfire.Writer(logfire.LevelInfo)
+//	cmd.Stderr = logfire.Writer(logfire.LevelWarn)
+func Writer(level Level, opts ...WriterOption) *LineWriter {
+	return globalLogger.Writer(level, opts...)
+}
+
+// Writer returns a LineWriter that logs every line written to it in the current span
+// context with the given level.
+func (s *SpanLogger) Writer(level Level, opts ...WriterOption) *LineWriter {
+	w := &LineWriter{
+		ctx:   s.spanCtx,
+		level: level,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Write logs every complete line in p.  Incomplete lines are buffered until the rest
+// of the line is written, or the writer is closed.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.send(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) >= maxWriterLineSize {
+		w.send(w.buf)
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Close logs any incomplete line that is still buffered.
+func (w *LineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.send(w.buf)
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *LineWriter) send(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if len(line) == 0 {
+		return
+	}
+	for _, parse := range w.parsers {
+		if parsed, ok := parse(string(line)); ok {
+			w.sendParsed(string(line), parsed)
+			return
+		}
+	}
+	sendLog(w.ctx, string(line), w.level, nil)
+}
+
+// sendParsed logs a line parsed by a LineParser.
+func (w *LineWriter) sendParsed(line string, parsed ParsedLine) {
+	level := parsed.Level
+	if level == 0 {
+		level = w.level
+	}
+	msg := parsed.Message
+	if msg == "" {
+		msg = line
+	}
+	opts := []SpanOption{WithAttributes(parsed.Attributes...)}
+	if !parsed.Time.IsZero() {
+		opts = append(opts, WithTimestamp(parsed.Time))
+	}
+	sendLog(w.ctx, msg, level, opts)
+}